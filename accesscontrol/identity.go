@@ -0,0 +1,66 @@
+package accesscontrol
+
+import (
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Identity is the identity of the creator of a transaction.
+type Identity struct {
+	// MSP is the MSP ID of the identity.
+	MSP string `json:"msp"`
+
+	// ID is the unique ID of the identity certificate, as returned by cid.GetID.
+	// It is empty for identities without a X509 certificate.
+	ID string `json:"id,omitempty"`
+
+	// OUs are the organizational units of the identity certificate.
+	OUs []string `json:"ous,omitempty"`
+
+	client *cid.ClientID
+}
+
+// GetIdentity returns the identity of the creator of the transaction.
+func GetIdentity(stub shim.ChaincodeStubInterface) (*Identity, error) {
+	client, err := cid.New(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "could not get tx creator identity")
+	}
+
+	mspID, err := client.GetMSPID()
+	if err != nil {
+		return nil, errors.WrapError(err, "could not get MSP id")
+	}
+
+	identity := &Identity{
+		MSP:    mspID,
+		client: client,
+	}
+
+	cert, _ := client.GetX509Certificate()
+	if cert != nil {
+		identity.ID, _ = client.GetID()
+		identity.OUs = cert.Subject.OrganizationalUnit
+	}
+
+	return identity, nil
+}
+
+// GetAttributeValue returns the value of an attribute of the identity and whether it was found.
+func (i *Identity) GetAttributeValue(name string) (string, bool, error) {
+	if i.client == nil {
+		return "", false, nil
+	}
+	return i.client.GetAttributeValue(name)
+}
+
+// HasOU returns true if the identity has the organizational unit.
+func (i *Identity) HasOU(ou string) bool {
+	for _, identityOU := range i.OUs {
+		if identityOU == ou {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package accesscontrol
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Policy decides if the creator of a transaction is authorized.
+type Policy interface {
+	Evaluate(stub shim.ChaincodeStubInterface) (bool, error)
+}
+
+// PolicyFunc is an adapter to use ordinary functions as policies.
+type PolicyFunc func(stub shim.ChaincodeStubInterface) (bool, error)
+
+// Evaluate calls f(stub).
+func (f PolicyFunc) Evaluate(stub shim.ChaincodeStubInterface) (bool, error) {
+	return f(stub)
+}
+
+// CallerPolicy is a policy which authorizes the callers allowed by AllowCaller.
+type CallerPolicy []Caller
+
+// Evaluate checks if the caller matches any of the caller rules.
+func (p CallerPolicy) Evaluate(stub shim.ChaincodeStubInterface) (bool, error) {
+	return AllowCaller(stub, p)
+}
+
+// policyRegistry is the map which should contain all named policies
+var policyRegistry = map[string]Policy{}
+
+// RegisterPolicy registers a policy to be referenced by name in transactions, events and asset types.
+func RegisterPolicy(name string, policy Policy) error {
+	if name == "" {
+		return errors.NewCCError("policy name cannot be empty", 500)
+	}
+	if policy == nil {
+		return errors.NewCCError(fmt.Sprintf("policy %s cannot be nil", name), 500)
+	}
+	if _, exists := policyRegistry[name]; exists {
+		return errors.NewCCError(fmt.Sprintf("policy %s is already registered", name), 500)
+	}
+
+	policyRegistry[name] = policy
+	return nil
+}
+
+// RegisterPolicyExpression parses a policy expression and registers it by name.
+func RegisterPolicyExpression(name, expr string) error {
+	policy, err := ParsePolicy(expr)
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, fmt.Sprintf("invalid expression for policy %s", name), 500)
+	}
+
+	return RegisterPolicy(name, policy)
+}
+
+// FetchPolicy returns the policy registered by name or nil if policy is not found.
+func FetchPolicy(name string) Policy {
+	return policyRegistry[name]
+}
+
+// PolicyNames returns the sorted names of the registered policies.
+func PolicyNames() []string {
+	names := make([]string, 0, len(policyRegistry))
+	for name := range policyRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckPolicy evaluates the policy registered by name. An empty name allows every caller.
+func CheckPolicy(stub shim.ChaincodeStubInterface, name string) (bool, error) {
+	if name == "" {
+		return true, nil
+	}
+
+	policy := FetchPolicy(name)
+	if policy == nil {
+		return false, errors.NewCCError(fmt.Sprintf("policy %s is not registered", name), 500)
+	}
+
+	allowed, err := policy.Evaluate(stub)
+	if err != nil {
+		return false, errors.WrapError(err, fmt.Sprintf("failed to evaluate policy %s", name))
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,727 @@
+package accesscontrol
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Expression is a policy written in the policy expression language. Expressions are
+// boolean expressions over the identity of the tx creator and the tx timestamp, e.g.
+//
+//	msp == "org1MSP" && (attr.role in ["admin", "auditor"] || ou == "finance")
+//
+// The identifiers are:
+//
+//	msp      MSP ID of the tx creator
+//	id       ID of the tx creator, as returned by cid.GetID
+//	ou       organizational units of the tx creator certificate
+//	attr.X   value of the certificate attribute X, e.g. attr.role or attr.hf.EnrollmentID
+//	now      tx timestamp, compared with RFC 3339 strings e.g. now < "2025-01-01T00:00:00Z"
+//	hour     UTC hour of the tx timestamp, from 0 to 23
+//	weekday  UTC weekday of the tx timestamp, from 0 (Sunday) to 6 (Saturday)
+//
+// Strings are either double-quoted with Go escapes or single-quoted without escapes.
+// The operators are, by increasing precedence, ||, &&, ! and the comparisons ==, !=,
+// <, <=, >, >=, in (membership in a list) and matches (regular expression match).
+// has(attr.X) checks if the attribute exists. Numeric comparisons parse attributes as
+// numbers. Comparisons with ou hold if they hold for any of the OUs, and comparisons with
+// a missing attribute are false, except for !=.
+type Expression struct {
+	source string
+	root   policyNode
+}
+
+// ParsePolicy parses a policy expression, returning an error if it is not valid.
+func ParsePolicy(expr string) (*Expression, error) {
+	tokens, err := lexPolicy(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &policyParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	if !root.boolean() {
+		return nil, errors.NewCCError("policy expression must be a boolean expression", 400)
+	}
+
+	return &Expression{source: expr, root: root}, nil
+}
+
+// Evaluate checks if the tx creator satisfies the expression.
+func (e *Expression) Evaluate(stub shim.ChaincodeStubInterface) (bool, error) {
+	value, err := e.root.eval(&policyEnv{stub: stub})
+	if err != nil {
+		return false, err
+	}
+
+	allowed, _ := value.(bool)
+	return allowed, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+/*****************************
+ Lexer
+*****************************/
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexPolicy(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(expr) && (isIdentStart(expr[i]) || isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, expr[start:i], start})
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			start := i
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, expr[start:i], start})
+		case c == '"':
+			start := i
+			for i++; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, errors.NewCCError(fmt.Sprintf("unterminated string at position %d", start), 400)
+			}
+			i++
+			text, err := strconv.Unquote(expr[start:i])
+			if err != nil {
+				return nil, errors.NewCCError(fmt.Sprintf("invalid string at position %d", start), 400)
+			}
+			tokens = append(tokens, token{tokenString, text, start})
+		case c == '\'':
+			start := i
+			end := strings.IndexByte(expr[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.NewCCError(fmt.Sprintf("unterminated string at position %d", start), 400)
+			}
+			i += end + 2
+			tokens = append(tokens, token{tokenString, expr[start+1 : i-1], start})
+		default:
+			if i+1 < len(expr) {
+				switch op := expr[i : i+2]; op {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, token{tokenOp, op, i})
+					i += 2
+					continue
+				}
+			}
+			switch c {
+			case '(', ')', '[', ']', ',', '!', '<', '>':
+				tokens = append(tokens, token{tokenOp, string(c), i})
+				i++
+			default:
+				return nil, errors.NewCCError(fmt.Sprintf("unexpected character %q at position %d", c, i), 400)
+			}
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(expr)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+/*****************************
+ Parser
+*****************************/
+
+type policyParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *policyParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *policyParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *policyParser) expect(text string) error {
+	tok := p.next()
+	if tok.kind != tokenOp || tok.text != text {
+		return p.errorf(tok, "expected %q", text)
+	}
+	return nil
+}
+
+func (p *policyParser) errorf(tok token, format string, args ...interface{}) error {
+	return errors.NewCCError(fmt.Sprintf(format, args...)+fmt.Sprintf(" at position %d", tok.pos), 400)
+}
+
+func (p *policyParser) parseOr() (policyNode, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *policyParser) parseAnd() (policyNode, error) {
+	return p.parseLogical("&&", p.parseNot)
+}
+
+func (p *policyParser) parseLogical(op string, parseOperand func() (policyNode, error)) (policyNode, error) {
+	left, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == tokenOp && tok.text == op; tok = p.peek() {
+		p.next()
+		right, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !left.boolean() || !right.boolean() {
+			return nil, p.errorf(tok, "operands of %s must be boolean expressions", op)
+		}
+		left = &logicalNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *policyParser) parseNot() (policyNode, error) {
+	tok := p.peek()
+	if tok.kind != tokenOp || tok.text != "!" {
+		return p.parseComparison()
+	}
+	p.next()
+
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if !operand.boolean() {
+		return nil, p.errorf(tok, "operand of ! must be a boolean expression")
+	}
+	return &notNode{operand: operand}, nil
+}
+
+func (p *policyParser) parseComparison() (policyNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenOp && (tok.text == "==" || tok.text == "!=" || tok.text == "<" || tok.text == "<=" || tok.text == ">" || tok.text == ">="):
+	case tok.kind == tokenIdent && (tok.text == "in" || tok.text == "matches"):
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &compareNode{op: tok.text, left: left, right: right}
+	switch tok.text {
+	case "matches":
+		pattern, ok := right.(*literalNode)
+		if !ok {
+			return nil, p.errorf(tok, "right operand of matches must be a string")
+		}
+		s, ok := pattern.value.(string)
+		if !ok {
+			return nil, p.errorf(tok, "right operand of matches must be a string")
+		}
+		node.re, err = regexp.Compile(s)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid regular expression %q", s)
+		}
+	case "in":
+		if literal, ok := right.(*literalNode); ok {
+			return nil, p.errorf(tok, "right operand of in must be a list, got %v", literal.value)
+		}
+	default:
+		// Timestamps are compared with literal times, which are validated beforehand
+		for _, operands := range [][2]policyNode{{left, right}, {right, left}} {
+			ident, isIdent := operands[0].(*identNode)
+			literal, isLiteral := operands[1].(*literalNode)
+			if isIdent && ident.name == "now" && isLiteral {
+				if _, err := toTime(literal.value); err != nil {
+					return nil, p.errorf(tok, "invalid time %q, expected RFC 3339 format", literal.value)
+				}
+			}
+		}
+	}
+
+	return node, nil
+}
+
+func (p *policyParser) parseOperand() (policyNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+		return &literalNode{value: number}, nil
+	case tokenOp:
+		switch tok.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			return p.parseList()
+		}
+	case tokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literalNode{value: tok.text == "true"}, nil
+		case "has":
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			attr := p.next()
+			if attr.kind != tokenIdent || !strings.HasPrefix(attr.text, "attr.") || len(attr.text) == len("attr.") {
+				return nil, p.errorf(attr, "has expects an attribute")
+			}
+			return &hasNode{attr: strings.TrimPrefix(attr.text, "attr.")}, p.expect(")")
+		case "msp", "id", "ou", "now", "hour", "weekday":
+			return &identNode{name: tok.text}, nil
+		}
+		if strings.HasPrefix(tok.text, "attr.") && len(tok.text) > len("attr.") {
+			return &attrNode{name: strings.TrimPrefix(tok.text, "attr.")}, nil
+		}
+		return nil, p.errorf(tok, "unknown identifier %q", tok.text)
+	}
+
+	if tok.kind == tokenEOF {
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *policyParser) parseList() (policyNode, error) {
+	list := &listNode{}
+	if tok := p.peek(); tok.kind == tokenOp && tok.text == "]" {
+		p.next()
+		return list, nil
+	}
+	for {
+		tok := p.peek()
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		literal, ok := item.(*literalNode)
+		if !ok {
+			return nil, p.errorf(tok, "list items must be literals")
+		}
+		list.items = append(list.items, literal.value)
+
+		tok = p.next()
+		if tok.kind == tokenOp && tok.text == "]" {
+			return list, nil
+		}
+		if tok.kind != tokenOp || tok.text != "," {
+			return nil, p.errorf(tok, "expected \",\" or \"]\"")
+		}
+	}
+}
+
+/*****************************
+ Evaluation
+*****************************/
+
+// policyEnv loads the identity of the tx creator and the tx timestamp when first needed.
+type policyEnv struct {
+	stub      shim.ChaincodeStubInterface
+	client    *cid.ClientID
+	timestamp *time.Time
+}
+
+func (e *policyEnv) clientID() (*cid.ClientID, error) {
+	if e.client == nil {
+		client, err := cid.New(e.stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "could not get tx creator identity")
+		}
+		e.client = client
+	}
+	return e.client, nil
+}
+
+func (e *policyEnv) txTimestamp() (time.Time, error) {
+	if e.timestamp == nil {
+		timestamp, err := e.stub.GetTxTimestamp()
+		if err != nil {
+			return time.Time{}, errors.WrapError(err, "could not get tx timestamp")
+		}
+		t := timestamp.AsTime().UTC()
+		e.timestamp = &t
+	}
+	return *e.timestamp, nil
+}
+
+type policyNode interface {
+	eval(env *policyEnv) (interface{}, error)
+	boolean() bool
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(*policyEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *literalNode) boolean() bool {
+	_, ok := n.value.(bool)
+	return ok
+}
+
+type listNode struct {
+	items []interface{}
+}
+
+func (n *listNode) eval(*policyEnv) (interface{}, error) {
+	return n.items, nil
+}
+
+func (n *listNode) boolean() bool {
+	return false
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(env *policyEnv) (interface{}, error) {
+	switch n.name {
+	case "now", "hour", "weekday":
+		timestamp, err := env.txTimestamp()
+		if err != nil {
+			return nil, err
+		}
+		switch n.name {
+		case "hour":
+			return float64(timestamp.Hour()), nil
+		case "weekday":
+			return float64(timestamp.Weekday()), nil
+		}
+		return timestamp, nil
+	}
+
+	client, err := env.clientID()
+	if err != nil {
+		return nil, err
+	}
+	switch n.name {
+	case "msp":
+		return client.GetMSPID()
+	case "id":
+		// Identities without a X509 certificate have no ID
+		id, err := client.GetID()
+		if err != nil {
+			return nil, nil
+		}
+		return id, nil
+	default: // ou
+		ous := []interface{}{}
+		cert, _ := client.GetX509Certificate()
+		if cert != nil {
+			for _, ou := range cert.Subject.OrganizationalUnit {
+				ous = append(ous, ou)
+			}
+		}
+		return ous, nil
+	}
+}
+
+func (n *identNode) boolean() bool {
+	return false
+}
+
+type attrNode struct {
+	name string
+}
+
+func (n *attrNode) eval(env *policyEnv) (interface{}, error) {
+	client, err := env.clientID()
+	if err != nil {
+		return nil, err
+	}
+	value, found, err := client.GetAttributeValue(n.name)
+	if err != nil {
+		return nil, errors.WrapError(err, fmt.Sprintf("could not get attribute %s", n.name))
+	}
+	if !found {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (n *attrNode) boolean() bool {
+	return false
+}
+
+type hasNode struct {
+	attr string
+}
+
+func (n *hasNode) eval(env *policyEnv) (interface{}, error) {
+	value, err := (&attrNode{name: n.attr}).eval(env)
+	return value != nil, err
+}
+
+func (n *hasNode) boolean() bool {
+	return true
+}
+
+type notNode struct {
+	operand policyNode
+}
+
+func (n *notNode) eval(env *policyEnv) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !value.(bool), nil
+}
+
+func (n *notNode) boolean() bool {
+	return true
+}
+
+type logicalNode struct {
+	op    string
+	left  policyNode
+	right policyNode
+}
+
+func (n *logicalNode) eval(env *policyEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit evaluation
+	if left.(bool) == (n.op == "||") {
+		return left, nil
+	}
+	return n.right.eval(env)
+}
+
+func (n *logicalNode) boolean() bool {
+	return true
+}
+
+type compareNode struct {
+	op    string
+	left  policyNode
+	right policyNode
+	re    *regexp.Regexp
+}
+
+func (n *compareNode) eval(env *policyEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "in":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, errors.NewCCError("right operand of in must be a list", 400)
+		}
+		return anyValue(left, func(value interface{}) (bool, error) {
+			for _, item := range list {
+				equal, err := compareValues("==", value, item)
+				if err != nil || equal {
+					return equal, err
+				}
+			}
+			return false, nil
+		})
+	case "matches":
+		return anyValue(left, func(value interface{}) (bool, error) {
+			s, ok := value.(string)
+			return ok && n.re.MatchString(s), nil
+		})
+	case "!=":
+		equal, err := anyValue(left, func(value interface{}) (bool, error) {
+			return compareValues("==", value, right)
+		})
+		return !equal, err
+	default:
+		return anyValue(left, func(value interface{}) (bool, error) {
+			return compareValues(n.op, value, right)
+		})
+	}
+}
+
+func (n *compareNode) boolean() bool {
+	return true
+}
+
+// anyValue checks if the condition holds for the value or for any of the values of a list.
+// Missing values never hold.
+func anyValue(value interface{}, condition func(interface{}) (bool, error)) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, item := range v {
+			holds, err := condition(item)
+			if err != nil || holds {
+				return holds, err
+			}
+		}
+		return false, nil
+	default:
+		return condition(value)
+	}
+}
+
+// compareValues compares two values with ==, <, <=, > or >=. Timestamps are compared with
+// RFC 3339 strings and numbers are compared with numeric strings.
+func compareValues(op string, left, right interface{}) (bool, error) {
+	_, leftIsTime := left.(time.Time)
+	_, rightIsTime := right.(time.Time)
+	if leftIsTime || rightIsTime {
+		leftTime, err := toTime(left)
+		if err != nil {
+			return false, err
+		}
+		rightTime, err := toTime(right)
+		if err != nil {
+			return false, err
+		}
+		return compareOrdered(op, leftTime.Compare(rightTime)), nil
+	}
+
+	_, leftIsNumber := left.(float64)
+	_, rightIsNumber := right.(float64)
+	if leftIsNumber || rightIsNumber {
+		leftNumber, leftOk := toNumber(left)
+		rightNumber, rightOk := toNumber(right)
+		if !leftOk || !rightOk {
+			return false, nil
+		}
+		switch {
+		case leftNumber < rightNumber:
+			return compareOrdered(op, -1), nil
+		case leftNumber > rightNumber:
+			return compareOrdered(op, 1), nil
+		}
+		return compareOrdered(op, 0), nil
+	}
+
+	leftString, leftOk := left.(string)
+	rightString, rightOk := right.(string)
+	if leftOk && rightOk {
+		return compareOrdered(op, strings.Compare(leftString, rightString)), nil
+	}
+
+	if op != "==" {
+		return false, errors.NewCCError(fmt.Sprintf("cannot compare %v and %v with %s", left, right, op), 400)
+	}
+	return left == right, nil
+}
+
+func compareOrdered(op string, cmp int) bool {
+	switch op {
+	case "==":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // >=
+		return cmp >= 0
+	}
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, errors.NewCCError(fmt.Sprintf("invalid time %q, expected RFC 3339 format", v), 400)
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.NewCCError(fmt.Sprintf("cannot compare %v with a time", value), 400)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
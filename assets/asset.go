@@ -37,6 +37,11 @@ func (a *Asset) UnmarshalJSON(jsonData []byte) error {
 // asset property is properly formatted and computes the asset's identifying
 // key on the ledger, storing it in the property "@key".
 func NewAsset(m map[string]interface{}) (a Asset, err errors.ICCError) {
+	return newAssetSkipping(m, nil)
+}
+
+// newAssetSkipping constructs an asset like NewAsset, without validating the props stored in the skipped collections.
+func newAssetSkipping(m map[string]interface{}, skipCollections map[string]bool) (a Asset, err errors.ICCError) {
 	if m == nil {
 		err = errors.NewCCError("cannot create asset from nil map", 500)
 		return
@@ -58,7 +63,7 @@ func NewAsset(m map[string]interface{}) (a Asset, err errors.ICCError) {
 	(a)["@key"] = key
 
 	// Filter, validate and convert props to proper format
-	err = a.ValidateProps()
+	err = a.validateProps(skipCollections)
 	if err != nil {
 		err = errors.WrapError(err, "format error")
 		return
@@ -96,6 +101,10 @@ func (a *Asset) injectMetadata(stub *sw.StubWrapper) errors.ICCError {
 	lastTxID := stub.Stub.GetTxID()
 	(*a)["@lastTxID"] = lastTxID
 
+	if assetTypeDef := a.Type(); assetTypeDef != nil && assetTypeDef.Version > 0 {
+		(*a)["@schemaVersion"] = assetTypeDef.Version
+	}
+
 	return nil
 }
 
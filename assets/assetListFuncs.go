@@ -30,6 +30,9 @@ func InitAssetList(l []AssetType) {
 	if GetEnabledDynamicAssetType() {
 		l = append(l, GetListAssetType())
 	}
+	if GetEnabledTxProposals() {
+		l = append(l, GetTxProposalAssetType())
+	}
 	assetTypeList = l
 }
 
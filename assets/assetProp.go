@@ -1,5 +1,7 @@
 package assets
 
+import "github.com/hyperledger-labs/cc-tools/accesscontrol"
+
 // AssetProp describes properties of each asset attribute
 type AssetProp struct {
 	// Tag is the string used to reference the prop in the Asset map
@@ -41,13 +43,32 @@ type AssetProp struct {
 	// check for a match with regular expression `org\dMSP`
 	Writers []string `json:"writers"`
 
+	// WriterCallers grants write access to callers matching the MSP, OU and attributes rules,
+	// in addition to the orgs in Writers.
+	// eg. []accesscontrol.Caller{{MSP: "org2MSP", Attributes: map[string]string{"role": "auditor"}}}
+	WriterCallers []accesscontrol.Caller `json:"writerCallers,omitempty"`
+
+	// Readers is an array of orgs that specify who can read the property, with the same
+	// syntax as Writers. When neither Readers nor ReaderCallers are set, everyone can read it.
+	// Properties the caller cannot read are removed from the responses of the read transactions.
+	Readers []string `json:"readers,omitempty"`
+
+	// ReaderCallers grants read access to callers matching the MSP, OU and attributes rules,
+	// in addition to the orgs in Readers.
+	ReaderCallers []accesscontrol.Caller `json:"readerCallers,omitempty"`
+
+	// Collection is the private collection the property is stored in. Properties without
+	// a collection are stored on the public ledger, under the same key. Only allowed in
+	// asset types which are not private.
+	Collection string `json:"collection,omitempty"`
+
 	// Validate is a function called when validating property format.
 	Validate func(interface{}) error `json:"-"`
 }
 
 // ToMap converts an AssetProp to a map[string]interface{}
 func (p AssetProp) ToMap() map[string]interface{} {
-	return map[string]interface{}{
+	m := map[string]interface{}{
 		"tag":          p.Tag,
 		"label":        p.Label,
 		"description":  p.Description,
@@ -57,7 +78,19 @@ func (p AssetProp) ToMap() map[string]interface{} {
 		"defaultValue": p.DefaultValue,
 		"dataType":     p.DataType,
 		"writers":      p.Writers,
+		"readers":      p.Readers,
 	}
+	if len(p.WriterCallers) > 0 {
+		m["writerCallers"] = p.WriterCallers
+	}
+	if len(p.ReaderCallers) > 0 {
+		m["readerCallers"] = p.ReaderCallers
+	}
+	if p.Collection != "" {
+		m["collection"] = p.Collection
+	}
+
+	return m
 }
 
 // AssetPropFromMap converts a map[string]interface{} to an AssetProp
@@ -82,6 +115,10 @@ func AssetPropFromMap(m map[string]interface{}) AssetProp {
 	if !ok {
 		readOnly = false
 	}
+	collection, ok := m["collection"].(string)
+	if !ok {
+		collection = ""
+	}
 
 	res := AssetProp{
 		Tag:          m["tag"].(string),
@@ -92,20 +129,81 @@ func AssetPropFromMap(m map[string]interface{}) AssetProp {
 		ReadOnly:     readOnly,
 		DefaultValue: m["defaultValue"],
 		DataType:     m["dataType"].(string),
+		Collection:   collection,
+	}
+
+	res.Writers = stringsFromArray(m["writers"])
+	res.Readers = stringsFromArray(m["readers"])
+
+	res.WriterCallers = callersFromArray(m["writerCallers"])
+	res.ReaderCallers = callersFromArray(m["readerCallers"])
+
+	return res
+}
+
+// stringsFromArray converts the string arrays of a map representation, which are arrays of
+// interfaces once decoded from JSON, to an array of strings. It returns nil if there are none.
+func stringsFromArray(v interface{}) []string {
+	if strs, ok := v.([]string); ok {
+		if len(strs) == 0 {
+			return nil
+		}
+		return strs
 	}
 
-	writers := make([]string, 0)
-	writersArr, ok := m["writers"].([]interface{})
-	if ok {
-		for _, w := range writersArr {
-			writers = append(writers, w.(string))
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil
+	}
+
+	strs := make([]string, 0, len(arr))
+	for _, s := range arr {
+		strs = append(strs, s.(string))
+	}
+
+	return strs
+}
+
+// callersFromArray converts the callers of a map representation, which are maps once
+// decoded from JSON, to an array of accesscontrol.Caller. It returns nil if there are none.
+func callersFromArray(v interface{}) []accesscontrol.Caller {
+	if callers, ok := v.([]accesscontrol.Caller); ok {
+		if len(callers) == 0 {
+			return nil
 		}
+		return callers
 	}
-	if len(writers) > 0 {
-		res.Writers = writers
+
+	callersArr, ok := v.([]interface{})
+	if !ok || len(callersArr) == 0 {
+		return nil
 	}
 
-	return res
+	callers := make([]accesscontrol.Caller, 0, len(callersArr))
+	for _, c := range callersArr {
+		callerMap, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		msp, _ := callerMap["msp"].(string)
+		ou, _ := callerMap["ou"].(string)
+		caller := accesscontrol.Caller{
+			MSP: msp,
+			OU:  ou,
+		}
+
+		attributes, ok := callerMap["attributes"].(map[string]interface{})
+		if ok && len(attributes) > 0 {
+			caller.Attributes = make(map[string]string)
+			for k, attr := range attributes {
+				caller.Attributes[k], _ = attr.(string)
+			}
+		}
+		callers = append(callers, caller)
+	}
+
+	return callers
 }
 
 // ArrayFromAssetPropList converts an array of AssetProp to an array of map[string]interface
@@ -1,6 +1,11 @@
 package assets
 
-import "strings"
+import (
+	"sort"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+)
 
 // AssetType is a list of all asset properties
 type AssetType struct {
@@ -25,6 +30,31 @@ type AssetType struct {
 	// check for a match with regular expression `org\dMSP`
 	Readers []string `json:"readers,omitempty"`
 
+	// WriterCallers restricts which callers can create or update assets of the type,
+	// in addition to the writers of each property. When nil, every caller can.
+	WriterCallers []accesscontrol.Caller `json:"writerCallers,omitempty"`
+
+	// DeleterCallers restricts which callers can delete assets of the type. When nil, every
+	// caller allowed to write all the properties of an asset can delete it.
+	DeleterCallers []accesscontrol.Caller `json:"deleterCallers,omitempty"`
+
+	// Policy is the name of a policy registered with accesscontrol.RegisterPolicy
+	// which callers must satisfy to create, update or delete assets of the type.
+	Policy string `json:"policy,omitempty"`
+
+	// Owned is a flag that indicates if the identity creating an asset of the type is
+	// recorded in its "@owner" property. Owned assets can only be updated or deleted by
+	// their owner, the admins of the owner's organization or the owner delegates.
+	Owned bool `json:"owned,omitempty"`
+
+	// OwnerDelegates lists the callers allowed to write owned assets of the type
+	// on behalf of their owners.
+	OwnerDelegates []accesscontrol.Caller `json:"ownerDelegates,omitempty"`
+
+	// LifecycleEvents is a flag that indicates if chaincode events are emitted when assets of
+	// the type are created, updated or deleted. See LifecycleEventPayload for their payload.
+	LifecycleEvents bool `json:"lifecycleEvents,omitempty"`
+
 	// Validate is a function called when validating asset as a whole.
 	Validate func(Asset) error `json:"-"`
 
@@ -34,6 +64,19 @@ type AssetType struct {
 	// Private collection name it belongs to. When empty and len(readers) > 0,
 	// Tag is considered instead
 	Collection string `json:"collection,omitempty"`
+
+	// Version is the current version of the asset type definition. When greater than 0,
+	// it is stamped in the "@schemaVersion" property of the assets written.
+	Version int `json:"version,omitempty"`
+
+	// Migrations maps each previous version to the function upgrading assets from it
+	// to the next version. Assets are upgraded by the migrateAssets transaction, and
+	// outdated assets are upgraded in memory when read.
+	Migrations map[int]Migration `json:"-"`
+
+	// WriteBack is a flag that indicates if outdated assets are persisted in the
+	// current version when updated. Otherwise they keep their version until migrated.
+	WriteBack bool `json:"writeBack,omitempty"`
 }
 
 // Keys returns a list of asset properties which are defined as primary keys. (IsKey == true)
@@ -88,6 +131,33 @@ func (t AssetType) IsPrivate() bool {
 	return len(t.Readers) > 0
 }
 
+// PropCollections returns the sorted names of the private collections the properties of
+// the asset type are stored in. Asset types with prop collections are hybrid: the other
+// properties are stored on the public ledger.
+func (t AssetType) PropCollections() []string {
+	if t.IsPrivate() {
+		return nil
+	}
+
+	collections := []string{}
+	collectionSet := map[string]struct{}{}
+	for _, prop := range t.Props {
+		if _, exists := collectionSet[prop.Collection]; exists || prop.Collection == "" {
+			continue
+		}
+		collectionSet[prop.Collection] = struct{}{}
+		collections = append(collections, prop.Collection)
+	}
+	sort.Strings(collections)
+
+	return collections
+}
+
+// IsHybrid returns true if some of the asset properties are stored in private collections.
+func (t AssetType) IsHybrid() bool {
+	return len(t.PropCollections()) > 0
+}
+
 // CollectionName returns the private collection name. Default is tag.
 func (t AssetType) CollectionName() string {
 	if t.Collection == "" {
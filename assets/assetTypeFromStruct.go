@@ -0,0 +1,220 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// AssetTypeOptions holds the asset type definitions which are not derived from struct fields.
+type AssetTypeOptions struct {
+	// Tag defaults to the struct name with its first letter in lower case
+	Tag string
+
+	// Label defaults to the struct name
+	Label string
+
+	Description string
+	Readers     []string
+	Collection  string
+	Validate    func(Asset) error
+	Dynamic     bool
+}
+
+// AssetTypeFromStruct builds an asset type from the fields of a struct. Each field becomes
+// a prop, configured by the following struct tags:
+//
+//	cctools:"<prop tag>[,key][,required][,readOnly][,omitempty][,dataType=<data type>]"
+//	label:"<label>"
+//	description:"<description>"
+//	writers:"<writer>[,<writer>...]" (or a JSON array, for writers containing commas)
+//	default:"<default value>" (JSON encoded, unless the field is a string)
+//
+// The prop tag follows the same rules as Encode and Decode. When dataType is not set, it is
+// inferred from the field type: strings are "string", integers are "integer", floats are
+// "number", booleans are "boolean", time.Time is "datetime", Key and Asset are "->@asset",
+// structs registered with RegisterAssetStruct are references to their asset types, other
+// structs and maps are "@object" and slices are arrays of their element data type.
+// Fields bound to internal props, such as "@key", are skipped.
+//
+// The struct is registered as the binding of the asset type, so it can be used with Encode
+// and Decode and referenced by the structs of asset types derived after it.
+func AssetTypeFromStruct(v interface{}, opts AssetTypeOptions) (AssetType, errors.ICCError) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return AssetType{}, errors.NewCCError(fmt.Sprintf("cannot derive asset type from %T: value must be a struct", v), 500)
+	}
+
+	assetType := AssetType{
+		Tag:         opts.Tag,
+		Label:       opts.Label,
+		Description: opts.Description,
+		Readers:     opts.Readers,
+		Collection:  opts.Collection,
+		Validate:    opts.Validate,
+		Dynamic:     opts.Dynamic,
+		Props:       []AssetProp{},
+	}
+	if assetType.Tag == "" {
+		name := []rune(t.Name())
+		if len(name) == 0 {
+			return AssetType{}, errors.NewCCError("asset type of anonymous struct must have a tag", 500)
+		}
+		name[0] = unicode.ToLower(name[0])
+		assetType.Tag = string(name)
+	}
+	if assetType.Label == "" {
+		assetType.Label = t.Name()
+	}
+	if assetType.Label == "" {
+		assetType.Label = assetType.Tag
+	}
+
+	for _, binding := range structBindings(t) {
+		if strings.HasPrefix(binding.propTag, "@") {
+			continue
+		}
+
+		prop, err := propFromField(t.Field(binding.index), binding)
+		if err != nil {
+			return AssetType{}, errors.WrapErrorWithStatus(err, fmt.Sprintf("invalid field %s of struct %s", binding.name, t), 500)
+		}
+		assetType.Props = append(assetType.Props, prop)
+	}
+
+	assetStructMap[t] = assetType.Tag
+
+	return assetType, nil
+}
+
+// propFromField builds the asset prop bound to a struct field
+func propFromField(field reflect.StructField, binding fieldBinding) (AssetProp, error) {
+	prop := AssetProp{
+		Tag:         binding.propTag,
+		Label:       field.Tag.Get("label"),
+		Description: field.Tag.Get("description"),
+	}
+	if prop.Label == "" {
+		prop.Label = field.Name
+	}
+
+	for _, opt := range binding.options {
+		switch {
+		case opt == "key":
+			prop.IsKey = true
+			prop.Required = true
+		case opt == "required":
+			prop.Required = true
+		case opt == "readOnly":
+			prop.ReadOnly = true
+		case strings.HasPrefix(opt, "dataType="):
+			prop.DataType = strings.TrimPrefix(opt, "dataType=")
+		case opt == "omitempty" || opt == "":
+		default:
+			return prop, fmt.Errorf("unknown option '%s'", opt)
+		}
+	}
+
+	if prop.DataType == "" {
+		dataType, err := inferDataType(field.Type)
+		if err != nil {
+			return prop, err
+		}
+		prop.DataType = dataType
+	}
+
+	var err error
+	if writers, hasWriters := field.Tag.Lookup("writers"); hasWriters {
+		prop.Writers, err = parseListTag(writers)
+		if err != nil {
+			return prop, fmt.Errorf("invalid writers: %w", err)
+		}
+	}
+
+	if readers, hasReaders := field.Tag.Lookup("readers"); hasReaders {
+		prop.Readers, err = parseListTag(readers)
+		if err != nil {
+			return prop, fmt.Errorf("invalid readers: %w", err)
+		}
+	}
+
+	if defaultValue, hasDefault := field.Tag.Lookup("default"); hasDefault {
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.String {
+			prop.DefaultValue = defaultValue
+		} else {
+			err = json.Unmarshal([]byte(defaultValue), &prop.DefaultValue)
+			if err != nil {
+				return prop, fmt.Errorf("invalid default value: %w", err)
+			}
+		}
+	}
+
+	return prop, nil
+}
+
+// inferDataType returns the data type of props bound to fields of type t
+func inferDataType(t reflect.Type) (string, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeReflectType:
+		return "datetime", nil
+	case keyReflectType, assetReflectType:
+		return "->@asset", nil
+	}
+	if assetTypeTag, registered := assetStructMap[t]; registered {
+		return "->" + assetTypeTag, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", nil
+	case reflect.Float32, reflect.Float64:
+		return "number", nil
+	case reflect.Map:
+		return "@object", nil
+	case reflect.Struct:
+		if isBindableStruct(t) {
+			return "@object", nil
+		}
+	case reflect.Slice, reflect.Array:
+		elemDataType, err := inferDataType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(elemDataType, "[]") {
+			return "", fmt.Errorf("nested arrays are not supported")
+		}
+		return "[]" + elemDataType, nil
+	}
+
+	return "", fmt.Errorf("unable to infer data type of %s, set it with the dataType option", t)
+}
+
+// parseListTag parses a list of orgs from a struct tag, either comma-separated or as a JSON array
+func parseListTag(value string) ([]string, error) {
+	if strings.HasPrefix(value, "[") {
+		var list []string
+		err := json.Unmarshal([]byte(value), &list)
+		return list, err
+	}
+	return strings.Split(value, ","), nil
+}
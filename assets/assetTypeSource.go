@@ -0,0 +1,247 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// GenerateStructSource generates the Go source code of a struct bound to the asset type,
+// along with the AssetTypeOptions needed to derive the asset type back with AssetTypeFromStruct.
+func GenerateStructSource(assetType AssetType, packageName string) ([]byte, errors.ICCError) {
+	if assetType.Tag == "" {
+		return nil, errors.NewCCError("asset type has empty tag", http.StatusBadRequest)
+	}
+	if packageName == "" {
+		return nil, errors.NewCCError("package name cannot be empty", http.StatusBadRequest)
+	}
+
+	structName := goIdentifier(assetType.Tag)
+	usedNames := map[string]struct{}{
+		"Key": {},
+	}
+	usesTime := false
+
+	var fields strings.Builder
+	fields.WriteString("\tKey string `cctools:\"@key,omitempty\"`\n")
+	for _, prop := range assetType.Props {
+		fieldName := goIdentifier(prop.Tag)
+		for i := 2; ; i++ {
+			if _, used := usedNames[fieldName]; !used {
+				break
+			}
+			fieldName = fmt.Sprintf("%s%d", goIdentifier(prop.Tag), i)
+		}
+		usedNames[fieldName] = struct{}{}
+
+		fieldType, exact := goTypeForDataType(prop.DataType)
+		if strings.Contains(fieldType, "time.Time") {
+			usesTime = true
+		}
+
+		options := []string{prop.Tag}
+		if prop.IsKey {
+			options = append(options, "key")
+		} else if prop.Required {
+			options = append(options, "required")
+		}
+		if prop.ReadOnly {
+			options = append(options, "readOnly")
+		}
+		if !prop.IsKey && !prop.Required {
+			options = append(options, "omitempty")
+		}
+		if !exact {
+			options = append(options, "dataType="+prop.DataType)
+		}
+
+		tags := []string{fmt.Sprintf("cctools:%s", strconv.Quote(strings.Join(options, ",")))}
+		tags = append(tags, fmt.Sprintf("label:%s", strconv.Quote(prop.Label)))
+		if prop.Description != "" {
+			tags = append(tags, fmt.Sprintf("description:%s", strconv.Quote(prop.Description)))
+		}
+		if len(prop.Writers) > 0 {
+			tags = append(tags, fmt.Sprintf("writers:%s", strconv.Quote(formatListTag(prop.Writers))))
+		}
+		if len(prop.Readers) > 0 {
+			tags = append(tags, fmt.Sprintf("readers:%s", strconv.Quote(formatListTag(prop.Readers))))
+		}
+		if prop.DefaultValue != nil {
+			defaultValue, isString := prop.DefaultValue.(string)
+			if !isString || fieldType != "string" {
+				defaultJSON, err := json.Marshal(prop.DefaultValue)
+				if err != nil {
+					return nil, errors.WrapErrorWithStatus(err, fmt.Sprintf("failed to marshal default value of prop '%s'", prop.Tag), http.StatusInternalServerError)
+				}
+				defaultValue = string(defaultJSON)
+			}
+			tags = append(tags, fmt.Sprintf("default:%s", strconv.Quote(defaultValue)))
+		}
+
+		tag := strings.Join(tags, " ")
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+
+		fmt.Fprintf(&fields, "\t%s %s %s\n", fieldName, fieldType, tag)
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "// Code generated by cc-tools from asset type %s. DO NOT EDIT.\n\n", strconv.Quote(assetType.Tag))
+	fmt.Fprintf(&src, "package %s\n\n", packageName)
+	src.WriteString("import (\n")
+	if usesTime {
+		src.WriteString("\t\"time\"\n\n")
+	}
+	src.WriteString("\t\"github.com/hyperledger-labs/cc-tools/assets\"\n)\n\n")
+
+	fmt.Fprintf(&src, "// %s is bound to the asset type %s.\n", structName, strconv.Quote(assetType.Tag))
+	if assetType.Description != "" {
+		for _, line := range strings.Split(assetType.Description, "\n") {
+			fmt.Fprintf(&src, "// %s\n", line)
+		}
+	}
+	fmt.Fprintf(&src, "type %s struct {\n%s}\n\n", structName, fields.String())
+
+	fmt.Fprintf(&src, "// %sAssetTypeOptions derives the asset type %s from %s with assets.AssetTypeFromStruct.\n", structName, strconv.Quote(assetType.Tag), structName)
+	fmt.Fprintf(&src, "var %sAssetTypeOptions = assets.AssetTypeOptions{\n", structName)
+	fmt.Fprintf(&src, "\tTag: %s,\n", strconv.Quote(assetType.Tag))
+	fmt.Fprintf(&src, "\tLabel: %s,\n", strconv.Quote(assetType.Label))
+	if assetType.Description != "" {
+		fmt.Fprintf(&src, "\tDescription: %s,\n", strconv.Quote(assetType.Description))
+	}
+	if len(assetType.Readers) > 0 {
+		readers := make([]string, 0, len(assetType.Readers))
+		for _, r := range assetType.Readers {
+			readers = append(readers, strconv.Quote(r))
+		}
+		fmt.Fprintf(&src, "\tReaders: []string{%s},\n", strings.Join(readers, ", "))
+	}
+	if assetType.Collection != "" {
+		fmt.Fprintf(&src, "\tCollection: %s,\n", strconv.Quote(assetType.Collection))
+	}
+	if assetType.Dynamic {
+		src.WriteString("\tDynamic: true,\n")
+	}
+	src.WriteString("}\n")
+
+	formatted, err := format.Source([]byte(src.String()))
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to format generated source", http.StatusInternalServerError)
+	}
+
+	return formatted, nil
+}
+
+// GenerateStoredStructSource generates the Go source code of a struct bound to the
+// dynamic asset type stored in the ledger's asset type list.
+func GenerateStoredStructSource(stub *sw.StubWrapper, assetTypeTag, packageName string) ([]byte, errors.ICCError) {
+	listKey, err := NewKey(map[string]interface{}{
+		"@assetType": "assetTypeListData",
+		"id":         "primary",
+	})
+	if err != nil {
+		return nil, errors.NewCCError("error getting asset list key", http.StatusInternalServerError)
+	}
+
+	listAsset, err := listKey.Get(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "error getting asset list")
+	}
+
+	list, ok := (*listAsset)["list"].([]interface{})
+	if !ok {
+		return nil, errors.NewCCError("invalid stored asset list", http.StatusInternalServerError)
+	}
+
+	for _, assetType := range AssetTypeListFromArray(list) {
+		if assetType.Tag == assetTypeTag {
+			return GenerateStructSource(assetType, packageName)
+		}
+	}
+
+	return nil, errors.NewCCError(fmt.Sprintf("asset type '%s' not found in stored asset list", assetTypeTag), http.StatusNotFound)
+}
+
+// goTypeForDataType returns the Go type of fields bound to props of the data type.
+// The returned flag is true if the data type inferred from the Go type is the same.
+func goTypeForDataType(dataType string) (string, bool) {
+	if strings.HasPrefix(dataType, "[]") {
+		elemType, exact := goTypeForDataType(strings.TrimPrefix(dataType, "[]"))
+		return "[]" + elemType, exact
+	}
+	if strings.HasPrefix(dataType, "->") {
+		return "assets.Key", dataType == "->@asset"
+	}
+
+	switch dataType {
+	case "string":
+		return "string", true
+	case "number":
+		return "float64", true
+	case "integer":
+		return "int64", true
+	case "boolean":
+		return "bool", true
+	case "datetime":
+		return "time.Time", true
+	case "@object":
+		return "map[string]interface{}", true
+	}
+
+	// Custom data types are bound to their first accepted format
+	if customType := FetchDataType(dataType); customType != nil && len(customType.AcceptedFormats) > 0 {
+		switch customType.AcceptedFormats[0] {
+		case "string":
+			return "string", false
+		case "number":
+			return "float64", false
+		case "boolean":
+			return "bool", false
+		}
+	}
+	return "interface{}", false
+}
+
+// goIdentifier converts a tag into an exported Go identifier
+func goIdentifier(tag string) string {
+	var id strings.Builder
+	upper := true
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		id.WriteRune(r)
+	}
+
+	if id.Len() == 0 || !unicode.IsLetter([]rune(id.String())[0]) {
+		return "X" + id.String()
+	}
+	return id.String()
+}
+
+// formatListTag formats a list of orgs as a struct tag value, using a JSON array if a comma-separated list would be ambiguous
+func formatListTag(list []string) string {
+	value := strings.Join(list, ",")
+	for _, elem := range list {
+		if strings.Contains(elem, ",") || strings.HasPrefix(value, "[") {
+			listJSON, _ := json.Marshal(list)
+			return string(listJSON)
+		}
+	}
+	return value
+}
@@ -0,0 +1,431 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// bindingTagName is the struct tag used to map struct fields to asset props.
+// The tag value is the prop tag, optionally followed by ",omitempty", e.g.
+//
+//	type Book struct {
+//		Key    string   `cctools:"@key"`
+//		Title  string   `cctools:"title"`
+//		Tenant *Key     `cctools:"currentTenant,omitempty"`
+//		Genres []string `cctools:"genres,omitempty"`
+//	}
+//
+// Fields without a cctools tag fall back to their json tag and then to their name.
+// Fields tagged with "-" are ignored.
+const bindingTagName = "cctools"
+
+// assetStructMap maps the structs registered with RegisterAssetStruct to their asset type tags
+var assetStructMap = map[reflect.Type]string{}
+
+var (
+	keyReflectType   = reflect.TypeOf(Key{})
+	assetReflectType = reflect.TypeOf(Asset{})
+	timeReflectType  = reflect.TypeOf(time.Time{})
+)
+
+// fieldBinding associates a struct field to an asset prop
+type fieldBinding struct {
+	index     int
+	name      string
+	propTag   string
+	omitEmpty bool
+	options   []string
+}
+
+// RegisterAssetStruct binds the struct type T to the asset type with the given tag.
+// Encode uses the registered asset type when the struct has no "@assetType" field
+// and StartupCheck verifies the struct fields match the asset type props.
+func RegisterAssetStruct[T any](assetTypeTag string) {
+	assetStructMap[reflect.TypeOf((*T)(nil)).Elem()] = assetTypeTag
+}
+
+// Decode converts an asset into a struct of type T, according to its field tags.
+// References are decoded into Key, Asset or struct fields, which receive the
+// reference's "@assetType" and "@key".
+func Decode[T any](a Asset) (T, errors.ICCError) {
+	var v T
+	err := decodeValue(map[string]interface{}(a), reflect.ValueOf(&v).Elem())
+	if err != nil {
+		return v, errors.WrapErrorWithStatus(err, fmt.Sprintf("unable to decode asset into %T", v), 400)
+	}
+	return v, nil
+}
+
+// Encode converts a struct into an asset, according to its field tags. The asset type
+// is taken from the "@assetType" field or from the type registered with RegisterAssetStruct.
+func Encode[T any](v T) (Asset, errors.ICCError) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.NewCCError("cannot encode nil value", 400)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.NewCCError(fmt.Sprintf("cannot encode %T as an asset: value must be a struct", v), 400)
+	}
+
+	m, err := encodeStruct(rv)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, fmt.Sprintf("unable to encode %T", v), 400)
+	}
+	if _, hasType := m["@assetType"]; !hasType {
+		return nil, errors.NewCCError(fmt.Sprintf("unable to find asset type of %T: register it with RegisterAssetStruct or add an @assetType field", v), 400)
+	}
+
+	return NewAsset(m)
+}
+
+// GetAs fetches the asset from the write set or ledger and decodes it into a struct of type T.
+func GetAs[T any](stub *sw.StubWrapper, key Key) (T, errors.ICCError) {
+	var v T
+	asset, err := key.Get(stub)
+	if err != nil {
+		return v, errors.WrapError(err, "failed to get asset")
+	}
+	return Decode[T](*asset)
+}
+
+// structBindings returns the asset prop bindings of the exported fields of the struct type t.
+func structBindings(t reflect.Type) []fieldBinding {
+	bindings := make([]fieldBinding, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup(bindingTagName)
+		if !hasTag {
+			tag = field.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		binding := fieldBinding{
+			index: i,
+			name:  field.Name,
+		}
+		opts := strings.Split(tag, ",")
+		binding.propTag = opts[0]
+		if binding.propTag == "" {
+			binding.propTag = field.Name
+		}
+		binding.options = opts[1:]
+		for _, opt := range binding.options {
+			if opt == "omitempty" {
+				binding.omitEmpty = true
+			}
+		}
+
+		bindings = append(bindings, binding)
+	}
+	return bindings
+}
+
+// isBindableStruct checks if t is a struct which is mapped field by field
+func isBindableStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	return !t.Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) &&
+		!reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem())
+}
+
+func decodeValue(data interface{}, rv reflect.Value) error {
+	if data == nil {
+		return nil
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeValue(data, rv.Elem())
+	}
+
+	switch rv.Type() {
+	case keyReflectType:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("reference must be an object, got %T", data)
+		}
+		key, err := NewKey(m)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(key))
+		return nil
+	case assetReflectType:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("reference must be an object, got %T", data)
+		}
+		asset := Asset{}
+		for k, v := range m {
+			asset[k] = v
+		}
+		rv.Set(reflect.ValueOf(asset))
+		return nil
+	}
+
+	if isBindableStruct(rv.Type()) {
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected an object, got %T", data)
+		}
+		for _, binding := range structBindings(rv.Type()) {
+			value, exists := m[binding.propTag]
+			if !exists {
+				continue
+			}
+			err := decodeValue(value, rv.Field(binding.index))
+			if err != nil {
+				return fmt.Errorf("prop '%s': %w", binding.propTag, err)
+			}
+		}
+		return nil
+	}
+
+	dataValue := reflect.ValueOf(data)
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && dataValue.Kind() == reflect.Slice {
+		if rv.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(rv.Type(), dataValue.Len(), dataValue.Len()))
+		} else if dataValue.Len() > rv.Len() {
+			return fmt.Errorf("expected at most %d elements, got %d", rv.Len(), dataValue.Len())
+		}
+		for i := 0; i < dataValue.Len(); i++ {
+			err := decodeValue(dataValue.Index(i).Interface(), rv.Index(i))
+			if err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	// Primitive values are converted the same way they are read from the ledger
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(dataJSON, rv.Addr().Interface())
+}
+
+func encodeStruct(rv reflect.Value) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for _, binding := range structBindings(rv.Type()) {
+		field := rv.Field(binding.index)
+		if binding.omitEmpty && field.IsZero() {
+			continue
+		}
+
+		value, err := encodeValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("prop '%s': %w", binding.propTag, err)
+		}
+		if value != nil {
+			m[binding.propTag] = value
+		}
+	}
+
+	if _, hasType := m["@assetType"]; !hasType {
+		if tag, registered := assetStructMap[rv.Type()]; registered {
+			m["@assetType"] = tag
+		}
+	}
+
+	return m, nil
+}
+
+func encodeValue(rv reflect.Value) (interface{}, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Type() {
+	case keyReflectType, assetReflectType:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]interface{})
+		for _, k := range rv.MapKeys() {
+			m[k.String()] = rv.MapIndex(k).Interface()
+		}
+		return m, nil
+	case timeReflectType:
+		return rv.Interface(), nil
+	}
+
+	if isBindableStruct(rv.Type()) {
+		return encodeStruct(rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		values := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			value, err := encodeValue(rv.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		// Objects are stored as JSON, so they are normalized through it
+		mapJSON, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]interface{}
+		err = json.Unmarshal(mapJSON, &m)
+		return m, err
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", rv.Type())
+}
+
+// checkAssetStructs verifies if the structs registered with RegisterAssetStruct match their asset types
+func checkAssetStructs() errors.ICCError {
+	for structType, assetTypeTag := range assetStructMap {
+		if structType.Kind() != reflect.Struct {
+			return errors.NewCCError(fmt.Sprintf("type %s registered for asset type '%s' is not a struct", structType, assetTypeTag), 500)
+		}
+
+		assetType := FetchAssetType(assetTypeTag)
+		if assetType == nil {
+			return errors.NewCCError(fmt.Sprintf("struct %s registered for undefined asset type '%s'", structType, assetTypeTag), 500)
+		}
+
+		boundProps := map[string]struct{}{}
+		for _, binding := range structBindings(structType) {
+			boundProps[binding.propTag] = struct{}{}
+
+			// Internal props such as @key and @lastUpdated
+			if strings.HasPrefix(binding.propTag, "@") {
+				continue
+			}
+
+			propDef := assetType.GetPropDef(binding.propTag)
+			if propDef == nil {
+				return errors.NewCCError(fmt.Sprintf("field %s of struct %s maps to undefined prop '%s' of asset type '%s'", binding.name, structType, binding.propTag, assetTypeTag), 500)
+			}
+
+			fieldType := structType.Field(binding.index).Type
+			err := checkFieldType(fieldType, propDef.DataType)
+			if err != nil {
+				return errors.WrapErrorWithStatus(err, fmt.Sprintf("field %s of struct %s does not match prop '%s' of asset type '%s'", binding.name, structType, binding.propTag, assetTypeTag), 500)
+			}
+		}
+
+		for _, propDef := range assetType.Props {
+			if !propDef.IsKey && !propDef.Required {
+				continue
+			}
+			if _, bound := boundProps[propDef.Tag]; !bound {
+				return errors.NewCCError(fmt.Sprintf("struct %s has no field for required prop '%s' of asset type '%s'", structType, propDef.Tag, assetTypeTag), 500)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFieldType checks if values of the field type t can hold props of the given data type
+func checkFieldType(t reflect.Type, dataTypeName string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+
+	if strings.HasPrefix(dataTypeName, "[]") {
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return fmt.Errorf("%s cannot hold a %s array", t, dataTypeName)
+		}
+		return checkFieldType(t.Elem(), strings.TrimPrefix(dataTypeName, "[]"))
+	}
+
+	if strings.HasPrefix(dataTypeName, "->") {
+		if t == keyReflectType || t == assetReflectType || t.Kind() == reflect.Map {
+			return nil
+		}
+		if !isBindableStruct(t) {
+			return fmt.Errorf("%s cannot hold a reference", t)
+		}
+		refTag := strings.TrimPrefix(dataTypeName, "->")
+		if structTag, registered := assetStructMap[t]; registered && refTag != "@asset" && structTag != refTag {
+			return fmt.Errorf("%s is registered for asset type '%s', not '%s'", t, structTag, refTag)
+		}
+		return nil
+	}
+
+	dataType := FetchDataType(dataTypeName)
+	if dataType == nil {
+		return fmt.Errorf("undefined data type '%s'", dataTypeName)
+	}
+	if len(dataType.AcceptedFormats) == 0 || (dataTypeName == "datetime" && t == timeReflectType) {
+		return nil
+	}
+
+	for _, format := range dataType.AcceptedFormats {
+		switch format {
+		case "string":
+			if t.Kind() == reflect.String {
+				return nil
+			}
+		case "number":
+			switch t.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				return nil
+			}
+		case "boolean":
+			if t.Kind() == reflect.Bool {
+				return nil
+			}
+		case "@object":
+			if t.Kind() == reflect.Map || isBindableStruct(t) {
+				return nil
+			}
+		default:
+			// Formats of custom data types are not checked
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s cannot hold a value of data type '%s'", t, dataTypeName)
+}
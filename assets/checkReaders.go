@@ -0,0 +1,152 @@
+package assets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// CheckReaders checks if tx creator is allowed to read the property.
+func (p AssetProp) CheckReaders(stub *sw.StubWrapper) (bool, errors.ICCError) {
+	if len(p.Readers) == 0 && len(p.ReaderCallers) == 0 {
+		return true, nil
+	}
+
+	// Get tx creator MSP ID
+	txCreator, err := stub.GetMSPID()
+	if err != nil {
+		return false, errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+	}
+
+	match, err := matchOrgs(p.Readers, txCreator)
+	if err != nil {
+		return false, errors.WrapError(err, "failed to check if reader matches")
+	}
+	if match {
+		return true, nil
+	}
+
+	if p.ReaderCallers != nil {
+		return allowCaller(stub, p.ReaderCallers)
+	}
+
+	return false, nil
+}
+
+// Redact removes from the asset the properties the tx creator is not allowed to read.
+// Resolved sub-assets are redacted as well.
+func Redact(stub *sw.StubWrapper, asset map[string]interface{}) errors.ICCError {
+	assetTypeTag, _ := asset["@assetType"].(string)
+	if assetTypeDef := FetchAssetType(assetTypeTag); assetTypeDef != nil {
+		for _, prop := range assetTypeDef.Props {
+			if _, exists := asset[prop.Tag]; !exists {
+				continue
+			}
+
+			readPermission, err := prop.CheckReaders(stub)
+			if err != nil {
+				return errors.WrapError(err, "failed to check read permission")
+			}
+			if !readPermission {
+				delete(asset, prop.Tag)
+			}
+		}
+	}
+
+	for _, value := range asset {
+		values, isArray := value.([]interface{})
+		if !isArray {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			var subAsset map[string]interface{}
+			switch t := v.(type) {
+			case map[string]interface{}:
+				subAsset = t
+			case Key:
+				subAsset = t
+			case Asset:
+				subAsset = t
+			default:
+				continue
+			}
+
+			err := Redact(stub, subAsset)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkQueryReaders returns an error if the selector or the sort of a search request reference
+// properties the tx creator is not allowed to read, since the results would leak their values.
+// Properties are checked against the asset type in the selector or, if it has none, against every asset type.
+func checkQueryReaders(stub *sw.StubWrapper, request map[string]interface{}) errors.ICCError {
+	fields := map[string]struct{}{}
+	selector, _ := request["selector"].(map[string]interface{})
+	selectorFields(selector, fields)
+
+	sortList, _ := request["sort"].([]interface{})
+	for _, s := range sortList {
+		switch t := s.(type) {
+		case string:
+			fields[strings.Split(t, ".")[0]] = struct{}{}
+		case map[string]interface{}:
+			for field := range t {
+				fields[strings.Split(field, ".")[0]] = struct{}{}
+			}
+		}
+	}
+
+	assetTypes := AssetTypeList()
+	if assetTypeTag, ok := selector["@assetType"].(string); ok {
+		assetTypes = nil
+		if assetTypeDef := FetchAssetType(assetTypeTag); assetTypeDef != nil {
+			assetTypes = []AssetType{*assetTypeDef}
+		}
+	}
+
+	for _, assetTypeDef := range assetTypes {
+		for _, prop := range assetTypeDef.Props {
+			if _, queried := fields[prop.Tag]; !queried {
+				continue
+			}
+
+			readPermission, err := prop.CheckReaders(stub)
+			if err != nil {
+				return errors.WrapError(err, "failed to check read permission")
+			}
+			if !readPermission {
+				return errors.NewCCError(fmt.Sprintf("cannot query asset property %s of asset type %s", prop.Tag, assetTypeDef.Tag), 403)
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectorFields adds to fields the top level fields referenced by a Mango selector,
+// including those inside combination operators such as $and and $or.
+func selectorFields(selector map[string]interface{}, fields map[string]struct{}) {
+	for k, v := range selector {
+		if !strings.HasPrefix(k, "$") {
+			fields[strings.Split(k, ".")[0]] = struct{}{}
+			continue
+		}
+
+		values, isArray := v.([]interface{})
+		if !isArray {
+			values = []interface{}{v}
+		}
+		for _, value := range values {
+			if subSelector, ok := value.(map[string]interface{}); ok {
+				selectorFields(subSelector, fields)
+			}
+		}
+	}
+}
@@ -4,58 +4,159 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/errors"
 	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
 )
 
 // CheckWriters checks if tx creator is allowed to write asset.
 func (a Asset) CheckWriters(stub *sw.StubWrapper) errors.ICCError {
-	// Get tx creator MSP ID
-	txCreator, err := stub.GetMSPID()
+	// Fetch asset properties
+	assetTypeDef := a.Type()
+	if assetTypeDef == nil {
+		return errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", a.TypeTag()), 400)
+	}
+
+	err := assetTypeDef.checkWriterCallers(stub)
 	if err != nil {
-		return errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+		return err
 	}
 
-	return a.checkWriters(txCreator)
+	// Check attributes write permission
+	for _, prop := range assetTypeDef.Props {
+		if _, exists := a[prop.Tag]; !exists {
+			continue
+		}
+
+		err := prop.checkWritePermission(stub)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// checkWriters is an internal function that checks if tx creator is allowed to write asset.
-func (a Asset) checkWriters(txCreator string) errors.ICCError {
+// CheckDeleters checks if tx creator is allowed to delete asset.
+func (a Asset) CheckDeleters(stub *sw.StubWrapper) errors.ICCError {
 	// Fetch asset properties
 	assetTypeDef := a.Type()
 	if assetTypeDef == nil {
 		return errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", a.TypeTag()), 400)
 	}
 
-	// Check attributes write permission
-	for _, prop := range assetTypeDef.Props {
-		if _, exists := a[prop.Tag]; exists && prop.Writers != nil {
-			writePermission := false
-			for _, w := range prop.Writers {
-				if len(w) <= 1 {
-					continue
-				}
-				if w[0] == '$' { // if writer is regexp
-					match, err := regexp.MatchString(w[1:], txCreator)
-					if err != nil {
-						return errors.NewCCError("failed to check if writer matches regexp", 500)
-					}
-					if match {
-						writePermission = true
-						break
-					}
-				} else { // if writer is not regexp
-					if w == txCreator {
-						writePermission = true
-						break
-					}
-				}
+	if assetTypeDef.DeleterCallers == nil {
+		return nil
+	}
+
+	deletePermission, err := allowCaller(stub, assetTypeDef.DeleterCallers)
+	if err != nil {
+		return errors.WrapError(err, "failed to check delete permission")
+	}
+	if !deletePermission {
+		return errors.NewCCError(fmt.Sprintf("caller cannot delete assets of type '%s'", assetTypeDef.Tag), 403)
+	}
+
+	return nil
+}
+
+// CheckWriters checks if tx creator is allowed to write the property.
+func (p AssetProp) CheckWriters(stub *sw.StubWrapper) (bool, errors.ICCError) {
+	if p.Writers == nil && p.WriterCallers == nil {
+		return true, nil
+	}
+
+	// Get tx creator MSP ID
+	txCreator, err := stub.GetMSPID()
+	if err != nil {
+		return false, errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+	}
+
+	match, err := matchOrgs(p.Writers, txCreator)
+	if err != nil {
+		return false, errors.WrapError(err, "failed to check if writer matches")
+	}
+	if match {
+		return true, nil
+	}
+
+	if p.WriterCallers != nil {
+		return allowCaller(stub, p.WriterCallers)
+	}
+
+	return false, nil
+}
+
+// checkWritePermission returns a 403 error if tx creator is not allowed to write the property.
+func (p AssetProp) checkWritePermission(stub *sw.StubWrapper) errors.ICCError {
+	writePermission, err := p.CheckWriters(stub)
+	if err != nil {
+		return errors.WrapError(err, "failed to check write permission")
+	}
+	if !writePermission {
+		txCreator, err := stub.GetMSPID()
+		if err != nil {
+			return errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+		}
+		return errors.NewCCError(fmt.Sprintf("%s cannot write to the '%s' (%s) asset property", txCreator, p.Tag, p.Label), 403)
+	}
+
+	return nil
+}
+
+// checkWriterCallers returns a 403 error if tx creator is not allowed to write assets of the
+// type by its writer callers or its policy.
+func (t AssetType) checkWriterCallers(stub *sw.StubWrapper) errors.ICCError {
+	if t.WriterCallers != nil {
+		writePermission, err := allowCaller(stub, t.WriterCallers)
+		if err != nil {
+			return errors.WrapError(err, "failed to check write permission")
+		}
+		if !writePermission {
+			return errors.NewCCError(fmt.Sprintf("caller cannot write assets of type '%s'", t.Tag), 403)
+		}
+	}
+
+	policyPermission, err := accesscontrol.CheckPolicy(stub.Stub, t.Policy)
+	if err != nil {
+		return errors.WrapError(err, "failed to check asset type policy")
+	}
+	if !policyPermission {
+		return errors.NewCCError(fmt.Sprintf("caller not allowed by policy %s to write assets of type '%s'", t.Policy, t.Tag), 403)
+	}
+
+	return nil
+}
+
+// matchOrgs checks if the MSP ID matches any of the orgs, given as exact
+// matches or as regular expressions prefixed with '$'.
+func matchOrgs(orgs []string, mspID string) (bool, errors.ICCError) {
+	for _, org := range orgs {
+		if len(org) <= 1 {
+			continue
+		}
+		if org[0] == '$' { // if org is regexp
+			match, err := regexp.MatchString(org[1:], mspID)
+			if err != nil {
+				return false, errors.NewCCError("failed to check if org matches regexp", 500)
 			}
-			if !writePermission {
-				return errors.NewCCError(fmt.Sprintf("%s cannot write to the '%s' (%s) asset property", txCreator, prop.Tag, prop.Label), 403)
+			if match {
+				return true, nil
 			}
+		} else if org == mspID { // if org is not regexp
+			return true, nil
 		}
 	}
 
-	return nil
+	return false, nil
+}
+
+// allowCaller checks if tx creator matches any of the caller rules.
+func allowCaller(stub *sw.StubWrapper, callers []accesscontrol.Caller) (bool, errors.ICCError) {
+	allowed, err := accesscontrol.AllowCaller(stub.Stub, callers)
+	if err != nil {
+		return false, errors.WrapErrorWithStatus(err, "error checking caller", 500)
+	}
+
+	return allowed, nil
 }
@@ -104,6 +104,8 @@ var dataTypeMap = map[string]*DataType{
 				dataVal = v
 			case int:
 				dataVal = (float64)(v)
+			case int64:
+				dataVal = (float64)(v)
 			case string:
 				var err error
 				dataVal, err = strconv.ParseFloat(v, 64)
@@ -2,7 +2,7 @@ package assets
 
 import (
 	"encoding/json"
-	"strings"
+	"fmt"
 
 	"github.com/hyperledger-labs/cc-tools/errors"
 	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
@@ -17,6 +17,18 @@ func (a *Asset) delete(stub *sw.StubWrapper) ([]byte, errors.ICCError) {
 		return nil, errors.WrapError(err, "failed write permission check")
 	}
 
+	// Check if org has delete permission
+	err = a.CheckDeleters(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed delete permission check")
+	}
+
+	// Check if tx creator is allowed to delete the asset if it is owned
+	err = a.checkOwnership(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed owner permission check")
+	}
+
 	// Clean up reference markers for this asset
 	err = a.delRefs(stub)
 	if err != nil {
@@ -29,7 +41,26 @@ func (a *Asset) delete(stub *sw.StubWrapper) ([]byte, errors.ICCError) {
 		if err != nil {
 			return nil, errors.WrapError(err, "failed to delete state from ledger")
 		}
-		assetJSON, err = json.Marshal(a)
+
+		// Erase private props of hybrid asset types and keep them out of the response
+		public := *a
+		if assetTypeDef := a.Type(); assetTypeDef != nil && assetTypeDef.IsHybrid() {
+			err = a.delPrivateProps(stub)
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to delete private props")
+			}
+			public, _ = a.splitPrivateProps()
+		} else {
+			public = copyAsset(*a)
+		}
+
+		// Keep the props the tx creator cannot read out of the response
+		err = Redact(stub, public)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to redact asset")
+		}
+
+		assetJSON, err = json.Marshal(public)
 		if err != nil {
 			return nil, errors.WrapError(err, "failed to marshal asset")
 		}
@@ -48,6 +79,11 @@ func (a *Asset) delete(stub *sw.StubWrapper) ([]byte, errors.ICCError) {
 		}
 	}
 
+	eventErr := emitLifecycleEvent(stub, AssetDeletedEvent, *a, nil)
+	if eventErr != nil {
+		return nil, eventErr
+	}
+
 	return assetJSON, nil
 }
 
@@ -116,10 +152,21 @@ func deleteRecursive(stub *sw.StubWrapper, key string, deletedKeys *[]string) er
 	}
 	defer states.Close()
 
+	// Collect referrers before deleting them, since deletions change the reference index
+	var referrerKeys []string
 	for states.HasNext() {
-		next, _ := states.Next()
-		referrerKeyString := strings.ReplaceAll(next.Key, key, "")
-		referrerKeyString = strings.ReplaceAll(referrerKeyString, "\x00", "")
+		next, err := states.Next()
+		if err != nil {
+			return errors.WrapError(err, "failed to iterate in reference index")
+		}
+		_, keyParts, err := stub.SplitCompositeKey(next.Key)
+		if err != nil || len(keyParts) == 0 {
+			return errors.NewCCError(fmt.Sprintf("invalid reference index %s", next.Key), 500)
+		}
+		referrerKeys = append(referrerKeys, keyParts[0])
+	}
+
+	for _, referrerKeyString := range referrerKeys {
 		var isDeleted bool = false
 
 		for _, deletedKey := range *deletedKeys {
@@ -129,15 +176,14 @@ func deleteRecursive(stub *sw.StubWrapper, key string, deletedKeys *[]string) er
 				break
 			}
 		}
-		*deletedKeys = append(*deletedKeys, referrerKeyString)
 
 		if !isDeleted {
+			*deletedKeys = append(*deletedKeys, referrerKeyString)
 			err = deleteRecursive(stub, referrerKeyString, deletedKeys)
 			if err != nil {
 				return errors.WrapError(err, "error deleting referrer asset")
 			}
 		}
-
 	}
 
 	keyMap := make(map[string]interface{})
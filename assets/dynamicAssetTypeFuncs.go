@@ -83,6 +83,23 @@ func BuildAssetProp(propMap map[string]interface{}, newTypesList []interface{})
 		assetProp.Writers = writers
 	}
 
+	// Readers
+	readers := make([]string, 0)
+	readersArr, ok := propMap["readers"].([]interface{})
+	if ok {
+		for _, reader := range readersArr {
+			readerValue, err := CheckValue(reader, false, "string", "reader")
+			if err != nil {
+				return AssetProp{}, errors.WrapError(err, "invalid reader value")
+			}
+
+			readers = append(readers, readerValue.(string))
+		}
+	}
+	if len(readers) > 0 {
+		assetProp.Readers = readers
+	}
+
 	// Validate Default Value
 	if propMap["defaultValue"] != nil {
 		defaultValue, err := validateProp(propMap["defaultValue"], assetProp)
@@ -141,6 +158,24 @@ func HandlePropUpdate(assetProps AssetProp, propMap map[string]interface{}) (Ass
 				}
 			}
 			assetProps.Writers = writers
+		case "readers":
+			readers := make([]string, 0)
+			readersArr, ok := v.([]interface{})
+			if ok {
+				for _, reader := range readersArr {
+					readerValue, err := CheckValue(reader, false, "string", "reader")
+					if err != nil {
+						return AssetProp{}, errors.WrapError(err, "invalid reader value")
+					}
+
+					readers = append(readers, readerValue.(string))
+				}
+			}
+			// An empty readers list does not restrict reading the property
+			if len(readers) == 0 {
+				readers = nil
+			}
+			assetProps.Readers = readers
 		default:
 			continue
 		}
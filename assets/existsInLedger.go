@@ -2,7 +2,6 @@ package assets
 
 import (
 	"github.com/hyperledger-labs/cc-tools/errors"
-	"github.com/hyperledger-labs/cc-tools/mock"
 	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
 )
 
@@ -10,12 +9,7 @@ func existsInLedger(stub *sw.StubWrapper, isPrivate bool, collection, key string
 	var assetBytes []byte
 	var err error
 	if isPrivate {
-		_, isMock := stub.Stub.(*mock.MockStub)
-		if isMock {
-			assetBytes, err = stub.GetPrivateData(collection, key)
-		} else {
-			assetBytes, err = stub.GetPrivateDataHash(collection, key)
-		}
+		assetBytes, err = stub.GetPrivateDataHash(collection, key)
 	} else {
 		assetBytes, err = stub.GetState(key)
 	}
@@ -51,12 +45,7 @@ func committedInLedger(stub *sw.StubWrapper, isPrivate bool, collection, key str
 	var assetBytes []byte
 	var err error
 	if isPrivate {
-		_, isMock := stub.Stub.(*mock.MockStub)
-		if isMock {
-			assetBytes, err = stub.Stub.GetPrivateData(collection, key)
-		} else {
-			assetBytes, err = stub.Stub.GetPrivateDataHash(collection, key)
-		}
+		assetBytes, err = stub.Stub.GetPrivateDataHash(collection, key)
 	} else {
 		assetBytes, err = stub.Stub.GetState(key)
 	}
@@ -35,8 +35,31 @@ func get(stub *sw.StubWrapper, pvtCollection, key string, committed bool) (*Asse
 		return nil, errors.NewCCError("asset not found", 404)
 	}
 
-	var response Asset
-	err = json.Unmarshal(assetBytes, &response)
+	var assetMap map[string]interface{}
+	err = json.Unmarshal(assetBytes, &assetMap)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to unmarshal asset from ledger", 500)
+	}
+
+	if pvtCollection == "" {
+		err = mergePrivateProps(stub, assetMap, committed)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read private props")
+		}
+	}
+
+	// Assets written with older versions of the asset type are read in the current shape
+	assetMap, upgraded, err := upgrade(assetMap)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to read asset")
+	}
+	// Assets missing the props of inaccessible collections cannot be validated
+	if _, hasHashes := assetMap["@privateHashes"]; upgraded || hasHashes {
+		response := Asset(assetMap)
+		return &response, nil
+	}
+
+	response, err := NewAsset(assetMap)
 	if err != nil {
 		return nil, errors.WrapErrorWithStatus(err, "failed to unmarshal asset from ledger", 500)
 	}
@@ -123,7 +146,7 @@ func (k *Key) GetBytes(stub *sw.StubWrapper) ([]byte, errors.ICCError) {
 	return assetBytes, nil
 }
 
-// GetMap reads the asset as map from ledger
+// GetMap reads the asset as map from ledger, including the private props of hybrid asset types
 func (k *Key) GetMap(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCError) {
 	var err error
 	assetBytes, err := k.GetBytes(stub)
@@ -137,6 +160,13 @@ func (k *Key) GetMap(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCEr
 		return nil, errors.WrapError(err, "failed to unmarshal asset")
 	}
 
+	if !k.IsPrivate() {
+		err = mergePrivateProps(stub, ret, false)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read private props")
+		}
+	}
+
 	return ret, nil
 }
 
@@ -179,6 +209,18 @@ func getRecursive(stub *sw.StubWrapper, pvtCollection, key string, keysChecked [
 		return nil, errors.WrapErrorWithStatus(err, "failed to unmarshal asset from ledger", 500)
 	}
 
+	if pvtCollection == "" {
+		err = mergePrivateProps(stub, response, false)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to read private props")
+		}
+	}
+
+	response, _, err = upgrade(response)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to read asset")
+	}
+
 	keysCheckedInScope := make([]string, 0)
 
 	for k, v := range response {
@@ -283,22 +325,44 @@ func getRecursive(stub *sw.StubWrapper, pvtCollection, key string, keysChecked [
 	return response, nil
 }
 
-// GetRecursive reads asset from ledger and resolves all references
+// GetRecursive reads asset from ledger and resolves all references, removing the
+// properties the tx creator is not allowed to read.
 func (a *Asset) GetRecursive(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCError) {
 	var pvtCollection string
 	if a.IsPrivate() {
 		pvtCollection = a.CollectionName()
 	}
 
-	return getRecursive(stub, pvtCollection, a.Key(), []string{})
+	response, err := getRecursive(stub, pvtCollection, a.Key(), []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = Redact(stub, response)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to redact asset")
+	}
+
+	return response, nil
 }
 
-// GetRecursive reads asset from ledger and resolves all references
+// GetRecursive reads asset from ledger and resolves all references, removing the
+// properties the tx creator is not allowed to read.
 func (k *Key) GetRecursive(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCError) {
 	var pvtCollection string
 	if k.IsPrivate() {
 		pvtCollection = k.CollectionName()
 	}
 
-	return getRecursive(stub, pvtCollection, k.Key(), []string{})
+	response, err := getRecursive(stub, pvtCollection, k.Key(), []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = Redact(stub, response)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to redact asset")
+	}
+
+	return response, nil
 }
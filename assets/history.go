@@ -3,6 +3,7 @@ package assets
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/hyperledger-labs/cc-tools/errors"
 	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
@@ -33,6 +34,17 @@ func History(stub *sw.StubWrapper, key string, resolve bool) (*HistoryResponse,
 			return nil, errors.WrapErrorWithStatus(err, "error iterating response", 500)
 		}
 
+		// Deleted versions carry no value, so only the deletion is returned
+		if queryResponse.IsDelete {
+			historyResult = append(historyResult, map[string]interface{}{
+				"@key":       key,
+				"_txId":      queryResponse.TxId,
+				"_isDelete":  true,
+				"_timestamp": queryResponse.Timestamp.AsTime().Format(time.RFC3339),
+			})
+			continue
+		}
+
 		var data map[string]interface{}
 
 		err = json.Unmarshal(queryResponse.Value, &data)
@@ -55,6 +67,11 @@ func History(stub *sw.StubWrapper, key string, resolve bool) (*HistoryResponse,
 			}
 		}
 
+		err = Redact(stub, data)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to redact result")
+		}
+
 		historyResult = append(historyResult, data)
 	}
 
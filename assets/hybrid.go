@@ -0,0 +1,179 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// splitPrivateProps returns the public part of an asset of a hybrid type and its
+// private parts, by collection. Private parts only carry the asset type, key and props.
+func (a Asset) splitPrivateProps() (Asset, map[string]Asset) {
+	public := Asset{}
+	for k, v := range a {
+		if k == "@privateHashes" {
+			continue
+		}
+		public[k] = v
+	}
+
+	private := map[string]Asset{}
+	assetTypeDef := a.Type()
+	if assetTypeDef == nil {
+		return public, private
+	}
+	for _, prop := range assetTypeDef.Props {
+		if prop.Collection == "" || assetTypeDef.IsPrivate() {
+			continue
+		}
+
+		part, exists := private[prop.Collection]
+		if !exists {
+			part = Asset{
+				"@assetType": a.TypeTag(),
+				"@key":       a.Key(),
+			}
+			private[prop.Collection] = part
+		}
+
+		if value, included := a[prop.Tag]; included {
+			part[prop.Tag] = value
+			delete(public, prop.Tag)
+		}
+	}
+
+	return public, private
+}
+
+// putPrivateProps writes the private parts of an asset of a hybrid type to their collections
+// and returns the public part. Collections with none of the props in the asset are left untouched,
+// so callers without access to them do not erase their props. Parts the tx creator can read are
+// merged with the stored part and only written if they changed.
+func (a Asset) putPrivateProps(stub *sw.StubWrapper) (Asset, errors.ICCError) {
+	public, private := a.splitPrivateProps()
+	for collection, part := range private {
+		if len(part) <= 2 {
+			continue
+		}
+
+		partJSON, err := json.Marshal(part)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to encode private props to JSON format", 500)
+		}
+
+		storedBytes, iccErr := stub.GetPrivateData(collection, a.Key())
+		if iccErr == nil && storedBytes != nil {
+			stored := map[string]interface{}{}
+			err = json.Unmarshal(storedBytes, &stored)
+			if err != nil {
+				return nil, errors.WrapErrorWithStatus(err, "failed to unmarshal private props from collection "+collection, 500)
+			}
+			for k, v := range part {
+				stored[k] = v
+			}
+
+			partJSON, err = json.Marshal(stored)
+			if err != nil {
+				return nil, errors.WrapErrorWithStatus(err, "failed to encode private props to JSON format", 500)
+			}
+			if bytes.Equal(partJSON, storedBytes) {
+				continue
+			}
+		}
+
+		iccErr = stub.PutPrivateData(collection, a.Key(), partJSON)
+		if iccErr != nil {
+			return nil, errors.WrapError(iccErr, "failed to write private props to collection "+collection)
+		}
+	}
+
+	return public, nil
+}
+
+// unreadablePropCollections returns the prop collections of the asset type the tx creator cannot read.
+func (t AssetType) unreadablePropCollections(stub *sw.StubWrapper, key string) map[string]bool {
+	unreadable := map[string]bool{}
+	for _, collection := range t.PropCollections() {
+		_, err := stub.GetPrivateData(collection, key)
+		if err != nil {
+			unreadable[collection] = true
+		}
+	}
+
+	return unreadable
+}
+
+// delPrivateProps erases the private parts of an asset of a hybrid type.
+func (a Asset) delPrivateProps(stub *sw.StubWrapper) errors.ICCError {
+	assetTypeDef := a.Type()
+	if assetTypeDef == nil {
+		return nil
+	}
+
+	for _, collection := range assetTypeDef.PropCollections() {
+		err := stub.DelPrivateData(collection, a.Key())
+		if err != nil {
+			return errors.WrapError(err, "failed to delete private props from collection "+collection)
+		}
+	}
+
+	return nil
+}
+
+// mergePrivateProps adds to the public part of an asset of a hybrid type the props stored in
+// private collections. For the collections the tx creator cannot read, the hash of the private
+// part is set in the "@privateHashes" property, by collection.
+func mergePrivateProps(stub *sw.StubWrapper, asset map[string]interface{}, committed bool) errors.ICCError {
+	assetTypeTag, _ := asset["@assetType"].(string)
+	assetTypeDef := FetchAssetType(assetTypeTag)
+	if assetTypeDef == nil {
+		return nil
+	}
+	key, _ := asset["@key"].(string)
+
+	hashes := map[string]interface{}{}
+	for _, collection := range assetTypeDef.PropCollections() {
+		var partBytes []byte
+		var err error
+		if committed {
+			partBytes, err = stub.GetCommittedPrivateData(collection, key)
+		} else {
+			partBytes, err = stub.GetPrivateData(collection, key)
+		}
+		// If org cannot get private data it might be because it has no permission, so we fetch the data hash
+		if err != nil {
+			hash, err := stub.GetPrivateDataHash(collection, key)
+			if err != nil {
+				return errors.WrapErrorWithStatus(err, "unable to get private props", 400)
+			}
+			if hash != nil {
+				hashes[collection] = hash
+			}
+			continue
+		}
+		if partBytes == nil {
+			continue
+		}
+
+		var part map[string]interface{}
+		err = json.Unmarshal(partBytes, &part)
+		if err != nil {
+			return errors.WrapErrorWithStatus(err, "failed to unmarshal private props from collection "+collection, 500)
+		}
+		for k, v := range part {
+			if strings.HasPrefix(k, "@") {
+				continue
+			}
+			asset[k] = v
+		}
+	}
+
+	if len(hashes) > 0 {
+		asset["@privateHashes"] = hashes
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package assets
+
+import (
+	"sort"
+	"strings"
+)
+
+// JSONSchemaDialect is the JSON Schema draft used by the exported schemas
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns the Draft 2020-12 JSON Schema of the asset type. Referenced
+// asset types are described in "$defs" by the schema of their keys.
+func (t AssetType) JSONSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+
+	schema := t.jsonSchema(defs)
+	schema["$schema"] = JSONSchemaDialect
+	if len(defs) > 0 {
+		schema["$defs"] = defs
+	}
+
+	return schema
+}
+
+// jsonSchema returns the schema of the asset type, adding the definitions it references to defs.
+func (t AssetType) jsonSchema(defs map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"@assetType": map[string]interface{}{
+			"const": t.Tag,
+		},
+		"@key": map[string]interface{}{
+			"type":     "string",
+			"readOnly": true,
+		},
+		"@lastTouchBy": map[string]interface{}{
+			"type":     "string",
+			"readOnly": true,
+		},
+		"@lastTx": map[string]interface{}{
+			"type":     "string",
+			"readOnly": true,
+		},
+		"@lastUpdated": map[string]interface{}{
+			"type":     "string",
+			"format":   "date-time",
+			"readOnly": true,
+		},
+	}
+	if t.IsHybrid() {
+		properties["@privateHashes"] = map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string", "contentEncoding": "base64"},
+			"readOnly":             true,
+		}
+	}
+	if t.Owned {
+		properties["@owner"] = map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"msp": map[string]interface{}{"type": "string"},
+				"id":  map[string]interface{}{"type": "string"},
+			},
+			"readOnly": true,
+		}
+	}
+	required := []string{"@assetType"}
+
+	for _, prop := range t.Props {
+		propSchema := DataTypeJSONSchema(prop.DataType, defs)
+		propSchema["title"] = prop.Label
+		if prop.Description != "" {
+			propSchema["description"] = prop.Description
+		}
+		if prop.ReadOnly {
+			propSchema["readOnly"] = true
+		}
+		if prop.DefaultValue != nil {
+			propSchema["default"] = prop.DefaultValue
+		}
+		properties[prop.Tag] = propSchema
+
+		if prop.IsKey || prop.Required {
+			required = append(required, prop.Tag)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"title":      t.Label,
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	if t.Description != "" {
+		schema["description"] = t.Description
+	}
+
+	return schema
+}
+
+// AssetJSONSchema returns a reference to the schema of the asset type,
+// adding its definition to defs.
+func AssetJSONSchema(assetTypeTag string, defs map[string]interface{}) map[string]interface{} {
+	if _, defined := defs[assetTypeTag]; !defined {
+		assetType := FetchAssetType(assetTypeTag)
+		if assetType == nil {
+			return map[string]interface{}{}
+		}
+
+		// Set before building to support self references
+		defs[assetTypeTag] = map[string]interface{}{}
+		defs[assetTypeTag] = assetType.jsonSchema(defs)
+	}
+
+	return map[string]interface{}{
+		"$ref": "#/$defs/" + assetTypeTag,
+	}
+}
+
+// KeyJSONSchema returns a reference to the schema of the keys of the asset type,
+// adding its definition to defs. An empty tag or "@asset" refers to keys of any asset type.
+func KeyJSONSchema(assetTypeTag string, defs map[string]interface{}) map[string]interface{} {
+	if assetTypeTag == "" {
+		assetTypeTag = "@asset"
+	}
+	defName := assetTypeTag + ".key"
+
+	if _, defined := defs[defName]; !defined {
+		if assetTypeTag == "@asset" {
+			defs[defName] = map[string]interface{}{
+				"title": "Asset key",
+				"type":  "object",
+				"properties": map[string]interface{}{
+					"@assetType": map[string]interface{}{"type": "string"},
+					"@key":       map[string]interface{}{"type": "string"},
+				},
+				"anyOf": []interface{}{
+					map[string]interface{}{"required": []string{"@key"}},
+					map[string]interface{}{"required": []string{"@assetType"}},
+				},
+			}
+		} else {
+			assetType := FetchAssetType(assetTypeTag)
+			if assetType == nil {
+				return map[string]interface{}{}
+			}
+
+			// Set before building to support self references
+			defs[defName] = map[string]interface{}{}
+
+			properties := map[string]interface{}{
+				"@assetType": map[string]interface{}{"const": assetTypeTag},
+				"@key":       map[string]interface{}{"type": "string"},
+			}
+			keyProps := []string{}
+			for _, prop := range assetType.Keys() {
+				propSchema := DataTypeJSONSchema(prop.DataType, defs)
+				propSchema["title"] = prop.Label
+				properties[prop.Tag] = propSchema
+				keyProps = append(keyProps, prop.Tag)
+			}
+
+			defs[defName] = map[string]interface{}{
+				"title":      assetType.Label + " key",
+				"type":       "object",
+				"properties": properties,
+				"anyOf": []interface{}{
+					map[string]interface{}{"required": []string{"@key"}},
+					map[string]interface{}{"required": keyProps},
+				},
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"$ref": "#/$defs/" + defName,
+	}
+}
+
+// DataTypeJSONSchema returns the schema of the values of a prop data type,
+// adding the definitions it references to defs.
+func DataTypeJSONSchema(dataTypeName string, defs map[string]interface{}) map[string]interface{} {
+	if strings.HasPrefix(dataTypeName, "[]") {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": DataTypeJSONSchema(strings.TrimPrefix(dataTypeName, "[]"), defs),
+		}
+	}
+
+	if strings.HasPrefix(dataTypeName, "->") {
+		return KeyJSONSchema(strings.TrimPrefix(dataTypeName, "->"), defs)
+	}
+
+	schema := map[string]interface{}{}
+	switch dataTypeName {
+	case "string", "number", "integer", "boolean":
+		schema["type"] = dataTypeName
+	case "datetime":
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+	case "@object":
+		schema["type"] = "object"
+	default:
+		dataType := FetchDataType(dataTypeName)
+		if dataType == nil {
+			return schema
+		}
+
+		types := []string{}
+		for _, format := range dataType.AcceptedFormats {
+			switch format {
+			case "string", "number", "integer", "boolean":
+				types = append(types, format)
+			case "datetime":
+				types = append(types, "string")
+				schema["format"] = "date-time"
+			case "@object":
+				types = append(types, "object")
+			}
+		}
+		if len(types) == 1 {
+			schema["type"] = types[0]
+		} else if len(types) > 1 {
+			schema["type"] = types
+		}
+
+		if dataType.Description != "" {
+			schema["description"] = dataType.Description
+		}
+	}
+
+	if dataType := FetchDataType(dataTypeName); dataType != nil && len(dataType.DropDownValues) > 0 {
+		labels := make([]string, 0, len(dataType.DropDownValues))
+		for label := range dataType.DropDownValues {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		enum := make([]interface{}, 0, len(labels))
+		for _, label := range labels {
+			enum = append(enum, dataType.DropDownValues[label])
+		}
+		schema["enum"] = enum
+	}
+
+	return schema
+}
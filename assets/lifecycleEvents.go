@@ -0,0 +1,159 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// Names of the chaincode events emitted for asset types with LifecycleEvents enabled
+const (
+	AssetCreatedEvent = "assetCreated"
+	AssetUpdatedEvent = "assetUpdated"
+	AssetDeletedEvent = "assetDeleted"
+)
+
+// LifecycleEventPayload is the payload of the events emitted when assets are created, updated or deleted.
+// Old and New values are omitted for private asset types, and the props stored in private collections
+// are omitted for hybrid asset types.
+type LifecycleEventPayload struct {
+	Key       string                 `json:"@key"`
+	AssetType string                 `json:"@assetType"`
+	Changed   []string               `json:"changed"`
+	Old       map[string]interface{} `json:"old,omitempty"`
+	New       map[string]interface{} `json:"new,omitempty"`
+	Redacted  bool                   `json:"redacted,omitempty"`
+	TxID      string                 `json:"txId"`
+	MSP       string                 `json:"msp"`
+}
+
+// emitLifecycleEvent sets the chaincode event describing the change of an asset from old to new.
+// Either of them is nil when the asset is created or deleted.
+func emitLifecycleEvent(stub *sw.StubWrapper, name string, old, new Asset) errors.ICCError {
+	current := new
+	if current == nil {
+		current = old
+	}
+	assetTypeDef := current.Type()
+	if assetTypeDef == nil || !assetTypeDef.LifecycleEvents {
+		return nil
+	}
+
+	mspID, err := stub.GetMSPID()
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+	}
+
+	changed, err := changedProps(*assetTypeDef, old, new)
+	if err != nil {
+		return err
+	}
+
+	payload := LifecycleEventPayload{
+		Key:       current.Key(),
+		AssetType: current.TypeTag(),
+		Changed:   changed,
+		TxID:      stub.Stub.GetTxID(),
+		MSP:       mspID,
+	}
+	if assetTypeDef.IsPrivate() {
+		payload.Redacted = true
+	} else {
+		payload.Old = old.redactPrivateProps()
+		payload.New = new.redactPrivateProps()
+		payload.Redacted = assetTypeDef.IsHybrid()
+	}
+
+	payloadJSON, nerr := json.Marshal(payload)
+	if nerr != nil {
+		return errors.WrapErrorWithStatus(nerr, "failed to marshal lifecycle event", 500)
+	}
+
+	err = stub.SetEvent(name, payloadJSON)
+	if err != nil {
+		return errors.WrapError(err, "failed to emit lifecycle event")
+	}
+
+	return nil
+}
+
+// storedForEvent returns the current state of the asset, with the props stored in private collections,
+// to be the old state in its lifecycle event. It is nil if the asset does not exist or its type
+// does not have lifecycle events.
+func (a Asset) storedForEvent(stub *sw.StubWrapper) (Asset, errors.ICCError) {
+	assetTypeDef := a.Type()
+	if assetTypeDef == nil || !assetTypeDef.LifecycleEvents {
+		return nil, nil
+	}
+
+	old, err := a.stored(stub)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil && assetTypeDef.IsHybrid() {
+		err = mergePrivateProps(stub, old, false)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to get asset current state")
+		}
+	}
+
+	return old, nil
+}
+
+// changedProps returns the tags of the props whose values differ between old and new, sorted.
+// For owned asset types, "@owner" is included when the owner changes.
+func changedProps(assetTypeDef AssetType, old, new Asset) ([]string, errors.ICCError) {
+	tags := []string{}
+	for _, prop := range assetTypeDef.Props {
+		tags = append(tags, prop.Tag)
+	}
+	if assetTypeDef.Owned {
+		tags = append(tags, "@owner")
+	}
+
+	changed := []string{}
+	for _, tag := range tags {
+		oldJSON, err := json.Marshal(old[tag])
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to marshal asset property", 500)
+		}
+		newJSON, err := json.Marshal(new[tag])
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to marshal asset property", 500)
+		}
+		if !bytes.Equal(oldJSON, newJSON) {
+			changed = append(changed, tag)
+		}
+	}
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+// redactPrivateProps returns the asset without the props of hybrid types stored in
+// private collections and without the hashes of the private parts.
+func (a Asset) redactPrivateProps() map[string]interface{} {
+	if a == nil {
+		return nil
+	}
+
+	public, _ := a.splitPrivateProps()
+	return public
+}
+
+// copyAsset returns a shallow copy of the asset, so its previous values are kept when it is updated.
+func copyAsset(a Asset) Asset {
+	if a == nil {
+		return nil
+	}
+
+	c := Asset{}
+	for k, v := range a {
+		c[k] = v
+	}
+
+	return c
+}
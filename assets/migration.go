@@ -0,0 +1,317 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Migration upgrades an asset from one version of its asset type to the next.
+// It receives a copy of the stored asset, including its internal properties.
+type Migration func(old Asset) (Asset, error)
+
+// MigrationCheckpoint records the progress of the migration of the assets of a type to a version.
+// It is stored under the composite key ("@migration", [asset type tag, version]).
+type MigrationCheckpoint struct {
+	AssetType string `json:"assetType"`
+	Version   int    `json:"version"`
+
+	// LastKey is the key of the last asset visited by the migration
+	LastKey string `json:"lastKey"`
+
+	// Migrated is the number of assets rewritten so far
+	Migrated int `json:"migrated"`
+
+	// Done is set once every asset of the type was visited
+	Done bool `json:"done"`
+}
+
+// SchemaVersion returns the version of the asset type the asset was written with.
+// Assets written before their type was versioned are version 0.
+func (a Asset) SchemaVersion() int {
+	switch v := a["@schemaVersion"].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// Migrate upgrades the asset to the current version of its asset type, applying the
+// migrations of each version in order. The migrated asset must keep the same key.
+func (t AssetType) Migrate(a Asset) (Asset, errors.ICCError) {
+	version := a.SchemaVersion()
+	if version > t.Version {
+		return nil, errors.NewCCError(fmt.Sprintf("asset %s has version %d, newer than version %d of asset type '%s'", a.Key(), version, t.Version, t.Tag), http.StatusBadRequest)
+	}
+	if version == t.Version {
+		return a, nil
+	}
+
+	migrated := a
+	for v := version; v < t.Version; v++ {
+		migration, exists := t.Migrations[v]
+		if !exists || migration == nil {
+			return nil, errors.NewCCError(fmt.Sprintf("asset type '%s' has no migration from version %d", t.Tag, v), http.StatusInternalServerError)
+		}
+
+		old := Asset{}
+		for k, v := range migrated {
+			old[k] = v
+		}
+
+		var err error
+		migrated, err = migration(old)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, fmt.Sprintf("failed to migrate asset %s from version %d", a.Key(), v), http.StatusInternalServerError)
+		}
+		if migrated == nil {
+			return nil, errors.NewCCError(fmt.Sprintf("migration of asset type '%s' from version %d returned nil", t.Tag, v), http.StatusInternalServerError)
+		}
+	}
+
+	oldKey, err := GenerateKey(a)
+	if err != nil {
+		return nil, errors.WrapError(err, "error generating key for asset")
+	}
+
+	migrated["@assetType"] = t.Tag
+	delete(migrated, "@key")
+	key, err := GenerateKey(migrated)
+	if err != nil {
+		return nil, errors.WrapError(err, "error generating key for migrated asset")
+	}
+	if key != oldKey {
+		return nil, errors.NewCCError(fmt.Sprintf("migration changed the key of asset %s to %s", oldKey, key), http.StatusInternalServerError)
+	}
+	migrated["@key"] = key
+
+	err = migrated.ValidateProps()
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, fmt.Sprintf("migrated asset %s is invalid", a.Key()), http.StatusInternalServerError)
+	}
+	migrated["@schemaVersion"] = t.Version
+
+	return migrated, nil
+}
+
+// upgrade applies the migrations of its asset type to an asset read from the ledger, if the asset
+// was written with an older version of the type. It returns whether the asset was upgraded.
+func upgrade(asset map[string]interface{}) (map[string]interface{}, bool, errors.ICCError) {
+	assetTypeTag, _ := asset["@assetType"].(string)
+	assetType := FetchAssetType(assetTypeTag)
+	if assetType == nil || Asset(asset).SchemaVersion() >= assetType.Version {
+		return asset, false, nil
+	}
+
+	upgraded, err := assetType.Migrate(asset)
+	if err != nil {
+		return nil, false, errors.WrapError(err, "failed to upgrade asset")
+	}
+
+	return upgraded, true, nil
+}
+
+// MigrateAssets migrates up to pageSize assets of the type to its current version, resuming
+// from the checkpoint of previous calls. It fails with status 409 once the migration is done.
+func MigrateAssets(stub *sw.StubWrapper, assetTypeTag string, pageSize int) (*MigrationCheckpoint, errors.ICCError) {
+	assetType := FetchAssetType(assetTypeTag)
+	if assetType == nil {
+		return nil, errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", assetTypeTag), http.StatusNotFound)
+	}
+	if assetType.Version == 0 {
+		return nil, errors.NewCCError(fmt.Sprintf("asset type '%s' is not versioned", assetTypeTag), http.StatusBadRequest)
+	}
+	if pageSize <= 0 {
+		return nil, errors.NewCCError("page size must be positive", http.StatusBadRequest)
+	}
+
+	checkpointKey, err := stub.CreateCompositeKey("@migration", []string{assetTypeTag, strconv.Itoa(assetType.Version)})
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed generating migration checkpoint key", http.StatusInternalServerError)
+	}
+
+	checkpoint := MigrationCheckpoint{
+		AssetType: assetTypeTag,
+		Version:   assetType.Version,
+	}
+	checkpointJSON, err := stub.GetState(checkpointKey)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to read migration checkpoint", http.StatusInternalServerError)
+	}
+	if checkpointJSON != nil {
+		jsonErr := json.Unmarshal(checkpointJSON, &checkpoint)
+		if jsonErr != nil {
+			return nil, errors.WrapErrorWithStatus(jsonErr, "failed to unmarshal migration checkpoint", http.StatusInternalServerError)
+		}
+	}
+	if checkpoint.Done {
+		return nil, errors.NewCCError(fmt.Sprintf("assets of type '%s' were already migrated to version %d", assetTypeTag, assetType.Version), http.StatusConflict)
+	}
+
+	// Asset keys are "<tag>:<uuid>", so the assets of the type are in the range ["<tag>:", "<tag>;")
+	startKey := assetTypeTag + ":"
+	if checkpoint.LastKey != "" {
+		startKey = checkpoint.LastKey + "\x00"
+	}
+	endKey := assetTypeTag + ";"
+
+	var it shim.StateQueryIteratorInterface
+	if assetType.IsPrivate() {
+		it, err = stub.GetPrivateDataByRange(assetType.CollectionName(), startKey, endKey)
+	} else {
+		it, err = stub.GetStateByRange(startKey, endKey)
+	}
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to read assets", http.StatusInternalServerError)
+	}
+	defer it.Close()
+
+	page := make([]Asset, 0, pageSize)
+	for len(page) < pageSize && it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "failed to iterate over assets", http.StatusInternalServerError)
+		}
+
+		var asset map[string]interface{}
+		err = json.Unmarshal(kv.Value, &asset)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, fmt.Sprintf("failed to unmarshal asset %s", kv.Key), http.StatusInternalServerError)
+		}
+		page = append(page, asset)
+		checkpoint.LastKey = kv.Key
+	}
+	checkpoint.Done = !it.HasNext()
+
+	for _, asset := range page {
+		if asset.SchemaVersion() == assetType.Version {
+			continue
+		}
+
+		// The migrations of hybrid types must see the private props, which are rewritten along with the public part
+		if assetType.IsHybrid() {
+			unreadable := assetType.unreadablePropCollections(stub, asset.Key())
+			for _, collection := range assetType.PropCollections() {
+				if unreadable[collection] {
+					return nil, errors.NewCCError(fmt.Sprintf("cannot migrate asset %s without access to collection %s", asset.Key(), collection), http.StatusForbidden)
+				}
+			}
+
+			err = mergePrivateProps(stub, asset, false)
+			if err != nil {
+				return nil, errors.WrapError(err, fmt.Sprintf("failed to read private props of asset %s", asset.Key()))
+			}
+		}
+
+		migrated, err := assetType.Migrate(asset)
+		if err != nil {
+			return nil, err
+		}
+
+		err = delRefs(stub, asset.Key(), storedRefs(asset))
+		if err != nil {
+			return nil, errors.WrapError(err, "failed erasing old reference indexes")
+		}
+
+		// Private parts are written from scratch, so props dropped by the migration are erased
+		if assetType.IsHybrid() {
+			err = asset.delPrivateProps(stub)
+			if err != nil {
+				return nil, errors.WrapError(err, fmt.Sprintf("failed erasing private props of asset %s", asset.Key()))
+			}
+		}
+
+		err = migrated.injectMetadata(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed injecting asset metadata")
+		}
+
+		err = migrated.validateRefs(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, fmt.Sprintf("invalid references in migrated asset %s", asset.Key()))
+		}
+
+		_, err = migrated.put(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, fmt.Sprintf("failed to write migrated asset %s", asset.Key()))
+		}
+		checkpoint.Migrated++
+	}
+
+	checkpointJSON, jsonErr := json.Marshal(checkpoint)
+	if jsonErr != nil {
+		return nil, errors.WrapErrorWithStatus(jsonErr, "failed to marshal migration checkpoint", http.StatusInternalServerError)
+	}
+	err = stub.PutState(checkpointKey, checkpointJSON)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to write migration checkpoint", http.StatusInternalServerError)
+	}
+
+	return &checkpoint, nil
+}
+
+// storedRefs returns the references stored in the asset, regardless of the current
+// definition of its type, since the props holding them may have changed.
+func storedRefs(a Asset) []Key {
+	refs := []Key{}
+	for propTag, value := range a {
+		if len(propTag) > 0 && propTag[0] == '@' {
+			continue
+		}
+
+		values, isArray := value.([]interface{})
+		if !isArray {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			ref, isMap := v.(map[string]interface{})
+			if !isMap {
+				continue
+			}
+			_, hasType := ref["@assetType"].(string)
+			_, hasKey := ref["@key"].(string)
+			if hasType && hasKey && len(ref) == 2 {
+				refs = append(refs, Key(ref))
+			}
+		}
+	}
+	return refs
+}
+
+// checkMigrations verifies if the migrations of the asset type lead to its current version
+func (t AssetType) checkMigrations() errors.ICCError {
+	if t.Version < 0 {
+		return errors.NewCCError(fmt.Sprintf("asset type '%s' has negative version", t.Tag), 500)
+	}
+
+	oldest := t.Version
+	for v, migration := range t.Migrations {
+		if v < 0 || v >= t.Version {
+			return errors.NewCCError(fmt.Sprintf("asset type '%s' has migration from version %d, out of the range [0, %d)", t.Tag, v, t.Version), 500)
+		}
+		if migration == nil {
+			return errors.NewCCError(fmt.Sprintf("asset type '%s' has nil migration from version %d", t.Tag, v), 500)
+		}
+		if v < oldest {
+			oldest = v
+		}
+	}
+
+	// Assets of versions older than the first migration cannot be upgraded, so there must be no gaps after it
+	for v := oldest; v < t.Version; v++ {
+		if _, exists := t.Migrations[v]; !exists {
+			return errors.NewCCError(fmt.Sprintf("asset type '%s' has no migration from version %d", t.Tag, v), 500)
+		}
+	}
+
+	return nil
+}
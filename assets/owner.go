@@ -0,0 +1,223 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// ownerAdminOU is the OU of the admins of an organization, who can manage the assets
+// owned by its members.
+const ownerAdminOU = "admin"
+
+// Owner returns the identity recorded in the "@owner" property of the asset, or nil if
+// the asset has no owner.
+func (a Asset) Owner() map[string]interface{} {
+	owner, _ := a["@owner"].(map[string]interface{})
+	return owner
+}
+
+// CheckOwner checks if tx creator is allowed to write an asset of the type owned by owner.
+// Assets without an owner can be written by anyone allowed by the other permissions.
+func (t AssetType) CheckOwner(stub *sw.StubWrapper, owner map[string]interface{}) (bool, errors.ICCError) {
+	if !t.Owned || owner == nil {
+		return true, nil
+	}
+
+	isOwner, err := isOwnerOrAdmin(stub, owner)
+	if err != nil {
+		return false, err
+	}
+	if isOwner {
+		return true, nil
+	}
+
+	if t.OwnerDelegates != nil {
+		return allowCaller(stub, t.OwnerDelegates)
+	}
+
+	return false, nil
+}
+
+// checkOwnership returns a 403 error if tx creator is not allowed to write the asset. The
+// owner stored in the ledger is kept, and the tx creator is recorded as the owner of new assets.
+func (a *Asset) checkOwnership(stub *sw.StubWrapper) errors.ICCError {
+	assetTypeDef := a.Type()
+	if assetTypeDef == nil || !assetTypeDef.Owned {
+		return nil
+	}
+
+	stored, err := a.stored(stub)
+	if err != nil {
+		return errors.WrapError(err, "failed to fetch stored asset")
+	}
+
+	if stored == nil {
+		owner, err := callerIdentity(stub)
+		if err != nil {
+			return err
+		}
+		(*a)["@owner"] = owner
+		return nil
+	}
+
+	owner := stored.Owner()
+	ownerPermission, err := assetTypeDef.CheckOwner(stub, owner)
+	if err != nil {
+		return errors.WrapError(err, "failed to check owner permission")
+	}
+	if !ownerPermission {
+		return errors.NewCCError(fmt.Sprintf("caller cannot write asset owned by %s", formatOwner(owner)), 403)
+	}
+
+	delete(*a, "@owner")
+	if owner != nil {
+		(*a)["@owner"] = owner
+	}
+
+	return nil
+}
+
+// TransferOwnership records newOwner as the owner of the asset. Only the current owner and
+// the admins of its organization can transfer the ownership of an asset, and assets without
+// an owner can be claimed by anyone allowed to write assets of the type.
+func (k *Key) TransferOwnership(stub *sw.StubWrapper, newOwner map[string]interface{}) (map[string]interface{}, errors.ICCError) {
+	// Fetch asset properties
+	assetTypeDef := k.Type()
+	if assetTypeDef == nil {
+		return nil, errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", k.TypeTag()), 400)
+	}
+	if !assetTypeDef.Owned {
+		return nil, errors.NewCCError(fmt.Sprintf("assets of type %s do not have owners", assetTypeDef.Tag), 400)
+	}
+
+	ownerMSP, mspOk := newOwner["msp"].(string)
+	ownerID, idOk := newOwner["id"].(string)
+	if !mspOk || !idOk || ownerMSP == "" || ownerID == "" {
+		return nil, errors.NewCCError("new owner must have non-empty 'msp' and 'id' strings", 400)
+	}
+
+	err := assetTypeDef.checkWriterCallers(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	assetMap, err := k.GetMap(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to get asset current state")
+	}
+	asset := Asset(assetMap)
+
+	if owner := asset.Owner(); owner != nil {
+		isOwner, err := isOwnerOrAdmin(stub, owner)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to check owner permission")
+		}
+		if !isOwner {
+			return nil, errors.NewCCError(fmt.Sprintf("caller cannot transfer asset owned by %s", formatOwner(owner)), 403)
+		}
+	}
+
+	old := copyAsset(asset)
+	storedVersion, hasVersion := asset["@schemaVersion"]
+
+	err = asset.validateRefs(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed reference validation")
+	}
+
+	err = asset.injectMetadata(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed injecting asset metadata")
+	}
+	asset["@owner"] = map[string]interface{}{
+		"msp": ownerMSP,
+		"id":  ownerID,
+	}
+
+	// The asset is not upgraded, so it keeps the version it was written with
+	delete(asset, "@schemaVersion")
+	if hasVersion {
+		asset["@schemaVersion"] = storedVersion
+	}
+
+	ret, err := asset.put(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed putting asset in ledger")
+	}
+
+	err = emitLifecycleEvent(stub, AssetUpdatedEvent, old, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// stored returns the asset as it is stored in the ledger, or nil if it does not exist.
+func (a Asset) stored(stub *sw.StubWrapper) (Asset, errors.ICCError) {
+	var assetBytes []byte
+	var err errors.ICCError
+	if a.IsPrivate() {
+		assetBytes, err = stub.GetPrivateData(a.CollectionName(), a.Key())
+	} else {
+		assetBytes, err = stub.GetState(a.Key())
+	}
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to read asset from ledger", 500)
+	}
+	if assetBytes == nil {
+		return nil, nil
+	}
+
+	var stored Asset
+	nerr := json.Unmarshal(assetBytes, (*map[string]interface{})(&stored))
+	if nerr != nil {
+		return nil, errors.WrapErrorWithStatus(nerr, "failed to unmarshal asset from ledger", 500)
+	}
+
+	return stored, nil
+}
+
+// callerIdentity returns the MSP and the ID of tx creator in the "@owner" format.
+func callerIdentity(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCError) {
+	mspID, err := stub.GetMSPID()
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+	}
+	id, err := stub.GetID()
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+	}
+
+	return map[string]interface{}{
+		"msp": mspID,
+		"id":  id,
+	}, nil
+}
+
+// isOwnerOrAdmin checks if tx creator is the owner or an admin of the owner's organization.
+func isOwnerOrAdmin(stub *sw.StubWrapper, owner map[string]interface{}) (bool, errors.ICCError) {
+	caller, err := callerIdentity(stub)
+	if err != nil {
+		return false, err
+	}
+	if caller["msp"] == owner["msp"] && caller["id"] == owner["id"] {
+		return true, nil
+	}
+
+	ownerMSP, _ := owner["msp"].(string)
+	if ownerMSP == "" || caller["msp"] != ownerMSP {
+		return false, nil
+	}
+
+	return allowCaller(stub, []accesscontrol.Caller{{MSP: ownerMSP, OU: ownerAdminOU}})
+}
+
+// formatOwner returns the owner in the "msp/id" format used in error messages.
+func formatOwner(owner map[string]interface{}) string {
+	return fmt.Sprintf("%v/%v", owner["msp"], owner["id"])
+}
@@ -24,8 +24,17 @@ func (a *Asset) put(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCErr
 		return nil, errors.WrapError(err, "failed writing reference index")
 	}
 
+	// Write private props of hybrid asset types to their collections, keeping the rest public
+	public := *a
+	if assetTypeDef := a.Type(); assetTypeDef != nil && assetTypeDef.IsHybrid() {
+		public, err = a.putPrivateProps(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to write private props")
+		}
+	}
+
 	// Marshal asset back to JSON format
-	assetJSON, err := json.Marshal(a)
+	assetJSON, err := json.Marshal(public)
 	if err != nil {
 		return nil, errors.WrapError(err, "failed to encode asset to JSON format")
 	}
@@ -48,7 +57,7 @@ func (a *Asset) put(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCErr
 		return nil, errors.WrapError(err, "failed to write asset to ledger")
 	}
 
-	return *a, nil
+	return public, nil
 }
 
 // Put inserts asset in blockchain
@@ -64,11 +73,38 @@ func (a *Asset) Put(stub *sw.StubWrapper) (map[string]interface{}, errors.ICCErr
 		return nil, errors.WrapError(err, "failed injecting asset metadata")
 	}
 
+	// Check if tx creator is allowed to overwrite owned assets
+	err = a.checkOwnership(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed owner permission check")
+	}
+
 	err = a.validateRefs(stub)
 	if err != nil {
 		return nil, errors.WrapError(err, "failed reference validation")
 	}
-	return a.put(stub)
+
+	// Keep the current state of the asset, if any, for its lifecycle event
+	old, err := a.storedForEvent(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.put(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	eventName := AssetUpdatedEvent
+	if old == nil {
+		eventName = AssetCreatedEvent
+	}
+	err = emitLifecycleEvent(stub, eventName, old, *a)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
 // PutNew inserts asset in blockchain and returns error if asset exists.
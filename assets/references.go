@@ -1,7 +1,6 @@
 package assets
 
 import (
-	"bytes"
 	"fmt"
 	"strings"
 
@@ -160,11 +159,6 @@ func referrers(stub *sw.StubWrapper, assetKey string, assetTypeFilter []string)
 			return nil, errors.WrapError(err, "failed to iterate in reference index")
 		}
 
-		newIndexState, isWritten := stub.WriteSet[ref.GetKey()]
-		if isWritten && newIndexState == nil {
-			continue
-		}
-
 		referredKey, keyParts, err := stub.Stub.SplitCompositeKey(ref.GetKey())
 		if err != nil {
 			return nil, errors.WrapError(err, "failed to split composite key")
@@ -177,29 +171,6 @@ func referrers(stub *sw.StubWrapper, assetKey string, assetTypeFilter []string)
 		retKeys = append(retKeys, keyParts[0])
 	}
 
-	for key, val := range stub.WriteSet {
-		if len(key) > 0 && key[0] == 0x00 {
-			referredKey, keyParts, err := stub.Stub.SplitCompositeKey(key)
-			if err != nil {
-				return nil, errors.WrapError(err, "failed to split composite key")
-			}
-
-			if referredKey != assetKey || len(keyParts) == 0 || !bytes.Equal(val, []byte{0x00}) {
-				continue
-			}
-
-			isCounted := false
-			for _, countedKey := range retKeys {
-				if countedKey == keyParts[0] {
-					isCounted = true
-				}
-			}
-			if !isCounted {
-				retKeys = append(retKeys, keyParts[0])
-			}
-		}
-	}
-
 	var ret []Key
 	for _, retKey := range retKeys {
 		assetType := strings.Split(retKey, ":")[0]
@@ -335,22 +306,13 @@ func isReferenced(stub *sw.StubWrapper, assetKey string) (bool, errors.ICCError)
 	}
 	defer queryIt.Close()
 
-	for queryIt.HasNext() {
-		ref, err := queryIt.Next()
+	// The reference index includes the references written in the transaction
+	if queryIt.HasNext() {
+		_, err := queryIt.Next()
 		if err != nil {
 			return false, errors.WrapError(err, "failed to iterate in reference index")
 		}
-
-		newIndexState, isWritten := stub.WriteSet[ref.GetKey()]
-		if !isWritten || newIndexState != nil {
-			return true, nil
-		}
-	}
-
-	for key, val := range stub.WriteSet {
-		if strings.HasPrefix(key, assetKey) && key != assetKey && bytes.Equal(val, []byte{0x00}) {
-			return true, nil
-		}
+		return true, nil
 	}
 
 	return false, nil
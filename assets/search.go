@@ -39,14 +39,20 @@ func Search(stub *sw.StubWrapper, request map[string]interface{}, privateCollect
 		}
 	}
 
+	// Results can not be selected nor sorted by properties the tx creator cannot read
+	err := checkQueryReaders(stub, request)
+	if err != nil {
+		return nil, err
+	}
+
 	// The "bookmark" and "limit" values are passed as arguments to chaincode API so we delete it from the request
 	delete(request, "bookmark")
 	delete(request, "limit")
 
 	// Marshal query string
-	query, err := json.Marshal(request)
-	if err != nil {
-		return nil, errors.WrapErrorWithStatus(err, "failed marshaling JSON-encoded asset", 500)
+	query, nerr := json.Marshal(request)
+	if nerr != nil {
+		return nil, errors.WrapErrorWithStatus(nerr, "failed marshaling JSON-encoded asset", 500)
 	}
 	queryString := string(query)
 
@@ -87,6 +93,13 @@ func Search(stub *sw.StubWrapper, request map[string]interface{}, privateCollect
 			return nil, errors.WrapErrorWithStatus(err, "failed to unmarshal queryResponse values", 500)
 		}
 
+		if privateCollection == "" {
+			err = mergePrivateProps(stub, data, false)
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to read private props of result")
+			}
+		}
+
 		if resolve {
 			key, err := NewKey(data)
 			if err != nil {
@@ -99,6 +112,11 @@ func Search(stub *sw.StubWrapper, request map[string]interface{}, privateCollect
 			data = asset
 		}
 
+		err = Redact(stub, data)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to redact result")
+		}
+
 		searchResult = append(searchResult, data)
 	}
 
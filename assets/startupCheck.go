@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/errors"
 )
 
@@ -33,6 +34,11 @@ func StartupCheck() errors.ICCError {
 		}
 		assetLabelSet[label] = struct{}{}
 
+		// Check if asset type policy is registered
+		if assetType.Policy != "" && accesscontrol.FetchPolicy(assetType.Policy) == nil {
+			return errors.NewCCError(fmt.Sprintf("policy %s of asset type '%s' is not registered", assetType.Policy, tag), 500)
+		}
+
 		propTagSet := map[string]struct{}{}
 		propLabelSet := map[string]struct{}{}
 		hasKey := false
@@ -106,13 +112,46 @@ func StartupCheck() errors.ICCError {
 				}
 			}
 
+			// Check if readers in regex mode compile
+			for _, r := range propDef.Readers {
+				if len(r) <= 1 {
+					continue
+				}
+				if r[0] == '$' {
+					_, err := regexp.Compile(r[1:])
+					if err != nil {
+						return errors.WrapErrorWithStatus(err, fmt.Sprintf("invalid reader regular expression %s for property %s of asset %s", r, propDef.Label, tag), 500)
+					}
+				}
+			}
+
+			if propDef.Collection != "" {
+				if assetType.IsPrivate() {
+					return errors.NewCCError(fmt.Sprintf("property %s of private asset %s cannot have a collection", propDef.Label, assetType.Tag), 500)
+				}
+				if propDef.IsKey {
+					return errors.NewCCError(fmt.Sprintf("key property %s of asset %s cannot have a collection", propDef.Label, assetType.Tag), 500)
+				}
+			}
+
 			if propDef.IsKey {
+				// Key props are part of every reference to the asset, so they cannot be hidden
+				if propDef.Readers != nil || propDef.ReaderCallers != nil {
+					return errors.NewCCError(fmt.Sprintf("key property %s of asset %s cannot have readers", propDef.Label, assetType.Tag), 500)
+				}
 				hasKey = true
 			}
 		}
 		if !hasKey {
 			return errors.NewCCError(fmt.Sprintf("asset '%s' has no key properties", tag), 500)
 		}
+
+		err := assetType.checkMigrations()
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+
+	// Check if structs bound to asset types match their props
+	return checkAssetStructs()
 }
@@ -0,0 +1,95 @@
+package assets
+
+// TxProposalPolicy is the name of the policy which only allows the proposal
+// transactions to write txProposal assets.
+const TxProposalPolicy = "@txProposal"
+
+// txProposalsEnabled indicates if the txProposal asset type is in the asset type list
+var txProposalsEnabled = false
+
+// InitTxProposals adds the txProposal asset type to the asset type list, or removes it.
+// It is called when the transaction list has transactions requiring approval.
+func InitTxProposals(enabled bool) {
+	txProposalsEnabled = enabled
+
+	l := []AssetType{}
+	for _, assetType := range assetTypeList {
+		if assetType.Tag != "txProposal" {
+			l = append(l, assetType)
+		}
+	}
+	if enabled {
+		l = append(l, GetTxProposalAssetType())
+	}
+	assetTypeList = l
+}
+
+// GetEnabledTxProposals returns true if the txProposal asset type is in the asset type list
+func GetEnabledTxProposals() bool {
+	return txProposalsEnabled
+}
+
+// GetTxProposalAssetType returns the txProposal meta type, which stores the proposals
+// of transactions requiring approval
+func GetTxProposalAssetType() AssetType {
+	var txProposal = AssetType{
+		Tag:         "txProposal",
+		Label:       "Transaction Proposal",
+		Description: "Transaction waiting for the approval of the organizations",
+		Policy:      TxProposalPolicy,
+
+		Props: []AssetProp{
+			{
+				Required: true,
+				IsKey:    true,
+				Tag:      "id",
+				Label:    "ID",
+				DataType: "string",
+			},
+			{
+				Required: true,
+				Tag:      "txName",
+				Label:    "Transaction",
+				DataType: "string",
+			},
+			{
+				Tag:      "args",
+				Label:    "Arguments",
+				DataType: "@object",
+			},
+			{
+				Required: true,
+				Tag:      "proposer",
+				Label:    "Proposer",
+				DataType: "string",
+			},
+			{
+				Tag:      "approvals",
+				Label:    "Approvals",
+				DataType: "[]string",
+			},
+			{
+				Tag:      "rejections",
+				Label:    "Rejections",
+				DataType: "[]string",
+			},
+			{
+				Required: true,
+				Tag:      "status",
+				Label:    "Status",
+				DataType: "string",
+			},
+			{
+				Tag:      "expiresAt",
+				Label:    "Expires At",
+				DataType: "datetime",
+			},
+			{
+				Tag:      "result",
+				Label:    "Result",
+				DataType: "string",
+			},
+		},
+	}
+	return txProposal
+}
@@ -2,7 +2,6 @@ package assets
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/hyperledger-labs/cc-tools/errors"
@@ -17,10 +16,22 @@ func (a *Asset) Update(stub *sw.StubWrapper, update map[string]interface{}) (map
 		return nil, errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", a.TypeTag()), 400)
 	}
 
-	// Get tx creator MSP ID
-	txCreator, err := stub.GetMSPID()
+	// Check if tx creator is allowed to write assets of the type
+	err := assetTypeDef.checkWriterCallers(stub)
 	if err != nil {
-		return nil, errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+		return nil, err
+	}
+
+	// Check if tx creator is allowed to write the asset if it is owned
+	err = a.checkOwnership(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed owner permission check")
+	}
+
+	// Keep the current state of the asset for its lifecycle event
+	old, err := a.storedForEvent(stub)
+	if err != nil {
+		return nil, err
 	}
 
 	// Delete current reference indexes
@@ -47,31 +58,9 @@ func (a *Asset) Update(stub *sw.StubWrapper, update map[string]interface{}) (map
 		}
 
 		// Check if tx creator is allowed to update this attribute
-		if prop.Writers != nil {
-			writePermission := false
-			for _, w := range prop.Writers {
-				if len(w) <= 1 {
-					continue
-				}
-				if w[0] == '$' { // if writer is regexp
-					match, err := regexp.MatchString(w[1:], txCreator)
-					if err != nil {
-						return nil, errors.NewCCError("failed to check if writer matches regexp", 500)
-					}
-					if match {
-						writePermission = true
-						break
-					}
-				} else { // if writer is not regexp
-					if w == txCreator {
-						writePermission = true
-						break
-					}
-				}
-			}
-			if !writePermission {
-				return nil, errors.NewCCError(fmt.Sprintf("%s cannot write to the '%s' (%s) asset property", txCreator, prop.Tag, prop.Label), 403)
-			}
+		err = prop.checkWritePermission(stub)
+		if err != nil {
+			return nil, err
 		}
 
 		// Validate data types
@@ -98,6 +87,11 @@ func (a *Asset) Update(stub *sw.StubWrapper, update map[string]interface{}) (map
 		return nil, errors.WrapError(err, "failed putting asset in ledger")
 	}
 
+	err = emitLifecycleEvent(stub, AssetUpdatedEvent, old, *a)
+	if err != nil {
+		return nil, err
+	}
+
 	return ret, nil
 }
 
@@ -109,10 +103,10 @@ func (k *Key) Update(stub *sw.StubWrapper, update map[string]interface{}) (map[s
 		return nil, errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", k.TypeTag()), 400)
 	}
 
-	// Get tx creator MSP ID
-	txCreator, err := stub.GetMSPID()
+	// Check if tx creator is allowed to write assets of the type
+	err := assetTypeDef.checkWriterCallers(stub)
 	if err != nil {
-		return nil, errors.WrapErrorWithStatus(err, "error getting tx creator", 500)
+		return nil, err
 	}
 
 	// Delete current reference indexes
@@ -125,6 +119,28 @@ func (k *Key) Update(stub *sw.StubWrapper, update map[string]interface{}) (map[s
 	if err != nil {
 		return nil, errors.WrapError(err, "failed to get asset current state")
 	}
+	old := copyAsset(assetMap)
+
+	// Check if tx creator is allowed to write the asset if it is owned
+	ownerPermission, err := assetTypeDef.CheckOwner(stub, Asset(assetMap).Owner())
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to check owner permission")
+	}
+	if !ownerPermission {
+		return nil, errors.NewCCError(fmt.Sprintf("caller cannot write asset owned by %s", formatOwner(Asset(assetMap).Owner())), 403)
+	}
+
+	storedVersion, hasVersion := assetMap["@schemaVersion"]
+	if assetTypeDef.WriteBack {
+		assetMap, _, err = upgrade(assetMap)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to upgrade asset current state")
+		}
+	}
+	outdated := Asset(assetMap).SchemaVersion() < assetTypeDef.Version
+
+	// Props in private collections the tx creator cannot read are left as they are stored
+	unreadable := assetTypeDef.unreadablePropCollections(stub, k.Key())
 
 	// Validate new asset properties
 	for _, prop := range assetTypeDef.Props {
@@ -143,30 +159,14 @@ func (k *Key) Update(stub *sw.StubWrapper, update map[string]interface{}) (map[s
 			return nil, errors.NewCCError(fmt.Sprintf("cannot update asset property %s", prop.Label), 403)
 		}
 
+		if unreadable[prop.Collection] {
+			return nil, errors.NewCCError(fmt.Sprintf("cannot update asset property %s stored in collection %s", prop.Label, prop.Collection), 403)
+		}
+
 		// Check if tx creator is allowed to update this attribute
-		if prop.Writers != nil {
-			writePermission := false
-			for _, w := range prop.Writers {
-				if len(w) <= 1 {
-					continue
-				}
-				if w[0] == '$' { // if writer is regexp
-					match, err := regexp.MatchString(w[1:], txCreator)
-					if err != nil {
-						return nil, errors.NewCCError("failed to check if writer matches regexp", 500)
-					}
-					if match {
-						writePermission = true
-						break
-					}
-				} else if w == txCreator { // if writer is not regexp
-					writePermission = true
-					break
-				}
-			}
-			if !writePermission {
-				return nil, errors.NewCCError(fmt.Sprintf("%s cannot write to the '%s' (%s) asset property", txCreator, prop.Tag, prop.Label), 403)
-			}
+		err = prop.checkWritePermission(stub)
+		if err != nil {
+			return nil, err
 		}
 
 		// Validate data types
@@ -178,7 +178,7 @@ func (k *Key) Update(stub *sw.StubWrapper, update map[string]interface{}) (map[s
 		assetMap[prop.Tag] = propInterface
 	}
 
-	newAsset, err := NewAsset(assetMap)
+	newAsset, err := newAssetSkipping(assetMap, unreadable)
 	if err != nil {
 		return nil, errors.WrapError(err, "could not construct asset object after update")
 	}
@@ -193,11 +193,24 @@ func (k *Key) Update(stub *sw.StubWrapper, update map[string]interface{}) (map[s
 		return nil, errors.WrapError(err, "failed injecting asset metadata")
 	}
 
+	// Outdated assets which were not upgraded keep the version they were written with
+	if outdated {
+		delete(newAsset, "@schemaVersion")
+		if hasVersion {
+			newAsset["@schemaVersion"] = storedVersion
+		}
+	}
+
 	ret, err := newAsset.put(stub)
 	if err != nil {
 		return nil, errors.WrapError(err, "failed putting asset in ledger")
 	}
 
+	err = emitLifecycleEvent(stub, AssetUpdatedEvent, old, newAsset)
+	if err != nil {
+		return nil, err
+	}
+
 	return ret, nil
 }
 
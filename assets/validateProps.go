@@ -9,6 +9,12 @@ import (
 
 // ValidateProps checks if all props are compliant to format
 func (a Asset) ValidateProps() errors.ICCError {
+	return a.validateProps(nil)
+}
+
+// validateProps checks if all props are compliant to format, except for the props stored in
+// the skipped collections, which are neither required nor filled with their default values.
+func (a Asset) validateProps(skipCollections map[string]bool) errors.ICCError {
 	// Perform validation of the @assetType field
 	assetType, exists := a["@assetType"]
 	if !exists {
@@ -29,6 +35,9 @@ func (a Asset) ValidateProps() errors.ICCError {
 	for _, prop := range assetTypeDef.Props {
 		// Check if required property is included
 		propInterface, propIncluded := a[prop.Tag]
+		if !propIncluded && prop.Collection != "" && skipCollections[prop.Collection] {
+			continue
+		}
 		if !propIncluded {
 			if prop.DefaultValue == nil {
 				if prop.Required {
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// RelayEventTag is the tag of the event raised for events of type EventTransaction
+// targeting another channel, which must be relayed by an off-chain listener.
+const RelayEventTag = "@relay"
+
+// RelayEnvelope is the payload of the relay event. It has everything an off-chain
+// listener needs to submit the triggered transaction to the target channel.
+type RelayEnvelope struct {
+	// Event is the tag of the event which triggered the transaction
+	Event string `json:"event"`
+
+	// Channel is the channel of the transaction to be submitted
+	Channel string `json:"channel"`
+
+	// Chaincode is the chaincode of the transaction to be submitted.
+	// When empty, it is the same chaincode which raised the event.
+	Chaincode string `json:"chaincode,omitempty"`
+
+	// Transaction is the tag of the transaction to be submitted
+	Transaction string `json:"transaction"`
+
+	// Args are the args of the transaction, taken from the event payload
+	Args map[string]interface{} `json:"args"`
+
+	// SourceChannel is the channel where the event was raised
+	SourceChannel string `json:"sourceChannel"`
+
+	// SourceTxID is the ID of the transaction which raised the event
+	SourceTxID string `json:"sourceTxId"`
+}
+
+// InvokeArgs returns the args used to submit the relayed transaction to a cc-tools chaincode.
+func (r RelayEnvelope) InvokeArgs() ([][]byte, error) {
+	argsJSON, err := json.Marshal(r.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]byte{[]byte(r.Transaction), argsJSON}, nil
+}
+
+// DecodeRelay returns the relay envelope carried by the payload of a relay event.
+func DecodeRelay(payload []byte) (*RelayEnvelope, error) {
+	var relay RelayEnvelope
+	err := json.Unmarshal(payload, &relay)
+	if err != nil {
+		return nil, err
+	}
+
+	return &relay, nil
+}
+
+// MaxEventDepth is the maximum number of nested transactions triggered by events of type
+// EventTransaction within a transaction, which stops events triggering each other forever.
+const MaxEventDepth = 8
+
+// txRunner runs a transaction of the chaincode with the given args.
+// It is set by the transactions package.
+var txRunner func(stub *sw.StubWrapper, txName string, args map[string]interface{}) ([]byte, errors.ICCError)
+
+// SetTxRunner sets the function which runs the transactions triggered by events on the same
+// channel and chaincode. It is called by transactions.InitTxList.
+func SetTxRunner(runner func(stub *sw.StubWrapper, txName string, args map[string]interface{}) ([]byte, errors.ICCError)) {
+	txRunner = runner
+}
+
+// IsLocal returns true if the transaction triggered by the event runs on the chaincode raising it.
+func (event Event) IsLocal(channel string) bool {
+	return event.Chaincode == "" && (event.Channel == "" || event.Channel == channel)
+}
+
+// dispatch triggers the transaction of an event of type EventTransaction. The payload holds the
+// args of the transaction as a JSON object. Transactions on the same channel run as part of the
+// current transaction, while transactions on other channels are relayed through a relay event.
+func (event Event) dispatch(stub *sw.StubWrapper, payload []byte) errors.ICCError {
+	var args map[string]interface{}
+	if len(payload) > 0 {
+		err := json.Unmarshal(payload, &args)
+		if err != nil {
+			return errors.WrapErrorWithStatus(err, fmt.Sprintf("payload of event %s must be a JSON object with the args of tx %s", event.Tag, event.Transaction), http.StatusBadRequest)
+		}
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	channel := stub.Stub.GetChannelID()
+	if event.IsLocal(channel) {
+		if txRunner == nil {
+			return errors.NewCCError(fmt.Sprintf("cannot run tx %s of event %s without a tx list", event.Transaction, event.Tag), http.StatusInternalServerError)
+		}
+
+		if stub.EventDepth >= MaxEventDepth {
+			return errors.NewCCError(fmt.Sprintf("tx %s of event %s exceeds the maximum of %d nested event transactions", event.Transaction, event.Tag, MaxEventDepth), http.StatusBadRequest)
+		}
+
+		stub.EventDepth++
+		_, err := txRunner(stub, event.Transaction, args)
+		stub.EventDepth--
+		if err != nil {
+			return errors.WrapError(err, fmt.Sprintf("tx %s triggered by event %s failed", event.Transaction, event.Tag))
+		}
+		return nil
+	}
+
+	if event.Channel == "" || event.Channel == channel {
+		argsJSON, err := json.Marshal(args)
+		if err != nil {
+			return errors.WrapErrorWithStatus(err, "failed to marshal args", http.StatusInternalServerError)
+		}
+
+		res := stub.Stub.InvokeChaincode(event.Chaincode, [][]byte{[]byte(event.Transaction), argsJSON}, "")
+		if res.GetStatus() != http.StatusOK {
+			status := res.GetStatus()
+			if status < http.StatusBadRequest {
+				status = http.StatusInternalServerError
+			}
+			return errors.NewCCError(fmt.Sprintf("tx %s of chaincode %s triggered by event %s failed: %s", event.Transaction, event.Chaincode, event.Tag, res.GetMessage()), status)
+		}
+		return nil
+	}
+
+	if len(event.Receivers) > 0 {
+		return errors.NewCCError(fmt.Sprintf("event %s with receivers cannot relay tx %s to channel %s", event.Tag, event.Transaction, event.Channel), http.StatusInternalServerError)
+	}
+
+	relayJSON, err := json.Marshal(RelayEnvelope{
+		Event:         event.Tag,
+		Channel:       event.Channel,
+		Chaincode:     event.Chaincode,
+		Transaction:   event.Transaction,
+		Args:          args,
+		SourceChannel: channel,
+		SourceTxID:    stub.Stub.GetTxID(),
+	})
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, "failed to marshal relay envelope", http.StatusInternalServerError)
+	}
+
+	return stub.SetEvent(RelayEventTag, relayJSON)
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"encoding/json"
+
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// EmittedEvent is an event raised by a transaction, with its tag and payload.
+type EmittedEvent = sw.Event
+
+// DecodeEnvelope returns the events carried by the payload of the envelope event
+// set by a transaction, in the order they were raised.
+func DecodeEnvelope(payload []byte) ([]EmittedEvent, error) {
+	var envelope sw.EventEnvelope
+	err := json.Unmarshal(payload, &envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.Events, nil
+}
+
+// DecodeChaincodeEvent returns the events carried by a chaincode event received by a client.
+// Events which were not set in an envelope are returned as a single event.
+func DecodeChaincodeEvent(event *pb.ChaincodeEvent) ([]EmittedEvent, error) {
+	if event.GetEventName() != sw.EventEnvelopeName {
+		return []EmittedEvent{{Tag: event.GetEventName(), Payload: event.GetPayload()}}, nil
+	}
+
+	return DecodeEnvelope(event.GetPayload())
+}
+
+// DecodeChaincodeEvents returns the events carried by a list of chaincode events, such as
+// the EmittedEvents of a mock stub, in order.
+func DecodeChaincodeEvents(chaincodeEvents []*pb.ChaincodeEvent) ([]EmittedEvent, error) {
+	emitted := []EmittedEvent{}
+	for _, event := range chaincodeEvents {
+		decoded, err := DecodeChaincodeEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		emitted = append(emitted, decoded...)
+	}
+
+	return emitted, nil
+}
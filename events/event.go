@@ -4,12 +4,31 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/errors"
 	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
 )
 
 type EventType float64
 
+// Arg describes a property of the event payload, in the same format as transaction arguments.
+type Arg struct {
+	// Tag is the key of the value in the payload
+	Tag string `json:"tag"`
+
+	// Label is the name used in frontend
+	Label string `json:"label"`
+
+	// Description is a simple explanation of the property
+	Description string `json:"description"`
+
+	// DataType accepts the same values as the DataType of transaction arguments
+	DataType string `json:"dataType"`
+
+	// Tells if the property is required
+	Required bool `json:"required"`
+}
+
 const (
 	EventLog EventType = iota
 	EventTransaction
@@ -39,13 +58,24 @@ type Event struct {
 	// or regular expressions
 	// eg. []string{`$org\dMSP`} and cc-tools will
 	// check for a match with regular expression `org\dMSP`
+	// Events with receivers are emitted with the hash of the payload only. The payload is
+	// written to the implicit private collection of each receiver, so regular expressions
+	// must match a single organization. Callers must set the EventSaltTransientKey transient
+	// field to raise them, and they cannot trigger transactions on other channels.
 	Receivers []string `json:"receivers,omitempty"`
 
-	// Transaction is the transaction that the event triggers (if of type EventTransaction)
+	// Args is the schema of the payload, which must be a JSON object with these properties.
+	// Payloads are validated as transaction args before the CustomFunction runs.
+	Args []Arg `json:"args,omitempty"`
+
+	// Transaction is the transaction that the event triggers (if of type EventTransaction).
+	// The event payload must be a JSON object with the args of the transaction.
 	Transaction string `json:"transaction"`
 
 	// Channel is the channel of the transaction that the event triggers (if of type EventTransaction)
-	// If empty, the event will trigger on the same channel as the transaction that calls the event
+	// If empty, the event will trigger on the same channel as the transaction that calls the event.
+	// Transactions on other channels are not run, but relayed by off-chain listeners of the RelayEventTag event,
+	// whose payload is public. For this reason, events relayed to other channels cannot have receivers.
 	Channel string `json:"channel"`
 
 	// Chaincode is the chaincode of the transaction that the event triggers (if of type EventTransaction)
@@ -58,14 +88,51 @@ type Event struct {
 
 	// ReadOnly indicates if the CustomFunction has the ability to alter the world state (if of type EventCustom).
 	ReadOnly bool `json:"readOnly"`
+
+	// Policy is the name of a policy registered with accesscontrol.RegisterPolicy
+	// which callers must satisfy to call the event.
+	Policy string `json:"policy,omitempty"`
+}
+
+// CheckPolicy returns a 403 error if tx creator does not satisfy the event policy.
+func (event Event) CheckPolicy(stub *sw.StubWrapper) errors.ICCError {
+	allowed, err := accesscontrol.CheckPolicy(stub.Stub, event.Policy)
+	if err != nil {
+		return errors.WrapError(err, "failed to check event policy")
+	}
+	if !allowed {
+		return errors.NewCCError(fmt.Sprintf("current caller not allowed by policy %s", event.Policy), http.StatusForbidden)
+	}
+
+	return nil
 }
 
 func (event Event) CallEvent(stub *sw.StubWrapper, payload []byte) errors.ICCError {
-	err := stub.SetEvent(event.Tag, payload)
+	err := event.CheckPolicy(stub)
+	if err != nil {
+		return err
+	}
+
+	publicPayload := payload
+	if len(event.Receivers) > 0 {
+		publicPayload, err = event.hidePayload(stub, payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = stub.SetEvent(event.Tag, publicPayload)
 	if err != nil {
 		return errors.WrapError(err, "stub.SetEvent call error")
 	}
 
+	if event.Type == EventTransaction {
+		err = event.dispatch(stub, payload)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
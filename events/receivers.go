@@ -0,0 +1,141 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// PrivateEventPayload is the public payload of events with receivers. The cleartext payload is
+// written to the implicit private collection of each receiver, with its salted hash as key.
+type PrivateEventPayload struct {
+	Hash      string   `json:"hash"`
+	Receivers []string `json:"receivers"`
+}
+
+// PrivateEventRecord is the record written to the implicit private collections of the receivers.
+// The salt is only stored there and mixes the secret EventSaltTransientKey transient field, so
+// the hash does not reveal payloads which can be guessed.
+type PrivateEventRecord struct {
+	Salt    string `json:"salt"`
+	Payload []byte `json:"payload"`
+}
+
+// EventSaltTransientKey is the transient field clients must set with secret random bytes to raise
+// events with receivers, which is mixed into the salts of the event payloads. Since the transient
+// data is the same on every endorsing peer, the salts match while not being derivable from the
+// public transaction.
+const EventSaltTransientKey = "@eventSalt"
+
+// ReceiverCollection returns the name of the implicit private collection of an organization.
+func ReceiverCollection(msp string) string {
+	return "_implicit_org_" + msp
+}
+
+// ReceiverMSP returns the MSP ID of a receiver. Regular expressions are only accepted
+// if they match a single MSP ID, since the receivers must be known to write the payload.
+func ReceiverMSP(receiver string) (string, bool) {
+	if len(receiver) <= 1 || receiver[0] != '$' {
+		return receiver, receiver != ""
+	}
+
+	expr := receiver[1:]
+	if regexp.QuoteMeta(expr) != expr {
+		return "", false
+	}
+	return expr, true
+}
+
+// eventSalt returns the salt of an event payload, derived from the tx ID, a nonce and the
+// EventSaltTransientKey transient field. It returns a 400 error if the transient field is not
+// set, since the salt could otherwise be derived from the public transaction.
+func (event Event) eventSalt(stub *sw.StubWrapper, nonce int) ([]byte, errors.ICCError) {
+	transient, err := stub.Stub.GetTransient()
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "failed to get transient data", http.StatusInternalServerError)
+	}
+	if len(transient[EventSaltTransientKey]) == 0 {
+		return nil, errors.NewCCError(fmt.Sprintf("transient field %s is required to raise event %s with receivers", EventSaltTransientKey, event.Tag), http.StatusBadRequest)
+	}
+
+	salt := sha256.New()
+	salt.Write([]byte(fmt.Sprintf("%s:%d:", stub.Stub.GetTxID(), nonce)))
+	salt.Write(transient[EventSaltTransientKey])
+
+	return salt.Sum(nil), nil
+}
+
+// hidePayload writes the payload to the implicit collections of the event receivers and
+// returns the public payload, which only has its salted hash.
+func (event Event) hidePayload(stub *sw.StubWrapper, payload []byte) ([]byte, errors.ICCError) {
+	salt, err := event.eventSalt(stub, len(stub.Events))
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(append(append([]byte{}, salt...), payload...))
+	key := hex.EncodeToString(hash[:])
+
+	recordJSON, nerr := json.Marshal(PrivateEventRecord{
+		Salt:    hex.EncodeToString(salt),
+		Payload: payload,
+	})
+	if nerr != nil {
+		return nil, errors.WrapErrorWithStatus(nerr, "failed to marshal event payload", http.StatusInternalServerError)
+	}
+
+	receivers := []string{}
+	for _, receiver := range event.Receivers {
+		msp, isSingleMSP := ReceiverMSP(receiver)
+		if !isSingleMSP {
+			return nil, errors.NewCCError(fmt.Sprintf("receiver %s of event %s must match a single msp", receiver, event.Tag), http.StatusInternalServerError)
+		}
+
+		err = stub.PutPrivateData(ReceiverCollection(msp), key, recordJSON)
+		if err != nil {
+			return nil, errors.WrapError(err, fmt.Sprintf("failed to write payload of event %s for %s", event.Tag, msp))
+		}
+		receivers = append(receivers, msp)
+	}
+
+	publicJSON, nerr := json.Marshal(PrivateEventPayload{
+		Hash:      key,
+		Receivers: receivers,
+	})
+	if nerr != nil {
+		return nil, errors.WrapErrorWithStatus(nerr, "failed to marshal event payload", http.StatusInternalServerError)
+	}
+
+	return publicJSON, nil
+}
+
+// GetPrivatePayload reads the cleartext payload of an event with receivers from the
+// implicit collection of the receiver.
+func GetPrivatePayload(stub *sw.StubWrapper, msp string, public []byte) ([]byte, errors.ICCError) {
+	var publicPayload PrivateEventPayload
+	err := json.Unmarshal(public, &publicPayload)
+	if err != nil || publicPayload.Hash == "" {
+		return nil, errors.NewCCError("invalid private event payload", http.StatusBadRequest)
+	}
+
+	recordJSON, iccErr := stub.GetPrivateData(ReceiverCollection(msp), publicPayload.Hash)
+	if iccErr != nil {
+		return nil, errors.WrapError(iccErr, "failed to read event payload")
+	}
+	if recordJSON == nil {
+		return nil, errors.NewCCError("event payload not found", http.StatusNotFound)
+	}
+
+	var record PrivateEventRecord
+	err = json.Unmarshal(recordJSON, &record)
+	if err != nil {
+		return nil, errors.WrapErrorWithStatus(err, "invalid private event record", http.StatusInternalServerError)
+	}
+
+	return record.Payload, nil
+}
@@ -0,0 +1,662 @@
+// Package mango evaluates CouchDB Mango queries against JSON documents.
+package mango
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// Query is a parsed CouchDB Mango query which can be evaluated against JSON documents.
+// It supports the subset of the CouchDB find API that is meaningful for chaincode:
+// selector, sort, fields, limit and skip.
+type Query struct {
+	Selector map[string]interface{}
+	Sort     []SortField
+	Fields   []string
+	Limit    int
+	Skip     int
+}
+
+// SortField defines a field and the direction the query results are sorted by.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// ParseQuery parses a CouchDB Mango query string.
+func ParseQuery(query string) (*Query, error) {
+	var raw map[string]interface{}
+	err := json.Unmarshal([]byte(query), &raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %s", err)
+	}
+
+	q := &Query{}
+
+	selector, ok := raw["selector"]
+	if !ok {
+		return nil, errors.New("invalid query: selector is required")
+	}
+	q.Selector, ok = selector.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid query: selector must be an object")
+	}
+	err = validateSelector(q.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if sortInterface, ok := raw["sort"]; ok {
+		sortList, ok := sortInterface.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid query: sort must be an array")
+		}
+		for _, s := range sortList {
+			switch sortField := s.(type) {
+			case string:
+				q.Sort = append(q.Sort, SortField{Field: sortField})
+			case map[string]interface{}:
+				if len(sortField) != 1 {
+					return nil, errors.New("invalid query: each sort object must have exactly one field")
+				}
+				for field, dir := range sortField {
+					switch dir {
+					case "asc":
+						q.Sort = append(q.Sort, SortField{Field: field})
+					case "desc":
+						q.Sort = append(q.Sort, SortField{Field: field, Descending: true})
+					default:
+						return nil, fmt.Errorf("invalid query: invalid sort direction for field %s", field)
+					}
+				}
+			default:
+				return nil, errors.New("invalid query: sort must be a list of strings or objects")
+			}
+		}
+	}
+
+	if fieldsInterface, ok := raw["fields"]; ok {
+		fieldList, ok := fieldsInterface.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid query: fields must be an array")
+		}
+		for _, f := range fieldList {
+			field, ok := f.(string)
+			if !ok {
+				return nil, errors.New("invalid query: fields must be a list of strings")
+			}
+			q.Fields = append(q.Fields, field)
+		}
+	}
+
+	q.Limit, err = parseQueryInt(raw, "limit")
+	if err != nil {
+		return nil, err
+	}
+	q.Skip, err = parseQueryInt(raw, "skip")
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func parseQueryInt(raw map[string]interface{}, name string) (int, error) {
+	valueInterface, ok := raw[name]
+	if !ok {
+		return 0, nil
+	}
+	value, ok := valueInterface.(float64)
+	if !ok || value < 0 || value != math.Trunc(value) {
+		return 0, fmt.Errorf("invalid query: %s must be a non-negative integer", name)
+	}
+	return int(value), nil
+}
+
+// validateSelector checks the operators used in a selector so malformed
+// queries fail when issued instead of silently matching nothing.
+func validateSelector(selector map[string]interface{}) error {
+	for k, v := range selector {
+		if !strings.HasPrefix(k, "$") {
+			if sub, ok := v.(map[string]interface{}); ok {
+				if err := validateSelector(sub); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		switch k {
+		case "$and", "$or", "$nor":
+			list, ok := v.([]interface{})
+			if !ok {
+				return fmt.Errorf("invalid query: %s requires an array", k)
+			}
+			for _, item := range list {
+				sub, ok := item.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("invalid query: %s requires an array of selectors", k)
+				}
+				if err := validateSelector(sub); err != nil {
+					return err
+				}
+			}
+		case "$not", "$elemMatch", "$allMatch":
+			sub, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid query: %s requires a selector", k)
+			}
+			if err := validateSelector(sub); err != nil {
+				return err
+			}
+		case "$in", "$nin", "$all":
+			if _, ok := v.([]interface{}); !ok {
+				return fmt.Errorf("invalid query: %s requires an array", k)
+			}
+		case "$mod":
+			args, ok := v.([]interface{})
+			if !ok || len(args) != 2 {
+				return errors.New("invalid query: $mod requires an array of [divisor, remainder]")
+			}
+			divisor, ok := args[0].(float64)
+			if !ok || divisor == 0 {
+				return errors.New("invalid query: $mod divisor must be a non-zero number")
+			}
+		case "$regex":
+			pattern, ok := v.(string)
+			if !ok {
+				return errors.New("invalid query: $regex requires a string")
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid query: %s", err)
+			}
+		case "$exists":
+			if _, ok := v.(bool); !ok {
+				return errors.New("invalid query: $exists requires a boolean")
+			}
+		case "$size":
+			if _, ok := v.(float64); !ok {
+				return errors.New("invalid query: $size requires a number")
+			}
+		case "$type":
+			if _, ok := v.(string); !ok {
+				return errors.New("invalid query: $type requires a string")
+			}
+		case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		default:
+			return fmt.Errorf("invalid query: unknown operator %s", k)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the given document satisfies the query selector.
+func (q *Query) Match(doc map[string]interface{}) bool {
+	return matchSelector(doc, q.Selector)
+}
+
+// Less reports whether the result a is sorted before the result b by the query.
+// Results which are equal on every sort field are ordered by key.
+func (q *Query) Less(a, b *queryresult.KV) bool {
+	var docA, docB map[string]interface{}
+	if len(q.Sort) > 0 {
+		json.Unmarshal(a.Value, &docA)
+		json.Unmarshal(b.Value, &docB)
+	}
+	for _, s := range q.Sort {
+		valueA, _ := getField(docA, s.Field)
+		valueB, _ := getField(docB, s.Field)
+		c := compareValues(valueA, valueB)
+		if c == 0 {
+			continue
+		}
+		if s.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+	return a.Key < b.Key
+}
+
+// Execute filters, sorts and projects the given key-value pairs according to the query.
+// Values which are not JSON objects are ignored, as they would be stored as
+// attachments in CouchDB and therefore not be queryable. Limit and skip are not
+// applied here so callers may paginate over the result.
+func (q *Query) Execute(kvs []*queryresult.KV) []*queryresult.KV {
+	type match struct {
+		kv  *queryresult.KV
+		doc map[string]interface{}
+	}
+
+	matches := make([]match, 0)
+	for _, kv := range kvs {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(kv.Value, &doc); err != nil {
+			continue
+		}
+		if q.Match(doc) {
+			matches = append(matches, match{kv, doc})
+		}
+	}
+
+	if len(q.Sort) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return q.Less(matches[i].kv, matches[j].kv)
+		})
+	}
+
+	result := make([]*queryresult.KV, 0, len(matches))
+	for _, m := range matches {
+		value := m.kv.Value
+		if len(q.Fields) > 0 {
+			value, _ = json.Marshal(projectFields(m.doc, q.Fields))
+		}
+		result = append(result, &queryresult.KV{
+			Namespace: m.kv.Namespace,
+			Key:       m.kv.Key,
+			Value:     value,
+		})
+	}
+
+	return result
+}
+
+// Page returns a page of the results of Execute starting after the given bookmark
+// and containing at most pageSize records, along with the bookmark for the next page.
+// If pageSize is not positive the remainder of the results is returned.
+func (q *Query) Page(results []*queryresult.KV, pageSize int32, bookmark string) ([]*queryresult.KV, string, error) {
+	start := 0
+	if bookmark != "" {
+		lastKey, err := decodeBookmark(bookmark)
+		if err != nil {
+			return nil, "", err
+		}
+		start = len(results)
+		for i, kv := range results {
+			if kv.Key == lastKey {
+				start = i + 1
+				break
+			}
+			if len(q.Sort) == 0 && kv.Key > lastKey {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := len(results)
+	if pageSize > 0 && start+int(pageSize) < end {
+		end = start + int(pageSize)
+	}
+	page := results[start:end]
+
+	nextBookmark := bookmark
+	if len(page) > 0 {
+		nextBookmark = base64.RawURLEncoding.EncodeToString([]byte(page[len(page)-1].Key))
+	}
+
+	return page, nextBookmark, nil
+}
+
+func decodeBookmark(bookmark string) (string, error) {
+	lastKey, err := base64.RawURLEncoding.DecodeString(bookmark)
+	if err != nil {
+		return "", fmt.Errorf("invalid bookmark: %s", err)
+	}
+	return string(lastKey), nil
+}
+
+// matchSelector evaluates a selector against a value. Operators at the top level
+// of the selector are applied to the value itself, which is required to evaluate
+// $elemMatch and $allMatch over arrays of scalars.
+func matchSelector(value interface{}, selector map[string]interface{}) bool {
+	for k, cond := range selector {
+		var ok bool
+		switch k {
+		case "$and":
+			ok = true
+			for _, sub := range cond.([]interface{}) {
+				if !matchSelector(value, sub.(map[string]interface{})) {
+					ok = false
+					break
+				}
+			}
+		case "$or":
+			for _, sub := range cond.([]interface{}) {
+				if matchSelector(value, sub.(map[string]interface{})) {
+					ok = true
+					break
+				}
+			}
+		case "$nor":
+			ok = true
+			for _, sub := range cond.([]interface{}) {
+				if matchSelector(value, sub.(map[string]interface{})) {
+					ok = false
+					break
+				}
+			}
+		case "$not":
+			ok = !matchSelector(value, cond.(map[string]interface{}))
+		default:
+			if strings.HasPrefix(k, "$") {
+				ok = matchOperator(k, cond, value)
+			} else {
+				doc, isObject := value.(map[string]interface{})
+				if !isObject {
+					return false
+				}
+				ok = matchField(doc, k, cond)
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchField evaluates the condition for the field at the given path of the document.
+func matchField(doc map[string]interface{}, path string, cond interface{}) bool {
+	condMap, isMap := cond.(map[string]interface{})
+	if !isMap || len(condMap) == 0 {
+		// Implicit equality
+		fieldValue, exists := getField(doc, path)
+		return exists && compareValues(fieldValue, cond) == 0
+	}
+
+	for op, arg := range condMap {
+		if !strings.HasPrefix(op, "$") {
+			// Nested field
+			if !matchField(doc, path+"."+escapeFieldName(op), arg) {
+				return false
+			}
+			continue
+		}
+
+		fieldValue, exists := getField(doc, path)
+		if !exists {
+			if op == "$exists" && arg == false {
+				continue
+			}
+			return false
+		}
+		if !matchOperator(op, arg, fieldValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchOperator(op string, arg, value interface{}) bool {
+	switch op {
+	case "$eq":
+		return compareValues(value, arg) == 0
+	case "$ne":
+		return compareValues(value, arg) != 0
+	case "$gt":
+		return compareValues(value, arg) > 0
+	case "$gte":
+		return compareValues(value, arg) >= 0
+	case "$lt":
+		return compareValues(value, arg) < 0
+	case "$lte":
+		return compareValues(value, arg) <= 0
+	case "$exists":
+		return arg == true
+	case "$type":
+		return typeName(value) == arg
+	case "$in":
+		return matchIn(value, arg.([]interface{}))
+	case "$nin":
+		return !matchIn(value, arg.([]interface{}))
+	case "$size":
+		array, ok := value.([]interface{})
+		return ok && float64(len(array)) == arg
+	case "$all":
+		array, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, a := range arg.([]interface{}) {
+			if !matchIn(a, array) {
+				return false
+			}
+		}
+		return true
+	case "$mod":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return false
+		}
+		args := arg.([]interface{})
+		divisor, _ := args[0].(float64)
+		remainder, _ := args[1].(float64)
+		return math.Mod(number, divisor) == remainder
+	case "$regex":
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		matched, _ := regexp.MatchString(arg.(string), str)
+		return matched
+	case "$elemMatch":
+		array, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, elem := range array {
+			if matchSelector(elem, arg.(map[string]interface{})) {
+				return true
+			}
+		}
+		return false
+	case "$allMatch":
+		array, ok := value.([]interface{})
+		if !ok || len(array) == 0 {
+			return false
+		}
+		for _, elem := range array {
+			if !matchSelector(elem, arg.(map[string]interface{})) {
+				return false
+			}
+		}
+		return true
+	case "$and", "$or", "$nor", "$not":
+		return matchSelector(value, map[string]interface{}{op: arg})
+	}
+	return false
+}
+
+// matchIn reports whether value equals any of the arguments. As in CouchDB,
+// array values match if any of their elements equals any of the arguments.
+func matchIn(value interface{}, args []interface{}) bool {
+	values, isArray := value.([]interface{})
+	if !isArray {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		for _, a := range args {
+			if compareValues(v, a) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getField returns the value at the dot-separated path of the document.
+// Dots which are part of a field name may be escaped with a backslash.
+func getField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, name := range splitFieldPath(path) {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = obj[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func splitFieldPath(path string) []string {
+	names := make([]string, 0)
+	var name strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			name.WriteByte('.')
+			i++
+		case path[i] == '.':
+			names = append(names, name.String())
+			name.Reset()
+		default:
+			name.WriteByte(path[i])
+		}
+	}
+	return append(names, name.String())
+}
+
+func escapeFieldName(name string) string {
+	return strings.ReplaceAll(name, ".", "\\.")
+}
+
+// projectFields returns a copy of the document containing only the given fields.
+func projectFields(doc map[string]interface{}, fields []string) map[string]interface{} {
+	projection := make(map[string]interface{})
+	for _, field := range fields {
+		value, exists := getField(doc, field)
+		if !exists {
+			continue
+		}
+		names := splitFieldPath(field)
+		current := projection
+		for _, name := range names[:len(names)-1] {
+			next, ok := current[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				current[name] = next
+			}
+			current = next
+		}
+		current[names[len(names)-1]] = value
+	}
+	return projection
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// typeRank orders JSON types according to CouchDB collation.
+func typeRank(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if !v {
+			return 1
+		}
+		return 2
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	case map[string]interface{}:
+		return 6
+	}
+	return 7
+}
+
+// compareValues compares two JSON values following CouchDB collation rules:
+// null < false < true < numbers < strings < arrays < objects.
+func compareValues(a, b interface{}) int {
+	rankA, rankB := typeRank(a), typeRank(b)
+	if rankA != rankB {
+		if rankA < rankB {
+			return -1
+		}
+		return 1
+	}
+
+	switch valueA := a.(type) {
+	case float64:
+		valueB := b.(float64)
+		switch {
+		case valueA < valueB:
+			return -1
+		case valueA > valueB:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(valueA, b.(string))
+	case []interface{}:
+		valueB := b.([]interface{})
+		for i := 0; i < len(valueA) && i < len(valueB); i++ {
+			if c := compareValues(valueA[i], valueB[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(valueA), len(valueB))
+	case map[string]interface{}:
+		valueB := b.(map[string]interface{})
+		keysA, keysB := sortedKeys(valueA), sortedKeys(valueB)
+		for i := 0; i < len(keysA) && i < len(keysB); i++ {
+			if c := strings.Compare(keysA[i], keysB[i]); c != 0 {
+				return c
+			}
+			if c := compareValues(valueA[keysA[i]], valueB[keysB[i]]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(keysA), len(keysB))
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
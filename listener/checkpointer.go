@@ -0,0 +1,86 @@
+package listener
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Checkpointer records the last block processed by a listener.
+type Checkpointer interface {
+	// LastBlock returns the last processed block, or false if no block was processed.
+	LastBlock() (uint64, bool, error)
+
+	// Checkpoint records the block as processed.
+	Checkpoint(blockNumber uint64) error
+}
+
+// MemoryCheckpointer keeps the last processed block in memory.
+type MemoryCheckpointer struct {
+	mu        sync.Mutex
+	lastBlock *uint64
+}
+
+// LastBlock returns the last processed block, or false if no block was processed.
+func (c *MemoryCheckpointer) LastBlock() (uint64, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastBlock == nil {
+		return 0, false, nil
+	}
+	return *c.lastBlock, true, nil
+}
+
+// Checkpoint records the block as processed.
+func (c *MemoryCheckpointer) Checkpoint(blockNumber uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastBlock = &blockNumber
+	return nil
+}
+
+// FileCheckpointer keeps the last processed block in a file, so listeners resume after restarts.
+type FileCheckpointer struct {
+	Path string
+}
+
+// LastBlock returns the last processed block, or false if the file does not exist.
+func (c FileCheckpointer) LastBlock() (uint64, bool, error) {
+	content, err := os.ReadFile(c.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	blockNumber, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return blockNumber, true, nil
+}
+
+// Checkpoint records the block as processed. The file is replaced atomically.
+func (c FileCheckpointer) Checkpoint(blockNumber uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(strconv.FormatUint(blockNumber, 10))
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.Path)
+}
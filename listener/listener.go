@@ -0,0 +1,206 @@
+// Package listener consumes the events raised by cc-tools chaincodes off-chain,
+// dispatching them to handlers registered by event tag.
+package listener
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger-labs/cc-tools/events"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// BlockEvent is a chaincode event read from a block.
+type BlockEvent struct {
+	BlockNumber uint64
+	Event       *pb.ChaincodeEvent
+}
+
+// Source provides the chaincode events of a channel, such as the block stream of a peer.
+type Source interface {
+	// Events returns the chaincode events committed from the given block on, in order.
+	// The channel is closed when the source ends or the context is done.
+	Events(ctx context.Context, fromBlock uint64) (<-chan BlockEvent, error)
+}
+
+// Delivery is an event dispatched to a handler.
+type Delivery struct {
+	// Tag is the tag of the event
+	Tag string
+
+	// Payload is the payload of the event. For events with receivers,
+	// it is the events.PrivateEventPayload with the hash of the payload.
+	Payload []byte
+
+	// Event is the definition of the event in the event list, or nil if the
+	// event is not defined, as for lifecycle and relay events.
+	Event *events.Event
+
+	TxID        string
+	ChaincodeID string
+	BlockNumber uint64
+}
+
+// Handler processes an event. Returning an error stops the listener, so the
+// block of the event is delivered again when the listener is restarted.
+type Handler func(ctx context.Context, delivery Delivery) error
+
+// Listener dispatches the events of a source to the handlers registered by tag.
+type Listener struct {
+	source       Source
+	checkpointer Checkpointer
+
+	mu             sync.RWMutex
+	handlers       map[string][]Handler
+	defaultHandler Handler
+}
+
+// New returns a listener of the source. The checkpointer records the last processed
+// block, so the listener resumes from the next one. It may be nil.
+func New(source Source, checkpointer Checkpointer) *Listener {
+	return &Listener{
+		source:       source,
+		checkpointer: checkpointer,
+		handlers:     make(map[string][]Handler),
+	}
+}
+
+// Handle registers a handler for the events with the tag. Handlers of the same tag run in
+// the order they were registered.
+func (l *Listener) Handle(tag string, handler Handler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers[tag] = append(l.handlers[tag], handler)
+}
+
+// HandleDefault registers a handler for the events with no handlers of their tag.
+func (l *Listener) HandleDefault(handler Handler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultHandler = handler
+}
+
+// HandleCustom registers a handler for an event of type EventCustom, which runs off-chain
+// the logic that CustomFunction runs on-chain.
+func (l *Listener) HandleCustom(tag string, handler func(ctx context.Context, payload []byte) error) error {
+	event := events.FetchEvent(tag)
+	if event == nil {
+		return fmt.Errorf("event named %s does not exist", tag)
+	}
+	if event.Type != events.EventCustom {
+		return fmt.Errorf("event %s is not of type 'EventCustom'", tag)
+	}
+
+	l.Handle(tag, func(ctx context.Context, delivery Delivery) error {
+		return handler(ctx, delivery.Payload)
+	})
+	return nil
+}
+
+// Run dispatches the events from the block after the last checkpoint on, until the source
+// ends or the context is done.
+func (l *Listener) Run(ctx context.Context) error {
+	var fromBlock uint64
+	if l.checkpointer != nil {
+		lastBlock, ok, err := l.checkpointer.LastBlock()
+		if err != nil {
+			return fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		if ok {
+			fromBlock = lastBlock + 1
+		}
+	}
+
+	return l.Replay(ctx, fromBlock)
+}
+
+// Replay dispatches the events from the given block on, regardless of the checkpoint,
+// until the source ends or the context is done.
+func (l *Listener) Replay(ctx context.Context, fromBlock uint64) error {
+	blockEvents, err := l.source.Events(ctx, fromBlock)
+	if err != nil {
+		return fmt.Errorf("failed to read events from block %d: %w", fromBlock, err)
+	}
+
+	// Blocks are checkpointed once all of their events are processed
+	var pending *uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case blockEvent, open := <-blockEvents:
+			if !open {
+				if pending != nil {
+					return l.checkpoint(*pending)
+				}
+				return nil
+			}
+
+			if pending != nil && blockEvent.BlockNumber > *pending {
+				err := l.checkpoint(*pending)
+				if err != nil {
+					return err
+				}
+			}
+			blockNumber := blockEvent.BlockNumber
+			pending = &blockNumber
+
+			err := l.dispatch(ctx, blockEvent)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// dispatch runs the handlers of each event carried by a chaincode event.
+func (l *Listener) dispatch(ctx context.Context, blockEvent BlockEvent) error {
+	emitted, err := events.DecodeChaincodeEvent(blockEvent.Event)
+	if err != nil {
+		return fmt.Errorf("failed to decode events of tx %s: %w", blockEvent.Event.GetTxId(), err)
+	}
+
+	for _, event := range emitted {
+		delivery := Delivery{
+			Tag:         event.Tag,
+			Payload:     event.Payload,
+			Event:       events.FetchEvent(event.Tag),
+			TxID:        blockEvent.Event.GetTxId(),
+			ChaincodeID: blockEvent.Event.GetChaincodeId(),
+			BlockNumber: blockEvent.BlockNumber,
+		}
+
+		for _, handler := range l.handlersOf(event.Tag) {
+			err := handler(ctx, delivery)
+			if err != nil {
+				return fmt.Errorf("handler of event %s failed at block %d: %w", event.Tag, blockEvent.BlockNumber, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *Listener) handlersOf(tag string) []Handler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	handlers := l.handlers[tag]
+	if len(handlers) == 0 && l.defaultHandler != nil {
+		return []Handler{l.defaultHandler}
+	}
+	return handlers
+}
+
+func (l *Listener) checkpoint(blockNumber uint64) error {
+	if l.checkpointer == nil {
+		return nil
+	}
+
+	err := l.checkpointer.Checkpoint(blockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint block %d: %w", blockNumber, err)
+	}
+	return nil
+}
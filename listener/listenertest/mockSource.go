@@ -0,0 +1,63 @@
+// Package listenertest provides a source of chaincode events for testing listeners without a peer.
+package listenertest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger-labs/cc-tools/listener"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// MockSource is a source fed from the ChaincodeEventsChannel of a mock stub, so listeners
+// can be tested without a peer. Each committed transaction is treated as a block, numbered
+// from 0. Events are kept by the source, so they can be replayed.
+type MockSource struct {
+	Stub *mock.MockStub
+
+	mu     sync.Mutex
+	blocks []*pb.ChaincodeEvent
+}
+
+// NewMockSource returns a source of the events of the mock stub.
+func NewMockSource(stub *mock.MockStub) *MockSource {
+	return &MockSource{Stub: stub}
+}
+
+// Events returns the events committed so far from the given block on, then closes the channel.
+func (s *MockSource) Events(ctx context.Context, fromBlock uint64) (<-chan listener.BlockEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for drained := false; !drained; {
+		select {
+		case event := <-s.Stub.ChaincodeEventsChannel:
+			s.blocks = append(s.blocks, event)
+		default:
+			drained = true
+		}
+	}
+
+	var pending []listener.BlockEvent
+	for blockNumber := fromBlock; blockNumber < uint64(len(s.blocks)); blockNumber++ {
+		pending = append(pending, listener.BlockEvent{
+			BlockNumber: blockNumber,
+			Event:       s.blocks[blockNumber],
+		})
+	}
+
+	blockEvents := make(chan listener.BlockEvent)
+	go func() {
+		defer close(blockEvents)
+		for _, blockEvent := range pending {
+			select {
+			case blockEvents <- blockEvent:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return blockEvents, nil
+}
@@ -0,0 +1,50 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+/*****************************
+ History Query Iterator
+*****************************/
+
+// MockHistoryQueryIterator iterates over the modifications of a key.
+type MockHistoryQueryIterator struct {
+	Closed        bool
+	Modifications []*queryresult.KeyModification
+	Current       int
+}
+
+// HasNext returns true if the history iterator contains additional modifications.
+func (iter *MockHistoryQueryIterator) HasNext() bool {
+	return !iter.Closed && iter.Current < len(iter.Modifications)
+}
+
+// Next returns the next modification in the history iterator.
+func (iter *MockHistoryQueryIterator) Next() (*queryresult.KeyModification, error) {
+	if iter.Closed {
+		return nil, errors.New("MockHistoryQueryIterator.Next() called after Close()")
+	}
+	if !iter.HasNext() {
+		return nil, errors.New("MockHistoryQueryIterator.Next() called when it does not HaveNext()")
+	}
+	modification := iter.Modifications[iter.Current]
+	iter.Current++
+	return modification, nil
+}
+
+// Close closes the history iterator.
+func (iter *MockHistoryQueryIterator) Close() error {
+	if iter.Closed {
+		return errors.New("MockHistoryQueryIterator.Close() called after Close()")
+	}
+	iter.Closed = true
+	return nil
+}
+
+// NewMockHistoryQueryIterator ...
+func NewMockHistoryQueryIterator(modifications []*queryresult.KeyModification) *MockHistoryQueryIterator {
+	return &MockHistoryQueryIterator{Modifications: modifications}
+}
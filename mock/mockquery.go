@@ -0,0 +1,94 @@
+package mock
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/hyperledger-labs/cc-tools/internal/mango"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// Query is a parsed CouchDB Mango query which can be evaluated against JSON documents.
+type Query = mango.Query
+
+// SortField defines a field and the direction the query results are sorted by.
+type SortField = mango.SortField
+
+// ParseQuery parses a CouchDB Mango query string.
+func ParseQuery(query string) (*Query, error) {
+	return mango.ParseQuery(query)
+}
+
+/*****************************
+ Query Result Iterator
+*****************************/
+
+// MockQueryResultIterator iterates over a precomputed list of query results.
+type MockQueryResultIterator struct {
+	Closed  bool
+	Results []*queryresult.KV
+	Current int
+}
+
+// HasNext returns true if the query iterator contains additional results.
+func (iter *MockQueryResultIterator) HasNext() bool {
+	return !iter.Closed && iter.Current < len(iter.Results)
+}
+
+// Next returns the next key and value in the query iterator.
+func (iter *MockQueryResultIterator) Next() (*queryresult.KV, error) {
+	if iter.Closed {
+		return nil, errors.New("MockQueryResultIterator.Next() called after Close()")
+	}
+	if !iter.HasNext() {
+		return nil, errors.New("MockQueryResultIterator.Next() called when it does not HaveNext()")
+	}
+	kv := iter.Results[iter.Current]
+	iter.Current++
+	return kv, nil
+}
+
+// Close closes the query iterator.
+func (iter *MockQueryResultIterator) Close() error {
+	if iter.Closed {
+		return errors.New("MockQueryResultIterator.Close() called after Close()")
+	}
+	iter.Closed = true
+	return nil
+}
+
+// NewMockQueryResultIterator ...
+func NewMockQueryResultIterator(results []*queryresult.KV) *MockQueryResultIterator {
+	return &MockQueryResultIterator{Results: results}
+}
+
+// stateKVs returns the key-value pairs of the given state in lexical key order.
+func stateKVs(state map[string][]byte) []*queryresult.KV {
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]*queryresult.KV, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, &queryresult.KV{Key: k, Value: state[k]})
+	}
+	return kvs
+}
+
+// executeQuery runs the query over the state, applying the query's own skip and,
+// unless the results are paginated, its limit. Paginated results are limited by the page size.
+func executeQuery(state map[string][]byte, q *Query, paginated bool) []*queryresult.KV {
+	results := q.Execute(stateKVs(state))
+	if q.Skip < len(results) {
+		results = results[q.Skip:]
+	} else {
+		results = results[:0]
+	}
+	if !paginated && q.Limit > 0 && q.Limit < len(results) {
+		results = results[:q.Limit]
+	}
+
+	return results
+}
@@ -7,6 +7,7 @@ package mock
 
 import (
 	"container/list"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"strings"
@@ -24,6 +25,7 @@ import (
 const (
 	minUnicodeRuneValue   = 0 //U+0000
 	compositeKeyNamespace = "\x00"
+	emptyKeySubstitute    = "\x01"
 )
 
 // MockStub is an implementation of ChaincodeStubInterface for unit testing chaincode.
@@ -64,15 +66,39 @@ type MockStub struct {
 
 	PvtState map[string]map[string][]byte
 
+	// CollectionMembers lists the MSPs which are members of each private collection.
+	// Collections which are not listed are readable by every organization.
+	CollectionMembers map[string][]string
+
 	// stores per-key endorsement policy, first map index is the collection, second map index is the key
 	EndorsementPolicies map[string]map[string][]byte
 
 	// channel to store ChaincodeEvents
 	ChaincodeEventsChannel chan *pb.ChaincodeEvent
 
+	// EmittedEvents keeps the events of the committed transactions, oldest first
+	EmittedEvents []*pb.ChaincodeEvent
+
 	Creator []byte
 
 	Decorations map[string][]byte
+
+	// History keeps the committed modifications of each key, oldest first
+	History map[string][]*queryresult.KeyModification
+
+	// stores the last write of each key made by the current transaction,
+	// which is only applied to the State when the transaction is committed
+	txWrites map[string]*queryresult.KeyModification
+
+	// stores the private data written by the current transaction, nil values are deletions
+	txPvtWrites map[string]map[string][]byte
+
+	// stores the event set by the current transaction
+	txEvent *pb.ChaincodeEvent
+
+	// stores the stubs of the chaincodes invoked by the current transaction,
+	// whose writes are committed or rolled back along with it
+	txInvoked []*MockStub
 }
 
 // GetTxID ...
@@ -117,16 +143,84 @@ func (stub *MockStub) GetFunctionAndParameters() (function string, params []stri
 // MockStub doesn't support concurrent transactions at present.
 func (stub *MockStub) MockTransactionStart(txid string) {
 	stub.TxID = txid
+	stub.txWrites = make(map[string]*queryresult.KeyModification)
+	stub.txPvtWrites = make(map[string]map[string][]byte)
+	stub.txEvent = nil
 	stub.setSignedProposal(&pb.SignedProposal{})
 	stub.setTxTimestamp(ptypes.TimestampNow())
 }
 
 // MockTransactionEnd End a mocked transaction, clearing the UUID.
+// The writes of the transaction are applied to the State, PvtState and History,
+// and the event set by the transaction, if any, is emitted.
 func (stub *MockStub) MockTransactionEnd(uuid string) {
+	for key, modification := range stub.txWrites {
+		if modification.IsDelete {
+			delete(stub.State, key)
+			stub.removeKey(key)
+		} else {
+			stub.State[key] = modification.Value
+			stub.insertKey(key)
+		}
+		stub.History[key] = append(stub.History[key], modification)
+	}
+
+	for collection, writes := range stub.txPvtWrites {
+		for key, value := range writes {
+			if value == nil {
+				delete(stub.PvtState[collection], key)
+				continue
+			}
+			if stub.PvtState[collection] == nil {
+				stub.PvtState[collection] = make(map[string][]byte)
+			}
+			stub.PvtState[collection][key] = value
+		}
+	}
+
+	if stub.txEvent != nil {
+		stub.EmittedEvents = append(stub.EmittedEvents, stub.txEvent)
+		stub.ChaincodeEventsChannel <- stub.txEvent
+	}
+
+	invoked := stub.txInvoked
+	stub.clearTransaction()
+
+	// The writes of the invoked chaincodes are committed with the transaction
+	for _, otherStub := range invoked {
+		otherStub.MockTransactionEnd(uuid)
+	}
+}
+
+// MockTransactionRollback End a mocked transaction discarding all of its writes and events,
+// along with the writes of the chaincodes it invoked.
+func (stub *MockStub) MockTransactionRollback(uuid string) {
+	invoked := stub.txInvoked
+	stub.clearTransaction()
+
+	for _, otherStub := range invoked {
+		otherStub.MockTransactionRollback(uuid)
+	}
+}
+
+func (stub *MockStub) clearTransaction() {
+	stub.txWrites = nil
+	stub.txPvtWrites = nil
+	stub.txEvent = nil
+	stub.txInvoked = nil
 	stub.signedProposal = nil
 	stub.TxID = ""
 }
 
+// mockTransactionFinish commits the transaction if the response is successful, or rolls it back otherwise.
+func (stub *MockStub) mockTransactionFinish(uuid string, res pb.Response) {
+	if res.Status >= shim.ERRORTHRESHOLD {
+		stub.MockTransactionRollback(uuid)
+		return
+	}
+	stub.MockTransactionEnd(uuid)
+}
+
 // MockPeerChaincode Register another MockStub chaincode with this MockStub.
 // invokableChaincodeName is the name of a chaincode.
 // otherStub is a MockStub of the chaincode, already initialized.
@@ -144,16 +238,17 @@ func (stub *MockStub) MockInit(uuid string, args [][]byte) pb.Response {
 	stub.args = args
 	stub.MockTransactionStart(uuid)
 	res := stub.cc.Init(stub)
-	stub.MockTransactionEnd(uuid)
+	stub.mockTransactionFinish(uuid, res)
 	return res
 }
 
 // MockInvoke Invoke this chaincode, also starts and ends a transaction.
+// As on a peer, the writes of the transaction are discarded if it returns an error status.
 func (stub *MockStub) MockInvoke(uuid string, args [][]byte) pb.Response {
 	stub.args = args
 	stub.MockTransactionStart(uuid)
 	res := stub.cc.Invoke(stub)
-	stub.MockTransactionEnd(uuid)
+	stub.mockTransactionFinish(uuid, res)
 	return res
 }
 
@@ -168,12 +263,17 @@ func (stub *MockStub) MockInvokeWithSignedProposal(uuid string, args [][]byte, s
 	stub.MockTransactionStart(uuid)
 	stub.signedProposal = sp
 	res := stub.cc.Invoke(stub)
-	stub.MockTransactionEnd(uuid)
+	stub.mockTransactionFinish(uuid, res)
 	return res
 }
 
-// GetPrivateData ...
+// GetPrivateData returns the committed value of the key in the private collection.
+// Writes of the current transaction are not visible until it is committed.
 func (stub *MockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	if err := stub.checkCollectionMembership(collection); err != nil {
+		return nil, err
+	}
+
 	m, in := stub.PvtState[collection]
 
 	if !in {
@@ -183,59 +283,149 @@ func (stub *MockStub) GetPrivateData(collection string, key string) ([]byte, err
 	return m[key], nil
 }
 
-// GetPrivateDataHash ...
+// GetPrivateDataHash returns the hash of the value of the specified key in the
+// private collection. Unlike GetPrivateData, it is available to non-members.
 func (stub *MockStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
-	return nil, errors.New("Not Implemented")
+	value := stub.PvtState[collection][key]
+	if value == nil {
+		return nil, nil
+	}
+
+	hash := sha256.Sum256(value)
+	return hash[:], nil
 }
 
-// PurgePrivateData ...
+// PurgePrivateData removes the specified key from the private collection.
+// The mock keeps no private data history, so it behaves like DelPrivateData.
 func (stub *MockStub) PurgePrivateData(collection, key string) error {
-	return errors.New("Not Implemented")
+	return stub.DelPrivateData(collection, key)
 }
 
-// PutPrivateData ...
+// PutPrivateData writes the specified `value` and `key` into the private collection.
+// The value is only stored when the transaction is committed.
 func (stub *MockStub) PutPrivateData(collection string, key string, value []byte) error {
-	m, in := stub.PvtState[collection]
-	if !in {
-		stub.PvtState[collection] = make(map[string][]byte)
-		m, in = stub.PvtState[collection]
+	if stub.TxID == "" {
+		return errors.New("cannot PutPrivateData without a transactions - call stub.MockTransactionStart()?")
+	}
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
 	}
 
-	m[key] = value
+	// If the value is nil or empty, delete the key
+	if len(value) == 0 {
+		return stub.DelPrivateData(collection, key)
+	}
+
+	if stub.txPvtWrites[collection] == nil {
+		stub.txPvtWrites[collection] = make(map[string][]byte)
+	}
+	stub.txPvtWrites[collection][key] = value
 
 	return nil
 }
 
-// DelPrivateData ...
+// DelPrivateData removes the specified key from the private collection
+// when the transaction is committed.
 func (stub *MockStub) DelPrivateData(collection string, key string) error {
-	return errors.New("Not Implemented")
+	if stub.TxID == "" {
+		return errors.New("cannot DelPrivateData without a transactions - call stub.MockTransactionStart()?")
+	}
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	if stub.txPvtWrites[collection] == nil {
+		stub.txPvtWrites[collection] = make(map[string][]byte)
+	}
+	stub.txPvtWrites[collection][key] = nil
+
+	return nil
 }
 
-// GetPrivateDataByRange ...
+// GetPrivateDataByRange returns an iterator over the keys of the private
+// collection in the range [startKey, endKey).
 func (stub *MockStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
-	return nil, errors.New("Not Implemented")
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+	return stub.getPrivateDataByRange(collection, startKey, endKey)
 }
 
-// GetPrivateDataByPartialCompositeKey ...
+// GetPrivateDataByPartialCompositeKey returns an iterator over the composite keys
+// of the private collection whose prefix matches the given partial composite key.
 func (stub *MockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
-	return nil, errors.New("Not Implemented")
+	partialCompositeKey, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return stub.getPrivateDataByRange(collection, partialCompositeKey, partialCompositeKey+string(utf8.MaxRune))
+}
+
+func (stub *MockStub) getPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	if err := stub.checkCollectionMembership(collection); err != nil {
+		return nil, err
+	}
+
+	results := make([]*queryresult.KV, 0)
+	for _, kv := range stateKVs(stub.PvtState[collection]) {
+		if kv.Key < startKey {
+			continue
+		}
+		if endKey != "" && kv.Key >= endKey {
+			break
+		}
+		results = append(results, kv)
+	}
+
+	return NewMockQueryResultIterator(results), nil
 }
 
-// GetPrivateDataQueryResult ...
+// GetPrivateDataQueryResult performs a rich query against the given private collection.
+// The query is evaluated by the mock Mango query engine.
 func (stub *MockStub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
-	// Not implemented since the mock engine does not have a query engine.
-	// However, a very simple query engine that supports string matching
-	// could be implemented to test that the framework supports queries
-	return nil, errors.New("Not Implemented")
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	if err := stub.checkCollectionMembership(collection); err != nil {
+		return nil, err
+	}
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return NewMockQueryResultIterator(executeQuery(stub.PvtState[collection], q, false)), nil
+}
+
+// checkCollectionMembership returns an error if the organization of the stub,
+// identified by its Name, is not a member of the private collection.
+func (stub *MockStub) checkCollectionMembership(collection string) error {
+	members, isConfigured := stub.CollectionMembers[collection]
+	if !isConfigured {
+		return nil
+	}
+	for _, member := range members {
+		if member == stub.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("tx creator %s does not have read access permission on private collection %s", stub.Name, collection)
 }
 
-// GetState retrieves the value for a given key from the ledger
+// GetState retrieves the value for a given key from the ledger.
+// As on a peer, writes of the current transaction are not visible until it is committed.
 func (stub *MockStub) GetState(key string) ([]byte, error) {
 	value := stub.State[key]
 	return value, nil
 }
 
-// PutState writes the specified `value` and `key` into the ledger.
+// PutState writes the specified `value` and `key` into the ledger
+// when the transaction is committed.
 func (stub *MockStub) PutState(key string, value []byte) error {
 	if stub.TxID == "" {
 		err := errors.New("cannot PutState without a transactions - call stub.MockTransactionStart()?")
@@ -246,8 +436,38 @@ func (stub *MockStub) PutState(key string, value []byte) error {
 	if len(value) == 0 {
 		return stub.DelState(key)
 	}
-	stub.State[key] = value
+	stub.recordWrite(key, value, false)
+
+	return nil
+}
+
+// DelState removes the specified `key` and its value from the ledger
+// when the transaction is committed.
+func (stub *MockStub) DelState(key string) error {
+	if stub.TxID == "" {
+		err := errors.New("cannot DelState without a transactions - call stub.MockTransactionStart()?")
+		return err
+	}
+
+	stub.recordWrite(key, nil, true)
 
+	return nil
+}
+
+// recordWrite stores the latest write of a key in the current transaction.
+// Like the Fabric history database, only the final value of each key
+// written by a transaction is kept.
+func (stub *MockStub) recordWrite(key string, value []byte, isDelete bool) {
+	stub.txWrites[key] = &queryresult.KeyModification{
+		TxId:      stub.TxID,
+		Value:     value,
+		Timestamp: stub.TxTimestamp,
+		IsDelete:  isDelete,
+	}
+}
+
+// insertKey inserts the key into the ordered list of keys.
+func (stub *MockStub) insertKey(key string) {
 	// insert key into ordered list of keys
 	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
 		elemValue := elem.Value.(string)
@@ -272,28 +492,28 @@ func (stub *MockStub) PutState(key string, value []byte) error {
 	if stub.Keys.Len() == 0 {
 		stub.Keys.PushFront(key)
 	}
-
-	return nil
 }
 
-// DelState removes the specified `key` and its value from the ledger.
-func (stub *MockStub) DelState(key string) error {
-	delete(stub.State, key)
-
+// removeKey removes the key from the ordered list of keys.
+func (stub *MockStub) removeKey(key string) {
 	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
 		if strings.Compare(key, elem.Value.(string)) == 0 {
 			stub.Keys.Remove(elem)
+			break
 		}
 	}
-
-	return nil
 }
 
-// GetStateByRange ...
+// GetStateByRange returns an iterator over the keys in the range [startKey, endKey).
+// As in the peer, an empty startKey does not include composite keys and an
+// empty endKey leaves the range unbounded.
 func (stub *MockStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
 	if err := validateSimpleKeys(startKey, endKey); err != nil {
 		return nil, err
 	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
 	return NewMockStateRangeQueryIterator(stub, startKey, endKey), nil
 }
 
@@ -314,18 +534,25 @@ func validateSimpleKeys(simpleKeys ...string) error {
 // rich query against state database.  Only supported by state database implementations
 // that support rich query.  The query string is in the syntax of the underlying
 // state database. An iterator is returned which can be used to iterate (next) over
-// the query result set
+// the query result set. The query is evaluated by the mock Mango query engine.
 func (stub *MockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
-	// Not implemented since the mock engine does not have a query engine.
-	// However, a very simple query engine that supports string matching
-	// could be implemented to test that the framework supports queries
-	return nil, errors.New("not implemented")
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return NewMockQueryResultIterator(executeQuery(stub.State, q, false)), nil
 }
 
 // GetHistoryForKey function can be invoked by a chaincode to return a history of
 // key values across time. GetHistoryForKey is intended to be used for read-only queries.
+// As in Fabric, the modifications are returned from the most recent to the oldest.
 func (stub *MockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
-	return nil, errors.New("not implemented")
+	history := stub.History[key]
+	modifications := make([]*queryresult.KeyModification, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		modifications = append(modifications, history[i])
+	}
+	return NewMockHistoryQueryIterator(modifications), nil
 }
 
 // GetStateByPartialCompositeKey function can be invoked by a chaincode to query the
@@ -366,22 +593,86 @@ func splitCompositeKey(compositeKey string) (string, []string, error) {
 	return components[0], components[1:], nil
 }
 
-// GetStateByRangeWithPagination ...
+// GetStateByRangeWithPagination returns a page of the keys in the range [startKey, endKey).
+// As in the peer, an empty startKey does not include composite keys, an empty
+// endKey leaves the range unbounded and the returned bookmark is the key the
+// next page starts from, which is empty once the range is exhausted.
 func (stub *MockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
 	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
-	return nil, nil, nil
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	return stub.getStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
 }
 
-// GetStateByPartialCompositeKeyWithPagination ...
+// GetStateByPartialCompositeKeyWithPagination returns a page of the composite keys
+// whose prefix matches the given partial composite key.
 func (stub *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
 	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
-	return nil, nil, nil
+	partialCompositeKey, err := stub.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stub.getStateByRangeWithPagination(partialCompositeKey, partialCompositeKey+string(utf8.MaxRune), pageSize, bookmark)
+}
+
+func (stub *MockStub) getStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	// The bookmark is the first key of the page
+	if bookmark != "" {
+		startKey = bookmark
+	}
+
+	results := make([]*queryresult.KV, 0)
+	nextKey := ""
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey {
+			continue
+		}
+		if endKey != "" && key >= endKey {
+			break
+		}
+		if pageSize > 0 && len(results) == int(pageSize) {
+			nextKey = key
+			break
+		}
+		results = append(results, &queryresult.KV{Key: key, Value: stub.State[key]})
+	}
+
+	metadata := &pb.QueryResponseMetadata{
+		FetchedRecordsCount: int32(len(results)),
+		Bookmark:            nextKey,
+	}
+
+	return NewMockQueryResultIterator(results), metadata, nil
 }
 
-// GetQueryResultWithPagination ...
+// GetQueryResultWithPagination performs a paginated rich query against the state.
+// The bookmark is an opaque string returned by the previous call which
+// points to the last record of the previous page.
 func (stub *MockStub) GetQueryResultWithPagination(query string, pageSize int32,
 	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
-	return nil, nil, nil
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := executeQuery(stub.State, q, true)
+	page, nextBookmark, err := q.Page(results, pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata := &pb.QueryResponseMetadata{
+		FetchedRecordsCount: int32(len(page)),
+		Bookmark:            nextBookmark,
+	}
+
+	return NewMockQueryResultIterator(page), metadata, nil
 }
 
 // InvokeChaincode locally calls the specified chaincode `Invoke`.
@@ -394,10 +685,28 @@ func (stub *MockStub) InvokeChaincode(chaincodeName string, args [][]byte, chann
 		chaincodeName = chaincodeName + "/" + channel
 	}
 	// TODO "args" here should possibly be a serialized pb.ChaincodeInput
-	otherStub := stub.Invokables[chaincodeName]
-	//	function, strings := getFuncArgs(args)
-	res := otherStub.MockInvoke(stub.TxID, args)
-	return res
+	otherStub, exists := stub.Invokables[chaincodeName]
+	if !exists {
+		return pb.Response{
+			Status:  500,
+			Message: fmt.Sprintf("chaincode %s is not registered with MockPeerChaincode", chaincodeName),
+		}
+	}
+
+	// As on a peer, the invoked chaincode sees the creator of the calling transaction.
+	// The name of mock stubs is used as the MSP ID of the creator.
+	name, creator := otherStub.Name, otherStub.Creator
+	otherStub.Name, otherStub.Creator = stub.Name, stub.Creator
+	defer func() { otherStub.Name, otherStub.Creator = name, creator }()
+
+	// As on a peer, the invoked chaincode runs within the calling transaction, so its writes
+	// are only committed or rolled back when the calling transaction ends
+	if otherStub != stub && (otherStub.txWrites == nil || otherStub.TxID != stub.TxID) {
+		otherStub.MockTransactionStart(stub.TxID)
+		stub.txInvoked = append(stub.txInvoked, otherStub)
+	}
+	otherStub.args = args
+	return otherStub.cc.Invoke(otherStub)
 }
 
 // GetCreator ...
@@ -463,9 +772,17 @@ func (stub *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
 	return stub.TxTimestamp, nil
 }
 
-// SetEvent ...
+// SetEvent sets the event of the transaction, which is emitted to the
+// ChaincodeEventsChannel when the transaction is committed. As on a peer,
+// only the last event set by a transaction is kept.
 func (stub *MockStub) SetEvent(name string, payload []byte) error {
-	stub.ChaincodeEventsChannel <- &pb.ChaincodeEvent{EventName: name, Payload: payload}
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+	if stub.TxID == "" {
+		return errors.New("cannot SetEvent without a transactions - call stub.MockTransactionStart()?")
+	}
+	stub.txEvent = &pb.ChaincodeEvent{ChaincodeId: stub.Name, TxId: stub.TxID, EventName: name, Payload: payload}
 	return nil
 }
 
@@ -509,11 +826,13 @@ func NewMockStub(name string, cc shim.Chaincode) *MockStub {
 	s.cc = cc
 	s.State = make(map[string][]byte)
 	s.PvtState = make(map[string]map[string][]byte)
+	s.CollectionMembers = make(map[string][]string)
 	s.EndorsementPolicies = make(map[string]map[string][]byte)
 	s.Invokables = make(map[string]*MockStub)
 	s.Keys = list.New()
 	s.ChaincodeEventsChannel = make(chan *pb.ChaincodeEvent, 100) //define large capacity for non-blocking setEvent calls.
 	s.Decorations = make(map[string][]byte)
+	s.History = make(map[string][]*queryresult.KeyModification)
 	s.Creator, _ = newCreator(name, []byte{})
 	return s
 }
@@ -567,7 +886,7 @@ func (iter *MockStateRangeQueryIterator) HasNext() bool {
 		comp1 := strings.Compare(current.Value.(string), iter.StartKey)
 		comp2 := strings.Compare(current.Value.(string), iter.EndKey)
 		if comp1 >= 0 {
-			if comp2 < 0 {
+			if comp2 < 0 || iter.EndKey == "" {
 				return true
 			}
 			return false
@@ -594,7 +913,7 @@ func (iter *MockStateRangeQueryIterator) Next() (*queryresult.KV, error) {
 		comp2 := strings.Compare(iter.Current.Value.(string), iter.EndKey)
 		// compare to start and end keys. or, if this is an open-ended query for
 		// all keys, it should always return the key and value
-		if (comp1 >= 0 && comp2 < 0) || (iter.StartKey == "" && iter.EndKey == "") {
+		if (comp1 >= 0 && (comp2 < 0 || iter.EndKey == "")) || (iter.StartKey == "" && iter.EndKey == "") {
 			key := iter.Current.Value.(string)
 			value, err := iter.Stub.GetState(key)
 			iter.Current = iter.Current.Next()
@@ -0,0 +1,68 @@
+package stubwrapper
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// EventEnvelopeName is the name of the chaincode event which carries the events raised by a transaction.
+const EventEnvelopeName = "@events"
+
+// Event is an event raised during a transaction.
+type Event struct {
+	Tag     string `json:"tag"`
+	Payload []byte `json:"payload"`
+}
+
+// EventEnvelope is the payload of the envelope event.
+type EventEnvelope struct {
+	Events []Event `json:"events"`
+}
+
+// SetEvent raises an event. Since Fabric only keeps the last event set by a transaction,
+// events are accumulated and set in a single envelope event by Flush. Code creating a
+// StubWrapper outside transactions.Run must call Flush once the transaction succeeds,
+// otherwise the events raised are silently lost.
+func (sw *StubWrapper) SetEvent(name string, payload []byte) errors.ICCError {
+	if name == "" {
+		return errors.NewCCError("event name can not be empty string", 400)
+	}
+
+	sw.Events = append(sw.Events, Event{
+		Tag:     name,
+		Payload: payload,
+	})
+
+	return nil
+}
+
+// DiscardEvents drops the events raised and not flushed yet, returning how many were dropped.
+// It is called when the transaction fails, so none of its events are emitted.
+func (sw *StubWrapper) DiscardEvents() int {
+	discarded := len(sw.Events)
+	sw.Events = nil
+
+	return discarded
+}
+
+// FlushEvents sets the envelope event with the events raised so far, if any.
+// It is called by Flush.
+func (sw *StubWrapper) FlushEvents() errors.ICCError {
+	if len(sw.Events) == 0 {
+		return nil
+	}
+
+	envelopeJSON, err := json.Marshal(EventEnvelope{Events: sw.Events})
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, "failed to marshal event envelope", 500)
+	}
+
+	err = sw.Stub.SetEvent(EventEnvelopeName, envelopeJSON)
+	if err != nil {
+		return errors.WrapError(err, "stub.SetEvent call error")
+	}
+	sw.Events = nil
+
+	return nil
+}
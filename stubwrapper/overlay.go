@@ -0,0 +1,139 @@
+package stubwrapper
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/internal/mango"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// emptyKeySubstitute is the start key the peer uses for range queries with an
+// empty start key, which keeps composite keys out of the results.
+const emptyKeySubstitute = "\x01"
+
+// overlayIterator merges the committed results of a query with the pending
+// writes of the transaction. Committed entries whose keys were written in the
+// transaction are skipped, since their pending value (if any) is in pending.
+type overlayIterator struct {
+	base    shim.StateQueryIteratorInterface
+	pending []*queryresult.KV
+	written map[string][]byte
+	less    func(a, b *queryresult.KV) bool
+
+	next *queryresult.KV
+	err  error
+}
+
+func (it *overlayIterator) fetch() {
+	for it.next == nil && it.err == nil && it.base.HasNext() {
+		kv, err := it.base.Next()
+		if err != nil {
+			it.err = err
+			return
+		}
+		if _, isWritten := it.written[kv.Key]; isWritten {
+			continue
+		}
+		it.next = kv
+	}
+}
+
+// HasNext returns true if there are committed or pending results left.
+func (it *overlayIterator) HasNext() bool {
+	it.fetch()
+	return it.err != nil || it.next != nil || len(it.pending) > 0
+}
+
+// Next returns the next result in the order defined by the query.
+func (it *overlayIterator) Next() (*queryresult.KV, error) {
+	it.fetch()
+	if it.err != nil {
+		return nil, it.err
+	}
+
+	if len(it.pending) > 0 && (it.next == nil || it.less(it.pending[0], it.next)) {
+		kv := it.pending[0]
+		it.pending = it.pending[1:]
+		return kv, nil
+	}
+
+	if it.next == nil {
+		return nil, errors.NewCCError("iterator has no more results", 500)
+	}
+	kv := it.next
+	it.next = nil
+	return kv, nil
+}
+
+// Close closes the underlying committed iterator.
+func (it *overlayIterator) Close() error {
+	return it.base.Close()
+}
+
+func lessKey(a, b *queryresult.KV) bool {
+	return a.Key < b.Key
+}
+
+// overlayRange merges the pending writes in the range [startKey, endKey) into the committed iterator.
+func overlayRange(base shim.StateQueryIteratorInterface, written map[string][]byte, startKey, endKey string) shim.StateQueryIteratorInterface {
+	if len(written) == 0 {
+		return base
+	}
+
+	pending := make([]*queryresult.KV, 0)
+	for key, value := range written {
+		if value == nil || key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		pending = append(pending, &queryresult.KV{Key: key, Value: value})
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Key < pending[j].Key
+	})
+
+	return &overlayIterator{
+		base:    base,
+		pending: pending,
+		written: written,
+		less:    lessKey,
+	}
+}
+
+// overlayQuery merges the pending writes matching the rich query into the committed iterator.
+// The query's limit and skip only apply to the committed results. Queries the matcher cannot
+// evaluate, such as those with unsupported operators, only return the committed results.
+func overlayQuery(base shim.StateQueryIteratorInterface, written map[string][]byte, query string) (shim.StateQueryIteratorInterface, errors.ICCError) {
+	if len(written) == 0 {
+		return base, nil
+	}
+
+	q, err := mango.ParseQuery(query)
+	if err != nil {
+		return base, nil
+	}
+
+	candidates := make([]*queryresult.KV, 0)
+	for key, value := range written {
+		if value == nil {
+			continue
+		}
+		candidates = append(candidates, &queryresult.KV{Key: key, Value: value})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Key < candidates[j].Key
+	})
+
+	return &overlayIterator{
+		base:    base,
+		pending: q.Execute(candidates),
+		written: written,
+		less:    q.Less,
+	}, nil
+}
+
+func partialCompositeKeyRange(compositeKey string) (string, string) {
+	return compositeKey, compositeKey + string(utf8.MaxRune)
+}
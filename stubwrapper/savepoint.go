@@ -0,0 +1,153 @@
+package stubwrapper
+
+import (
+	"sort"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+)
+
+// Savepoint marks a point of the transaction, so the writes made and the events
+// raised after it can be undone with RollbackTo.
+type Savepoint struct {
+	undo   int
+	events int
+}
+
+// writeKey identifies an entry of the write sets. The collection is empty for public states.
+type writeKey struct {
+	collection string
+	key        string
+}
+
+// undoEntry is the entry of a write set before it was overwritten.
+type undoEntry struct {
+	writeKey
+	value   []byte
+	written bool
+	pending bool
+}
+
+// Savepoint records the current point of the transaction. Since Fabric cannot remove a write
+// from a transaction, the writes made from then on are only kept in the write sets, and sent to
+// the stub by Flush once they can no longer be rolled back.
+func (sw *StubWrapper) Savepoint() *Savepoint {
+	sw.deferWrites = true
+
+	return &Savepoint{
+		undo:   len(sw.undoLog),
+		events: len(sw.Events),
+	}
+}
+
+// RollbackTo undoes the writes made and discards the events raised after the savepoint was recorded.
+// The write sets are restored from the undo log, so no write is sent to the stub.
+func (sw *StubWrapper) RollbackTo(sp *Savepoint) errors.ICCError {
+	if sp.undo > len(sw.undoLog) {
+		return errors.NewCCError("savepoint was released by a flush", 500)
+	}
+
+	if sp.events < len(sw.Events) {
+		sw.Events = sw.Events[:sp.events]
+	}
+
+	for i := len(sw.undoLog) - 1; i >= sp.undo; i-- {
+		entry := sw.undoLog[i]
+		writes := sw.writeSet(entry.collection)
+		if entry.written {
+			writes[entry.key] = entry.value
+		} else {
+			delete(writes, entry.key)
+		}
+		if entry.pending {
+			sw.pending[entry.writeKey] = true
+		} else {
+			delete(sw.pending, entry.writeKey)
+		}
+	}
+	sw.undoLog = sw.undoLog[:sp.undo]
+
+	return nil
+}
+
+// Flush sends the writes deferred since the first savepoint to the stub and sets the envelope
+// event with the events raised, releasing previous savepoints. It must be called once the
+// transaction succeeds by every entry point creating a StubWrapper, as transactions.Run does,
+// since the deferred writes and the raised events are otherwise lost.
+func (sw *StubWrapper) Flush() errors.ICCError {
+	keys := make([]writeKey, 0, len(sw.pending))
+	for k := range sw.pending {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].collection != keys[j].collection {
+			return keys[i].collection < keys[j].collection
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	for _, k := range keys {
+		value := sw.writeSet(k.collection)[k.key]
+
+		var err error
+		switch {
+		case k.collection == "" && value == nil:
+			err = sw.Stub.DelState(k.key)
+		case k.collection == "":
+			err = sw.Stub.PutState(k.key, value)
+		case value == nil:
+			err = sw.Stub.DelPrivateData(k.collection, k.key)
+		default:
+			err = sw.Stub.PutPrivateData(k.collection, k.key, value)
+		}
+		if err != nil {
+			return errors.WrapError(err, "failed to write deferred state")
+		}
+	}
+
+	sw.pending = nil
+	sw.undoLog = nil
+	sw.deferWrites = false
+
+	return sw.FlushEvents()
+}
+
+// setWrite records a write in the write sets. After a savepoint, the previous entry is
+// kept in the undo log and the write is deferred until Flush.
+func (sw *StubWrapper) setWrite(collection, key string, obj []byte) {
+	writes := sw.writeSet(collection)
+	if sw.deferWrites {
+		k := writeKey{collection: collection, key: key}
+		value, written := writes[key]
+		sw.undoLog = append(sw.undoLog, undoEntry{
+			writeKey: k,
+			value:    value,
+			written:  written,
+			pending:  sw.pending[k],
+		})
+
+		if sw.pending == nil {
+			sw.pending = make(map[writeKey]bool)
+		}
+		sw.pending[k] = true
+	}
+
+	writes[key] = obj
+}
+
+// writeSet returns the write set of the collection, or the public one if it is empty.
+func (sw *StubWrapper) writeSet(collection string) map[string][]byte {
+	if collection == "" {
+		if sw.WriteSet == nil {
+			sw.WriteSet = make(map[string][]byte)
+		}
+		return sw.WriteSet
+	}
+
+	if sw.PvtWriteSet == nil {
+		sw.PvtWriteSet = make(map[string]map[string][]byte)
+	}
+	if sw.PvtWriteSet[collection] == nil {
+		sw.PvtWriteSet[collection] = make(map[string][]byte)
+	}
+	return sw.PvtWriteSet[collection]
+}
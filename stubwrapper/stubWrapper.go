@@ -13,25 +13,41 @@ type StubWrapper struct {
 	Stub        shim.ChaincodeStubInterface
 	WriteSet    map[string][]byte
 	PvtWriteSet map[string]map[string][]byte
+
+	// CommittedReadsOnly disables the write set overlay, so reads and queries
+	// only return committed ledger states, as they do on a peer.
+	CommittedReadsOnly bool
+
+	// Events are the events raised during the transaction, not yet set in the envelope event.
+	Events []Event
+
+	// EventDepth is the number of nested transactions triggered by events currently running.
+	EventDepth int
+
+	// deferWrites is set by Savepoint. Writes are then only kept in the write sets until Flush.
+	deferWrites bool
+	pending     map[writeKey]bool
+	undoLog     []undoEntry
 }
 
 func (sw *StubWrapper) PutState(key string, obj []byte) errors.ICCError {
-	err := sw.Stub.PutState(key, obj)
-	if err != nil {
-		return errors.WrapError(err, "stub.PutState call error")
+	if !sw.deferWrites {
+		err := sw.Stub.PutState(key, obj)
+		if err != nil {
+			return errors.WrapError(err, "stub.PutState call error")
+		}
 	}
 
-	if sw.WriteSet == nil {
-		sw.WriteSet = make(map[string][]byte)
-	}
-	sw.WriteSet[key] = obj
+	sw.setWrite("", key, obj)
 
 	return nil
 }
 
+// GetState returns the value written in the transaction, or the committed one.
+// Deleted keys return nil.
 func (sw *StubWrapper) GetState(key string) ([]byte, errors.ICCError) {
 	obj, inSet := sw.WriteSet[key]
-	if inSet {
+	if inSet && !sw.CommittedReadsOnly {
 		return obj, nil
 	}
 
@@ -48,40 +64,36 @@ func (sw *StubWrapper) GetCommittedState(key string) ([]byte, errors.ICCError) {
 }
 
 func (sw *StubWrapper) DelState(key string) errors.ICCError {
-	err := sw.Stub.DelState(key)
-	if err != nil {
-		return errors.WrapError(err, "stub.DelState call error")
+	if !sw.deferWrites {
+		err := sw.Stub.DelState(key)
+		if err != nil {
+			return errors.WrapError(err, "stub.DelState call error")
+		}
 	}
 
-	if sw.WriteSet == nil {
-		sw.WriteSet = make(map[string][]byte)
-	}
-	sw.WriteSet[key] = nil
+	sw.setWrite("", key, nil)
 
 	return nil
 }
 
 func (sw *StubWrapper) PutPrivateData(collection, key string, obj []byte) errors.ICCError {
-	err := sw.Stub.PutPrivateData(collection, key, obj)
-	if err != nil {
-		return errors.WrapError(err, "stub.PutPrivateData call error")
-	}
-
-	if sw.PvtWriteSet == nil {
-		sw.PvtWriteSet = make(map[string]map[string][]byte)
-	}
-	if sw.PvtWriteSet[collection] == nil {
-		sw.PvtWriteSet[collection] = make(map[string][]byte)
+	if collection == "" || !sw.deferWrites {
+		err := sw.Stub.PutPrivateData(collection, key, obj)
+		if err != nil {
+			return errors.WrapError(err, "stub.PutPrivateData call error")
+		}
 	}
 
-	sw.PvtWriteSet[collection][key] = obj
+	sw.setWrite(collection, key, obj)
 
 	return nil
 }
 
+// GetPrivateData returns the value written in the transaction, or the committed one.
+// Deleted keys return nil.
 func (sw *StubWrapper) GetPrivateData(collection, key string) ([]byte, errors.ICCError) {
 	obj, inSet := sw.PvtWriteSet[collection][key]
-	if inSet {
+	if inSet && !sw.CommittedReadsOnly {
 		return obj, nil
 	}
 
@@ -100,7 +112,7 @@ func (sw *StubWrapper) GetCommittedPrivateData(collection, key string) ([]byte,
 
 func (sw *StubWrapper) GetPrivateDataHash(collection, key string) ([]byte, errors.ICCError) {
 	obj, inSet := sw.PvtWriteSet[collection][key]
-	if inSet {
+	if inSet && !sw.CommittedReadsOnly {
 		if obj != nil {
 			return util.ComputeSHA256(obj), nil
 		} else {
@@ -117,19 +129,14 @@ func (sw *StubWrapper) GetPrivateDataHash(collection, key string) ([]byte, error
 }
 
 func (sw *StubWrapper) DelPrivateData(collection, key string) errors.ICCError {
-	err := sw.Stub.DelPrivateData(collection, key)
-	if err != nil {
-		return errors.WrapError(err, "stub.DelPrivateData call error")
-	}
-
-	if sw.PvtWriteSet == nil {
-		sw.PvtWriteSet = make(map[string]map[string][]byte)
-	}
-	if sw.PvtWriteSet[collection] == nil {
-		sw.PvtWriteSet[collection] = make(map[string][]byte)
+	if collection == "" || !sw.deferWrites {
+		err := sw.Stub.DelPrivateData(collection, key)
+		if err != nil {
+			return errors.WrapError(err, "stub.DelPrivateData call error")
+		}
 	}
 
-	sw.WriteSet[key] = nil
+	sw.setWrite(collection, key, nil)
 
 	return nil
 }
@@ -142,25 +149,32 @@ func (sw *StubWrapper) CreateCompositeKey(objectType string, attributes []string
 	return compositeKey, nil
 }
 
-// GetQueryResult does not return non-commited ledger states
+// GetQueryResult merges the states written in the transaction into the query results
 func (sw *StubWrapper) GetQueryResult(query string) (shim.StateQueryIteratorInterface, errors.ICCError) {
 	it, err := sw.Stub.GetQueryResult(query)
 	if err != nil {
 		return it, errors.WrapError(err, "stub.GetQueryResult call error")
 	}
-	return it, nil
+	if sw.CommittedReadsOnly {
+		return it, nil
+	}
+	return overlayQuery(it, sw.WriteSet, query)
 }
 
-// GetPrivateDataQueryResult does not return non-commited ledger states
+// GetPrivateDataQueryResult merges the private data written in the transaction into the query results
 func (sw *StubWrapper) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, errors.ICCError) {
 	it, err := sw.Stub.GetPrivateDataQueryResult(collection, query)
 	if err != nil {
 		return it, errors.WrapError(err, "stub.GetPrivateDataQueryResult call error")
 	}
-	return it, nil
+	if sw.CommittedReadsOnly {
+		return it, nil
+	}
+	return overlayQuery(it, sw.PvtWriteSet[collection], query)
 }
 
-// GetQueryResultWithPagination does not return non-commited ledger states
+// GetQueryResultWithPagination does not return non-commited ledger states,
+// since bookmarks can only refer to committed results
 func (sw *StubWrapper) GetQueryResultWithPagination(query string, pageSize int32,
 	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, errors.ICCError) {
 
@@ -171,13 +185,70 @@ func (sw *StubWrapper) GetQueryResultWithPagination(query string, pageSize int32
 	return it, metadata, nil
 }
 
-// GetStateByPartialCompositeKey does not return non-commited ledger states
+// GetStateByRange merges the states written in the transaction into the range results
+func (sw *StubWrapper) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, errors.ICCError) {
+	it, err := sw.Stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return it, errors.WrapError(err, "stub.GetStateByRange call error")
+	}
+	if sw.CommittedReadsOnly {
+		return it, nil
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	return overlayRange(it, sw.WriteSet, startKey, endKey), nil
+}
+
+// GetStateByPartialCompositeKey merges the states written in the transaction into the results
 func (sw *StubWrapper) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, errors.ICCError) {
 	it, err := sw.Stub.GetStateByPartialCompositeKey(objectType, keys)
 	if err != nil {
 		return it, errors.WrapError(err, "stub.GetStateByPartialCompositeKey call error")
 	}
-	return it, nil
+	if sw.CommittedReadsOnly {
+		return it, nil
+	}
+	compositeKey, err := sw.Stub.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		it.Close()
+		return nil, errors.WrapError(err, "stub.CreateCompositeKey call error")
+	}
+	startKey, endKey := partialCompositeKeyRange(compositeKey)
+	return overlayRange(it, sw.WriteSet, startKey, endKey), nil
+}
+
+// GetPrivateDataByRange merges the private data written in the transaction into the range results
+func (sw *StubWrapper) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, errors.ICCError) {
+	it, err := sw.Stub.GetPrivateDataByRange(collection, startKey, endKey)
+	if err != nil {
+		return it, errors.WrapError(err, "stub.GetPrivateDataByRange call error")
+	}
+	if sw.CommittedReadsOnly {
+		return it, nil
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	return overlayRange(it, sw.PvtWriteSet[collection], startKey, endKey), nil
+}
+
+// GetPrivateDataByPartialCompositeKey merges the private data written in the transaction into the results
+func (sw *StubWrapper) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, errors.ICCError) {
+	it, err := sw.Stub.GetPrivateDataByPartialCompositeKey(collection, objectType, keys)
+	if err != nil {
+		return it, errors.WrapError(err, "stub.GetPrivateDataByPartialCompositeKey call error")
+	}
+	if sw.CommittedReadsOnly {
+		return it, nil
+	}
+	compositeKey, err := sw.Stub.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		it.Close()
+		return nil, errors.WrapError(err, "stub.CreateCompositeKey call error")
+	}
+	startKey, endKey := partialCompositeKeyRange(compositeKey)
+	return overlayRange(it, sw.PvtWriteSet[collection], startKey, endKey), nil
 }
 
 // GetHistoryForKey does not return non-commited ledger states
@@ -202,6 +273,20 @@ func (sw *StubWrapper) GetMSPID() (string, errors.ICCError) {
 	return mspid, nil
 }
 
+// GetID wraps cid.GetID allowing for automated testing. Mock stubs without a
+// certificate are identified by their name.
+func (sw *StubWrapper) GetID() (string, errors.ICCError) {
+	id, err := cid.GetID(sw.Stub)
+	if err != nil {
+		mockStub, isMock := sw.Stub.(*mock.MockStub)
+		if isMock {
+			return mockStub.Name, nil
+		}
+		return id, errors.WrapError(err, "cid.GetID call error")
+	}
+	return id, nil
+}
+
 // SplitCompositeKey returns composite keys
 func (sw *StubWrapper) SplitCompositeKey(compositeKey string) (string, []string, errors.ICCError) {
 	key, keys, err := sw.Stub.SplitCompositeKey(compositeKey)
@@ -210,12 +295,3 @@ func (sw *StubWrapper) SplitCompositeKey(compositeKey string) (string, []string,
 	}
 	return key, keys, nil
 }
-
-func (sw *StubWrapper) SetEvent(name string, payload []byte) errors.ICCError {
-	err := sw.Stub.SetEvent(name, payload)
-	if err != nil {
-		return errors.WrapError(err, "stub.SetEvent call error")
-	}
-
-	return nil
-}
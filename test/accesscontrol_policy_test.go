@@ -0,0 +1,218 @@
+package test
+
+import (
+	b64 "encoding/base64"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/events"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+func TestParsePolicy(t *testing.T) {
+	valid := []string{
+		`msp == "org1MSP" && (attr.role in ["admin", "auditor"] || ou == "finance")`,
+		`!has(attr.hf.Revoked) && msp matches '^org\d+MSP$'`,
+		`attr.level >= 3 && attr.level < 10.5`,
+		`now >= "2024-01-01T00:00:00Z" && hour >= 9 && hour < 18 && weekday != 0`,
+		`"finance" in ou || id != "" || true`,
+	}
+	for _, expr := range valid {
+		_, err := accesscontrol.ParsePolicy(expr)
+		if err != nil {
+			log.Printf("expected %s to be valid: %s", expr, err)
+			t.FailNow()
+		}
+	}
+
+	invalid := map[string]string{
+		`msp`:                         "policy expression must be a boolean expression",
+		`msp == "org1MSP" &&`:         "unexpected end of expression at position 19",
+		`(msp == "org1MSP"`:           "expected \")\" at position 17",
+		`org == "org1MSP"`:            "unknown identifier \"org\" at position 0",
+		`msp matches "("`:             "invalid regular expression \"(\" at position 4",
+		`msp in "org1MSP"`:            "right operand of in must be a list, got org1MSP at position 4",
+		`now < "tomorrow"`:            "invalid time \"tomorrow\", expected RFC 3339 format at position 4",
+		`msp == "org1MSP" && attr.x`:  "operands of && must be boolean expressions at position 17",
+		`has(msp)`:                    "has expects an attribute at position 4",
+		`msp == "org1MSP" # comment`:  "unexpected character '#' at position 17",
+		`attr.role in ["admin", msp]`: "list items must be literals at position 23",
+	}
+	for expr, expectedErr := range invalid {
+		_, err := accesscontrol.ParsePolicy(expr)
+		if err == nil || err.(errors.ICCError).Message() != expectedErr {
+			log.Printf("expected %s to fail with %q, got %v", expr, expectedErr, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	auditor := newTestCertStub(t, "alice", "org1MSP", "finance", map[string]string{"role": "auditor", "level": "5"})
+	clerk := newTestCertStub(t, "bob", "org2MSP", "client", map[string]string{"role": "clerk"})
+
+	expected := map[string][2]bool{
+		`msp == "org1MSP" && (attr.role in ["admin", "auditor"] || ou == "finance")`: {true, false},
+		`attr.role in ["admin", "auditor"] || ou == "finance"`:                       {true, false},
+		`msp matches '^org\d+MSP$' && !(ou == "finance")`:                            {false, true},
+		`has(attr.level) && attr.level > 3`:                                          {true, false},
+		`attr.level != "5"`:                                                          {false, true},
+		`"client" in ou`:                                                             {false, true},
+		`ou != "client"`:                                                             {true, false},
+		`now > "2000-01-01T00:00:00Z" && now < "2999-01-01T00:00:00Z"`:               {true, true},
+		`now > "2999-01-01T00:00:00Z" || hour < 0 || weekday > 6`:                    {false, false},
+	}
+	for expr, results := range expected {
+		policy, err := accesscontrol.ParsePolicy(expr)
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+		for i, stub := range []*mock.MockStub{auditor, clerk} {
+			stub.MockTransactionStart("evaluatePolicy")
+			allowed, err := policy.Evaluate(stub)
+			stub.MockTransactionEnd("evaluatePolicy")
+			if err != nil {
+				log.Println(err)
+				t.FailNow()
+			}
+			if allowed != results[i] {
+				log.Printf("expected %s to return %v for caller %d", expr, results[i], i)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestPolicyReferences(t *testing.T) {
+	// Policies are registered once per process
+	var err error
+	if accesscontrol.FetchPolicy("testAuditors") == nil {
+		err = accesscontrol.RegisterPolicyExpression("testAuditors", `msp == "org1MSP" && attr.role == "auditor"`)
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+	}
+	err = accesscontrol.RegisterPolicyExpression("testAuditors", `msp == "org2MSP"`)
+	if err == nil || err.(errors.ICCError).Message() != "policy testAuditors is already registered" {
+		log.Println("expected duplicate policy to be rejected, got", err)
+		t.FailNow()
+	}
+	err = accesscontrol.RegisterPolicyExpression("testInvalid", `msp ==`)
+	if err == nil || accesscontrol.FetchPolicy("testInvalid") != nil {
+		log.Println("expected invalid policy to be rejected")
+		t.FailNow()
+	}
+
+	auditor := newTestCertStub(t, "alice", "org1MSP", "client", map[string]string{"role": "auditor"})
+	clerk := newTestCertStub(t, "bob", "org1MSP", "client", map[string]string{"role": "clerk"})
+
+	// Transactions
+	defer tx.InitTxList(testTxList)
+	audit := tx.Transaction{
+		Tag:    "audit",
+		Label:  "Audit",
+		Method: "GET",
+		Policy: "testAuditors",
+		Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+			return []byte(`"audited"`), nil
+		},
+	}
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), audit))
+
+	err = invokeAndVerify(auditor, "audit", nil, "audited", 200)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	err = invokeAndVerify(clerk, "audit", nil, "current caller not allowed by policy testAuditors", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// Events
+	defer events.InitEventList(testEventTypeList)
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), events.Event{
+		Tag:    "auditLog",
+		Label:  "Audit Log",
+		Type:   events.EventCustom,
+		Policy: "testAuditors",
+		CustomFunction: func(stub *sw.StubWrapper, payload []byte) error {
+			return nil
+		},
+	}))
+	req := map[string]interface{}{
+		"eventTag": "auditLog",
+		"payload":  b64.StdEncoding.EncodeToString([]byte("{}")),
+	}
+	err = invokeAndVerify(clerk, "runEvent", req, "current caller not allowed by policy testAuditors", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	res := auditor.MockInvoke("runEvent", [][]byte{[]byte("runEvent"), mustMarshal(req)})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	// Asset types
+	defer assets.InitAssetList(testAssetList)
+	auditReport := writerCallersTestAssetType
+	auditReport.WriterCallers = nil
+	auditReport.DeleterCallers = nil
+	auditReport.Policy = "testAuditors"
+	assets.InitAssetList(append([]assets.AssetType{auditReport}, testAssetList...))
+
+	report := map[string]interface{}{"@assetType": "report", "code": "R1"}
+	res = clerk.MockInvoke("createReport", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{report}})})
+	if res.GetStatus() != 403 {
+		log.Println("expected create by clerk to fail, got", res.GetStatus(), res.GetMessage())
+		t.FailNow()
+	}
+	res = auditor.MockInvoke("createReport", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{report}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+}
+
+func TestPolicyStartupCheck(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), tx.Transaction{
+		Tag:    "unregisteredPolicy",
+		Label:  "Unregistered Policy",
+		Policy: "testMissing",
+	}))
+	err := tx.StartupCheck()
+	if err == nil || err.Message() != "policy testMissing of tx unregisteredPolicy is not registered" {
+		log.Println("expected unregistered tx policy to be rejected, got", err)
+		t.FailNow()
+	}
+	tx.InitTxList(testTxList)
+
+	defer events.InitEventList(testEventTypeList)
+	events.InitEventList([]events.Event{{Tag: "missingPolicyLog", Label: "Missing Policy Log", Policy: "testMissing"}})
+	err = tx.StartupCheck()
+	if err == nil || err.Message() != "policy testMissing of event missingPolicyLog is not registered" {
+		log.Println("expected unregistered event policy to be rejected, got", err)
+		t.FailNow()
+	}
+
+	defer assets.InitAssetList(testAssetList)
+	report := writerCallersTestAssetType
+	report.Policy = "testMissing"
+	assets.InitAssetList(append([]assets.AssetType{report}, testAssetList...))
+	err = assets.StartupCheck()
+	if err == nil || err.Message() != "policy testMissing of asset type 'report' is not registered" {
+		log.Println("expected unregistered asset type policy to be rejected, got", err)
+		t.FailNow()
+	}
+}
@@ -1,10 +1,12 @@
 package test
 
 import (
+	"encoding/json"
 	"log"
 	"reflect"
 	"testing"
 
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/assets"
 )
 
@@ -20,6 +22,7 @@ func TestAssetPropToMap(t *testing.T) {
 		"defaultValue": nil,
 		"dataType":     "cpf",
 		"writers":      []string{"org1MSP"},
+		"readers":      []string(nil),
 	}
 
 	if !reflect.DeepEqual(propMap, expectedMap) {
@@ -30,6 +33,39 @@ func TestAssetPropToMap(t *testing.T) {
 	}
 }
 
+func TestAssetPropMapRoundTrip(t *testing.T) {
+	prop := assets.AssetProp{
+		Tag:           "diagnosis",
+		Label:         "Diagnosis",
+		DataType:      "string",
+		Writers:       []string{"org1MSP"},
+		WriterCallers: []accesscontrol.Caller{{MSP: "org2MSP", Attributes: map[string]string{"role": "doctor"}}},
+		Readers:       []string{"org1MSP"},
+		ReaderCallers: []accesscontrol.Caller{{MSP: "org3MSP", OU: "auditors"}},
+		Collection:    "clinicCollection",
+	}
+
+	roundTrip := assets.AssetPropFromMap(prop.ToMap())
+	if !reflect.DeepEqual(roundTrip, prop) {
+		log.Println("these should be deeply equal")
+		log.Println(roundTrip)
+		log.Println(prop)
+		t.FailNow()
+	}
+
+	// Asset types stored in the ledger are decoded from JSON
+	propJSON, _ := json.Marshal(prop.ToMap())
+	var propMap map[string]interface{}
+	_ = json.Unmarshal(propJSON, &propMap)
+	roundTrip = assets.AssetPropFromMap(propMap)
+	if !reflect.DeepEqual(roundTrip, prop) {
+		log.Println("these should be deeply equal")
+		log.Println(roundTrip)
+		log.Println(prop)
+		t.FailNow()
+	}
+}
+
 func TestAssetPropFromMap(t *testing.T) {
 	testMap := map[string]interface{}{
 		"tag":      "secretName",
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+type derivedAuthor struct {
+	Key     string `cctools:"@key,omitempty"`
+	Name    string `cctools:"name,key" label:"Author name"`
+	Country string `cctools:"country,omitempty" writers:"org1MSP,org2MSP" default:"Brazil"`
+}
+
+type derivedPoem struct {
+	Title   string                 `cctools:"title,key" label:"Title" description:"Title of the poem"`
+	Author  *derivedAuthor         `cctools:"author,required"`
+	Lines   []string               `cctools:"lines,omitempty"`
+	Rating  int                    `cctools:"rating,omitempty" default:"3"`
+	Written time.Time              `cctools:"written,readOnly,omitempty"`
+	Info    map[string]interface{} `cctools:"info,omitempty"`
+	Code    string                 `cctools:"code,omitempty,dataType=cpf" writers:"[\"$org\\\\d{1,2}MSP\"]"`
+	Related []assets.Key           `json:"related,omitempty"`
+	Ignored string                 `cctools:"-"`
+}
+
+func TestAssetTypeFromStruct(t *testing.T) {
+	authorType, err := assets.AssetTypeFromStruct(derivedAuthor{}, assets.AssetTypeOptions{})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	expectedAuthorType := assets.AssetType{
+		Tag:   "derivedAuthor",
+		Label: "derivedAuthor",
+		Props: []assets.AssetProp{
+			{Tag: "name", Label: "Author name", IsKey: true, Required: true, DataType: "string"},
+			{Tag: "country", Label: "Country", DataType: "string", Writers: []string{"org1MSP", "org2MSP"}, DefaultValue: "Brazil"},
+		},
+	}
+	if !reflect.DeepEqual(authorType, expectedAuthorType) {
+		log.Println("these should be deeply equal")
+		log.Println(expectedAuthorType)
+		log.Println(authorType)
+		t.FailNow()
+	}
+
+	poemType, err := assets.AssetTypeFromStruct(&derivedPoem{}, assets.AssetTypeOptions{
+		Tag:     "poem",
+		Label:   "Poem",
+		Readers: []string{"org1MSP"},
+	})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	expectedPoemType := assets.AssetType{
+		Tag:     "poem",
+		Label:   "Poem",
+		Readers: []string{"org1MSP"},
+		Props: []assets.AssetProp{
+			{Tag: "title", Label: "Title", Description: "Title of the poem", IsKey: true, Required: true, DataType: "string"},
+			{Tag: "author", Label: "Author", Required: true, DataType: "->derivedAuthor"},
+			{Tag: "lines", Label: "Lines", DataType: "[]string"},
+			{Tag: "rating", Label: "Rating", DataType: "integer", DefaultValue: 3.0},
+			{Tag: "written", Label: "Written", ReadOnly: true, DataType: "datetime"},
+			{Tag: "info", Label: "Info", DataType: "@object"},
+			{Tag: "code", Label: "Code", DataType: "cpf", Writers: []string{`$org\d{1,2}MSP`}},
+			{Tag: "related", Label: "Related", DataType: "[]->@asset"},
+		},
+	}
+	if !reflect.DeepEqual(poemType, expectedPoemType) {
+		log.Println("these should be deeply equal")
+		log.Println(expectedPoemType)
+		log.Println(poemType)
+		t.FailNow()
+	}
+
+	_, err = assets.AssetTypeFromStruct(struct {
+		Value interface{} `cctools:"value"`
+	}{}, assets.AssetTypeOptions{Tag: "anonymous"})
+	if err == nil {
+		log.Println("expected error inferring data type of interface{}")
+		t.FailNow()
+	}
+
+	_, err = assets.AssetTypeFromStruct(struct {
+		Value string `cctools:"value,primary"`
+	}{}, assets.AssetTypeOptions{Tag: "anonymous"})
+	if err == nil {
+		log.Println("expected error for unknown option")
+		t.FailNow()
+	}
+}
+
+func TestGenerateStructSource(t *testing.T) {
+	src, err := assets.GenerateStructSource(testAssetList[0], "models")
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	_, parseErr := parser.ParseFile(token.NewFileSet(), "person.go", src, parser.AllErrors)
+	if parseErr != nil {
+		log.Println(parseErr)
+		log.Println(string(src))
+		t.FailNow()
+	}
+
+	expectedLines := []string{
+		"package models",
+		"type Person struct {",
+		"Key         string                 `cctools:\"@key,omitempty\"`",
+		"Id          interface{}            `cctools:\"id,key,dataType=cpf\" label:\"CPF (Brazilian ID)\" writers:\"org1MSP\"`",
+		"Name        string                 `cctools:\"name,required\" label:\"Name of the person\"`",
+		"DateOfBirth time.Time              `cctools:\"dateOfBirth,omitempty\" label:\"Date of Birth\" writers:\"org1MSP\"`",
+		"Height      float64                `cctools:\"height,omitempty\" label:\"Person's height\" default:\"0\"`",
+		"Info        map[string]interface{} `cctools:\"info,omitempty\" label:\"Other Info\"`",
+		"Association []assets.Key           `cctools:\"association,omitempty\" label:\"Association\"`",
+		"var PersonAssetTypeOptions = assets.AssetTypeOptions{",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(string(src), line) {
+			log.Println("missing line:", line)
+			log.Println(string(src))
+			t.FailNow()
+		}
+	}
+}
+
+func TestGenerateStoredStructSource(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	magazine := map[string]interface{}{
+		"tag":     "magazine",
+		"label":   "Magazine",
+		"dynamic": true,
+		"props": []interface{}{
+			map[string]interface{}{
+				"tag":      "name",
+				"label":    "Name",
+				"dataType": "string",
+				"isKey":    true,
+				"required": true,
+			},
+			map[string]interface{}{
+				"tag":      "images",
+				"label":    "Images",
+				"dataType": "[]string",
+			},
+		},
+	}
+	listAsset := map[string]interface{}{
+		"@assetType":  "assetTypeListData",
+		"@key":        "assetTypeListData:1e5da1f3-a1b0-5d7d-ba2a-f8f5d0cb6b69",
+		"id":          "primary",
+		"list":        []interface{}{magazine},
+		"lastUpdated": time.Now().Format(time.RFC3339),
+	}
+	listKey, _ := assets.NewKey(map[string]interface{}{"@assetType": "assetTypeListData", "id": "primary"})
+	listAsset["@key"] = listKey.Key()
+	listJSON, _ := json.Marshal(listAsset)
+	stub.MockTransactionStart("setup")
+	stub.PutState(listKey.Key(), listJSON)
+	stub.MockTransactionEnd("setup")
+
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	src, err := assets.GenerateStoredStructSource(sw, "magazine", "models")
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if !strings.Contains(string(src), "Images []string `cctools:\"images,omitempty\" label:\"Images\"`") ||
+		!strings.Contains(string(src), "Dynamic: true,") {
+		log.Println(string(src))
+		t.FailNow()
+	}
+
+	_, err = assets.GenerateStoredStructSource(sw, "newspaper", "models")
+	if err == nil || err.Status() != 404 {
+		log.Println("expected 404 for missing asset type")
+		t.FailNow()
+	}
+}
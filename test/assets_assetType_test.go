@@ -38,6 +38,7 @@ func TestAssetTypeToMap(t *testing.T) {
 				"defaultValue": nil,
 				"dataType":     "cpf",
 				"writers":      []string{"org1MSP"},
+				"readers":      emptySlice,
 			},
 			{
 				"tag":          "name",
@@ -49,6 +50,7 @@ func TestAssetTypeToMap(t *testing.T) {
 				"defaultValue": nil,
 				"dataType":     "string",
 				"writers":      emptySlice,
+				"readers":      emptySlice,
 			},
 			{
 				"tag":          "dateOfBirth",
@@ -60,6 +62,7 @@ func TestAssetTypeToMap(t *testing.T) {
 				"defaultValue": nil,
 				"dataType":     "datetime",
 				"writers":      []string{"org1MSP"},
+				"readers":      emptySlice,
 			},
 			{
 				"tag":          "height",
@@ -71,6 +74,7 @@ func TestAssetTypeToMap(t *testing.T) {
 				"defaultValue": 0,
 				"dataType":     "number",
 				"writers":      emptySlice,
+				"readers":      emptySlice,
 			},
 			{
 				"tag":          "info",
@@ -82,6 +86,7 @@ func TestAssetTypeToMap(t *testing.T) {
 				"defaultValue": nil,
 				"dataType":     "@object",
 				"writers":      emptySlice,
+				"readers":      emptySlice,
 			},
 			{
 				"tag":          "association",
@@ -93,6 +98,7 @@ func TestAssetTypeToMap(t *testing.T) {
 				"defaultValue": nil,
 				"dataType":     "[]->@asset",
 				"writers":      emptySlice,
+				"readers":      emptySlice,
 			},
 		},
 		"readers": emptySlice,
@@ -164,6 +170,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "cpf",
 					"writers":      []string{"org1MSP"},
+					"readers":      emptySlice,
 				},
 				{
 					"tag":          "name",
@@ -175,6 +182,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "string",
 					"writers":      emptySlice,
+					"readers":      emptySlice,
 				},
 				{
 					"tag":          "dateOfBirth",
@@ -186,6 +194,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "datetime",
 					"writers":      []string{"org1MSP"},
+					"readers":      emptySlice,
 				},
 				{
 					"tag":          "height",
@@ -197,6 +206,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": 0,
 					"dataType":     "number",
 					"writers":      emptySlice,
+					"readers":      emptySlice,
 				},
 				{
 					"tag":          "info",
@@ -208,6 +218,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "@object",
 					"writers":      emptySlice,
+					"readers":      emptySlice,
 				},
 				{
 					"tag":          "association",
@@ -219,6 +230,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "[]->@asset",
 					"writers":      emptySlice,
+					"readers":      emptySlice,
 				},
 			},
 			"readers": emptySlice,
@@ -239,6 +251,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "string",
 					"writers":      []string{"org2MSP"},
+					"readers":      emptySlice,
 				},
 				{
 					"tag":          "secret",
@@ -250,6 +263,7 @@ func TestAssetTypeListToMap(t *testing.T) {
 					"defaultValue": nil,
 					"dataType":     "string",
 					"writers":      emptySlice,
+					"readers":      emptySlice,
 				},
 			},
 			"readers": []string{"org2MSP", "org3MSP"},
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+type bindingPerson struct {
+	Key         string                 `cctools:"@key,omitempty"`
+	ID          string                 `cctools:"id"`
+	Name        string                 `cctools:"name"`
+	DateOfBirth *time.Time             `cctools:"dateOfBirth,omitempty"`
+	Height      float64                `cctools:"height"`
+	Info        map[string]interface{} `cctools:"info,omitempty"`
+}
+
+type bindingBook struct {
+	Title         string         `json:"title"`
+	Author        string         `json:"author"`
+	CurrentTenant *bindingPerson `cctools:"currentTenant,omitempty"`
+	Genres        []string       `cctools:"genres,omitempty"`
+	Published     time.Time      `cctools:"published,omitempty"`
+}
+
+type bindingLibrary struct {
+	Name      string       `cctools:"name"`
+	Books     []assets.Key `cctools:"books,omitempty"`
+	Librarian assets.Key   `cctools:"librarian,omitempty"`
+}
+
+func registerBindingStructs() {
+	assets.RegisterAssetStruct[bindingPerson]("person")
+	assets.RegisterAssetStruct[bindingBook]("book")
+	assets.RegisterAssetStruct[bindingLibrary]("library")
+}
+
+func TestEncodeDecode(t *testing.T) {
+	dateOfBirth := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	person := bindingPerson{
+		ID:          "318.207.920-48",
+		Name:        "Maria",
+		DateOfBirth: &dateOfBirth,
+		Height:      1.66,
+	}
+
+	asset, err := assets.Encode(person)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if asset["@assetType"] != "person" || asset["@key"] != "person:47061146-c642-51a1-844a-bf0b17cb5e19" || asset["id"] != "31820792048" {
+		log.Println("unexpected encoded asset", asset)
+		t.FailNow()
+	}
+	if _, hasInfo := asset["info"]; hasInfo {
+		log.Println("empty fields with omitempty should not be encoded")
+		t.FailNow()
+	}
+
+	decoded, err := assets.Decode[bindingPerson](asset)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	expected := person
+	expected.Key = "person:47061146-c642-51a1-844a-bf0b17cb5e19"
+	expected.ID = "31820792048"
+	if !reflect.DeepEqual(decoded, expected) {
+		log.Println("these should be deeply equal")
+		log.Println(expected)
+		log.Println(decoded)
+		t.FailNow()
+	}
+
+	book := bindingBook{
+		Title:         "Meu Nome é Maria",
+		Author:        "Maria Viana",
+		CurrentTenant: &bindingPerson{ID: "318.207.920-48"},
+		Genres:        []string{"biography"},
+	}
+	bookAsset, err := assets.Encode(&book)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	tenant, isKey := bookAsset["currentTenant"].(map[string]interface{})
+	if !isKey || tenant["@key"] != "person:47061146-c642-51a1-844a-bf0b17cb5e19" {
+		log.Println("reference should be encoded as a key", bookAsset["currentTenant"])
+		t.FailNow()
+	}
+
+	_, err = assets.Encode(struct {
+		Name string `cctools:"name"`
+	}{"Maria"})
+	if err == nil || err.Status() != 400 {
+		log.Println("expected error encoding unregistered struct")
+		t.FailNow()
+	}
+}
+
+func TestGetAs(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	stub.MockTransactionStart("TestGetAs")
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	personAsset, _ := assets.Encode(bindingPerson{ID: "318.207.920-48", Name: "Maria"})
+	_, err := personAsset.PutNew(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	bookAsset, _ := assets.Encode(bindingBook{
+		Title:         "Meu Nome é Maria",
+		Author:        "Maria Viana",
+		CurrentTenant: &bindingPerson{ID: "318.207.920-48"},
+	})
+	_, err = bookAsset.PutNew(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	libraryAsset, _ := assets.Encode(bindingLibrary{
+		Name:  "Biblioteca Maria da Silva",
+		Books: []assets.Key{{"@assetType": "book", "@key": bookAsset.Key()}},
+	})
+	_, err = libraryAsset.PutNew(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	stub.MockTransactionEnd("TestGetAs")
+
+	bookKey, _ := assets.NewKey(bookAsset)
+	book, err := assets.GetAs[bindingBook](sw, bookKey)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if book.Title != "Meu Nome é Maria" || book.CurrentTenant == nil || book.CurrentTenant.Key != personAsset.Key() {
+		log.Println("unexpected book", book)
+		t.FailNow()
+	}
+
+	libraryKey, _ := assets.NewKey(libraryAsset)
+	library, err := assets.GetAs[bindingLibrary](sw, libraryKey)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if len(library.Books) != 1 || library.Books[0].Key() != bookAsset.Key() || library.Librarian != nil {
+		log.Println("unexpected library", library)
+		t.FailNow()
+	}
+
+	_, err = assets.GetAs[bindingBook](sw, assets.Key{"@assetType": "book", "@key": "book:00000000-0000-0000-0000-000000000000"})
+	if err == nil || err.Status() != 404 {
+		log.Println("expected 404 for missing asset")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,307 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+var readersTestAssetList = []assets.AssetType{
+	{
+		Tag:   "note",
+		Label: "Note",
+		Props: []assets.AssetProp{
+			{
+				Required: true,
+				IsKey:    true,
+				Tag:      "code",
+				Label:    "Code",
+				DataType: "string",
+			},
+			{
+				Tag:      "title",
+				Label:    "Title",
+				DataType: "string",
+			},
+			{
+				Tag:      "content",
+				Label:    "Content",
+				DataType: "string",
+				Readers:  []string{"org2MSP"},
+			},
+			{
+				Tag:           "score",
+				Label:         "Score",
+				DataType:      "number",
+				Readers:       []string{"org2MSP"},
+				ReaderCallers: []accesscontrol.Caller{{MSP: `$org[34]MSP`}},
+			},
+		},
+	},
+	{
+		Tag:   "notebook",
+		Label: "Notebook",
+		Props: []assets.AssetProp{
+			{
+				Required: true,
+				IsKey:    true,
+				Tag:      "name",
+				Label:    "Name",
+				DataType: "string",
+			},
+			{
+				Tag:      "notes",
+				Label:    "Notes",
+				DataType: "[]->note",
+			},
+		},
+	},
+}
+
+func TestCheckReaders(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append(append([]assets.AssetType{}, readersTestAssetList...), testAssetList...))
+
+	noteType := assets.FetchAssetType("note")
+
+	expected := map[string]map[string]bool{
+		"org1MSP": {"title": true, "content": false, "score": false},
+		"org2MSP": {"title": true, "content": true, "score": true},
+		"org3MSP": {"title": true, "content": false, "score": true},
+	}
+	for msp, props := range expected {
+		// The creator identity checked by the reader callers is set when the stub is created
+		sw := &sw.StubWrapper{
+			Stub: mock.NewMockStub(msp, new(testCC)),
+		}
+		for propTag, expectedPermission := range props {
+			permission, err := noteType.GetPropDef(propTag).CheckReaders(sw)
+			if err != nil {
+				log.Println(err)
+				t.FailNow()
+			}
+			if permission != expectedPermission {
+				log.Printf("expected read permission of %s on '%s' to be %v", msp, propTag, expectedPermission)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestRedactReadPaths(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append(append([]assets.AssetType{}, readersTestAssetList...), testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	note := map[string]interface{}{
+		"@assetType": "note",
+		"code":       "N1",
+		"title":      "Groceries",
+		"content":    "Milk",
+		"score":      4.5,
+	}
+	notebook := map[string]interface{}{
+		"@assetType": "notebook",
+		"name":       "Home",
+		"notes": []interface{}{
+			map[string]interface{}{"@assetType": "note", "code": "N1"},
+		},
+	}
+	res := stub.MockInvoke("createNotes", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{note, notebook}}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	noteKey := map[string]interface{}{"@assetType": "note", "code": "N1"}
+	notebookKey := map[string]interface{}{"@assetType": "notebook", "name": "Home"}
+
+	for _, msp := range []string{"org1MSP", "org2MSP"} {
+		stub.Name = msp
+		readable := msp == "org2MSP"
+
+		var readNote map[string]interface{}
+		res = stub.MockInvoke("readNote", [][]byte{
+			[]byte("readAsset"),
+			mustMarshal(map[string]interface{}{"key": noteKey}),
+		})
+		_ = json.Unmarshal(res.GetPayload(), &readNote)
+		if !checkRedacted(readNote, readable) {
+			log.Println("unexpected readAsset response for", msp, readNote)
+			t.FailNow()
+		}
+
+		var readNotebook map[string]interface{}
+		res = stub.MockInvoke("readNotebook", [][]byte{
+			[]byte("readAsset"),
+			mustMarshal(map[string]interface{}{"key": notebookKey, "resolve": true}),
+		})
+		_ = json.Unmarshal(res.GetPayload(), &readNotebook)
+		notes, _ := readNotebook["notes"].([]interface{})
+		if len(notes) != 1 || !checkRedacted(notes[0].(map[string]interface{}), readable) {
+			log.Println("unexpected resolved sub-asset for", msp, readNotebook)
+			t.FailNow()
+		}
+
+		var history []map[string]interface{}
+		res = stub.MockInvoke("readNoteHistory", [][]byte{
+			[]byte("readAssetHistory"),
+			mustMarshal(map[string]interface{}{"key": noteKey}),
+		})
+		_ = json.Unmarshal(res.GetPayload(), &history)
+		if len(history) != 1 || !checkRedacted(history[0], readable) {
+			log.Println("unexpected history for", msp, history)
+			t.FailNow()
+		}
+
+		var search map[string]interface{}
+		res = stub.MockInvoke("searchNotes", [][]byte{
+			[]byte("search"),
+			mustMarshal(map[string]interface{}{
+				"query": map[string]interface{}{
+					"selector": map[string]interface{}{"@assetType": "note"},
+				},
+			}),
+		})
+		_ = json.Unmarshal(res.GetPayload(), &search)
+		results, _ := search["result"].([]interface{})
+		if len(results) != 1 || !checkRedacted(results[0].(map[string]interface{}), readable) {
+			log.Println("unexpected search result for", msp, search)
+			t.FailNow()
+		}
+	}
+}
+
+func TestRedactWriteResponses(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append(append([]assets.AssetType{}, readersTestAssetList...), testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	var created []map[string]interface{}
+	res := stub.MockInvoke("createNote", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{map[string]interface{}{
+			"@assetType": "note",
+			"code":       "N1",
+			"title":      "Groceries",
+			"content":    "Milk",
+			"score":      4.5,
+		}}}),
+	})
+	_ = json.Unmarshal(res.GetPayload(), &created)
+	if res.GetStatus() != 200 || len(created) != 1 || !checkRedacted(created[0], false) {
+		log.Println("unexpected createAsset response", res.GetMessage(), created)
+		t.FailNow()
+	}
+
+	var updated map[string]interface{}
+	res = stub.MockInvoke("updateNote", [][]byte{
+		[]byte("updateAsset"),
+		mustMarshal(map[string]interface{}{"update": map[string]interface{}{"@assetType": "note", "code": "N1", "content": "Eggs"}}),
+	})
+	_ = json.Unmarshal(res.GetPayload(), &updated)
+	if res.GetStatus() != 200 || !checkRedacted(updated, false) {
+		log.Println("unexpected updateAsset response", res.GetMessage(), updated)
+		t.FailNow()
+	}
+
+	var deleted map[string]interface{}
+	res = stub.MockInvoke("deleteNote", [][]byte{
+		[]byte("deleteAsset"),
+		mustMarshal(map[string]interface{}{"key": map[string]interface{}{"@assetType": "note", "code": "N1"}}),
+	})
+	_ = json.Unmarshal(res.GetPayload(), &deleted)
+	if res.GetStatus() != 200 || !checkRedacted(deleted, false) {
+		log.Println("unexpected deleteAsset response", res.GetMessage(), deleted)
+		t.FailNow()
+	}
+}
+
+func TestSearchUnreadableProps(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append(append([]assets.AssetType{}, readersTestAssetList...), testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	search := func(query map[string]interface{}) int32 {
+		res := stub.MockInvoke("searchNotes", [][]byte{
+			[]byte("search"),
+			mustMarshal(map[string]interface{}{"query": query}),
+		})
+		return res.GetStatus()
+	}
+
+	for _, query := range []map[string]interface{}{
+		{"selector": map[string]interface{}{"@assetType": "note", "content": "Milk"}},
+		{"selector": map[string]interface{}{"$or": []interface{}{map[string]interface{}{"score": map[string]interface{}{"$gt": 4}}}}},
+		{"selector": map[string]interface{}{"@assetType": "note"}, "sort": []interface{}{map[string]interface{}{"score": "asc"}}},
+	} {
+		if status := search(query); status != 403 {
+			log.Println("expected query on unreadable props to be refused, got", status, query)
+			t.FailNow()
+		}
+	}
+
+	if status := search(map[string]interface{}{"selector": map[string]interface{}{"@assetType": "note", "title": "Groceries"}}); status != 200 {
+		log.Println("expected query on readable props to succeed, got", status)
+		t.FailNow()
+	}
+
+	stub.Name = "org2MSP"
+	if status := search(map[string]interface{}{"selector": map[string]interface{}{"@assetType": "note", "content": "Milk"}}); status != 200 {
+		log.Println("expected readers to query the prop, got", status)
+		t.FailNow()
+	}
+}
+
+func TestEmptyReadersUpdate(t *testing.T) {
+	prop := readersTestAssetList[0].Props[2]
+	updated, err := assets.HandlePropUpdate(prop, map[string]interface{}{"readers": []interface{}{}})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	stub := &sw.StubWrapper{
+		Stub: mock.NewMockStub("org1MSP", new(testCC)),
+	}
+	readPermission, err := updated.CheckReaders(stub)
+	if err != nil || updated.Readers != nil || !readPermission {
+		log.Println("empty readers should not restrict reading", updated.Readers, err)
+		t.FailNow()
+	}
+}
+
+func TestKeyPropReaders(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+
+	note := readersTestAssetList[0]
+	note.Props = append([]assets.AssetProp{}, note.Props...)
+	note.Props[0].Readers = []string{"org2MSP"}
+	assets.InitAssetList(append([]assets.AssetType{note}, testAssetList...))
+
+	err := assets.StartupCheck()
+	if err == nil || err.Message() != "key property Code of asset note cannot have readers" {
+		log.Println("expected key prop readers to be rejected, got", err)
+		t.FailNow()
+	}
+}
+
+// checkRedacted returns true if the note has the restricted props only when readable
+func checkRedacted(note map[string]interface{}, readable bool) bool {
+	if note == nil || note["title"] != "Groceries" {
+		return false
+	}
+	_, hasContent := note["content"]
+	_, hasScore := note["score"]
+	return hasContent == readable && hasScore == readable
+}
@@ -1,11 +1,21 @@
 package test
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"log"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
 	"github.com/hyperledger-labs/cc-tools/events"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
 )
 
 func TestFetchEvent(t *testing.T) {
@@ -31,3 +41,464 @@ func TestFetchEventList(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+var raiseEventsTestTx = tx.Transaction{
+	Tag:    "raiseEvents",
+	Label:  "Raise Events",
+	Method: "POST",
+	Args: tx.ArgList{
+		{
+			Tag:      "logs",
+			DataType: "[]string",
+			Required: true,
+		},
+		{
+			Tag:      "fail",
+			DataType: "boolean",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		for _, log := range req["logs"].([]interface{}) {
+			err := events.CallEvent(stub, "createLibraryLog", []byte(log.(string)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if fail, _ := req["fail"].(bool); fail {
+			return nil, errors.NewCCError("failed after raising events", 400)
+		}
+		return nil, nil
+	},
+}
+
+func TestEventEnvelope(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), raiseEventsTestTx))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.TransientMap = map[string][]byte{events.EventSaltTransientKey: []byte("secret")}
+	res := stub.MockInvoke("tx1", [][]byte{[]byte("raiseEvents"), mustMarshal(map[string]interface{}{"logs": []string{"first", "second"}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	if len(stub.EmittedEvents) != 1 || stub.EmittedEvents[0].EventName != sw.EventEnvelopeName || stub.EmittedEvents[0].TxId != "tx1" {
+		log.Println("events should be set in a single envelope", stub.EmittedEvents)
+		t.FailNow()
+	}
+
+	// Events raised by failed txs are discarded
+	res = stub.MockInvoke("tx2", [][]byte{[]byte("raiseEvents"), mustMarshal(map[string]interface{}{"logs": []string{"lost"}, "fail": true})})
+	if res.GetStatus() != 400 || len(stub.EmittedEvents) != 1 {
+		log.Println("failed tx should not emit events", res.GetMessage())
+		t.FailNow()
+	}
+
+	// Events raised by failed operations of bestEffort batches are discarded
+	_, status, msg := invokeBatch(stub, map[string]interface{}{
+		"mode": "bestEffort",
+		"operations": []interface{}{
+			map[string]interface{}{"tx": "raiseEvents", "args": map[string]interface{}{"logs": []string{"third"}}},
+			map[string]interface{}{"tx": "raiseEvents", "args": map[string]interface{}{"logs": []string{"discarded"}, "fail": true}},
+			map[string]interface{}{"tx": "raiseEvents", "args": map[string]interface{}{"logs": []string{"fourth"}}},
+		},
+	})
+	if status != 200 {
+		log.Println(msg)
+		t.FailNow()
+	}
+
+	emitted, err := events.DecodeChaincodeEvents(stub.EmittedEvents)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	payloads := []string{}
+	for _, event := range emitted {
+		if event.Tag != "createLibraryLog" {
+			log.Println("unexpected event", event.Tag)
+			t.FailNow()
+		}
+		// The payload is only readable by the receivers of the event
+		var public events.PrivateEventPayload
+		var record events.PrivateEventRecord
+		_ = json.Unmarshal(event.Payload, &public)
+		_ = json.Unmarshal(stub.PvtState[events.ReceiverCollection("org1MSP")][public.Hash], &record)
+		payloads = append(payloads, string(record.Payload))
+	}
+	if !reflect.DeepEqual(payloads, []string{"first", "second", "third", "fourth"}) {
+		log.Println("unexpected events", payloads)
+		t.FailNow()
+	}
+}
+
+var triggerEventTestTx = tx.Transaction{
+	Tag:    "triggerEvent",
+	Label:  "Trigger Event",
+	Method: "POST",
+	Args: tx.ArgList{
+		{
+			Tag:      "eventTag",
+			DataType: "string",
+			Required: true,
+		},
+		{
+			Tag:      "args",
+			DataType: "@object",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		payload, _ := json.Marshal(req["args"])
+		err := events.CallEvent(stub, req["eventTag"].(string), payload)
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	},
+}
+
+var eventTransactionTestEvents = []events.Event{
+	{
+		Tag:         "registerLibrary",
+		Type:        events.EventTransaction,
+		Transaction: "createAsset",
+	},
+	{
+		Tag:         "archiveLibrary",
+		Type:        events.EventTransaction,
+		Transaction: "createAsset",
+		Chaincode:   "archive",
+	},
+	{
+		Tag:         "auditLibrary",
+		Type:        events.EventTransaction,
+		Transaction: "createAsset",
+		Channel:     "audit",
+	},
+	{
+		Tag:         "unknownChaincode",
+		Type:        events.EventTransaction,
+		Transaction: "createAsset",
+		Chaincode:   "unknown",
+	},
+}
+
+func TestEventTransaction(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	defer events.InitEventList(testEventTypeList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), triggerEventTestTx))
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), eventTransactionTestEvents...))
+	checkErr := tx.StartupCheck()
+	if checkErr != nil {
+		log.Println(checkErr)
+		t.FailNow()
+	}
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.ChannelID = "main"
+	archive := mock.NewMockStub("orgArchiveMSP", new(testCC))
+	stub.MockPeerChaincode("archive", archive, "")
+
+	library := func(name string) map[string]interface{} {
+		return map[string]interface{}{"asset": []interface{}{map[string]interface{}{"@assetType": "library", "name": name}}}
+	}
+	libraryKey := func(name string) string {
+		key, _ := assets.NewKey(map[string]interface{}{"@assetType": "library", "name": name})
+		return key.Key()
+	}
+
+	// Transactions on the same chaincode run within the transaction raising the event
+	res := stub.MockInvoke("tx1", [][]byte{[]byte("triggerEvent"), mustMarshal(map[string]interface{}{"eventTag": "registerLibrary", "args": library("Local")})})
+	if res.GetStatus() != 200 || stub.State[libraryKey("Local")] == nil {
+		log.Println("expected library to be created locally", res.GetMessage())
+		t.FailNow()
+	}
+
+	// Transactions on other chaincodes are invoked with the creator of the transaction
+	res = stub.MockInvoke("tx2", [][]byte{[]byte("triggerEvent"), mustMarshal(map[string]interface{}{"eventTag": "archiveLibrary", "args": library("Archived")})})
+	if res.GetStatus() != 200 || archive.State[libraryKey("Archived")] == nil || stub.State[libraryKey("Archived")] != nil {
+		log.Println("expected library to be created in the archive chaincode", res.GetMessage())
+		t.FailNow()
+	}
+	var archived map[string]interface{}
+	_ = json.Unmarshal(archive.State[libraryKey("Archived")], &archived)
+	if archived["@lastTouchBy"] != "org1MSP" {
+		log.Println("invoked chaincode should see the caller", archived)
+		t.FailNow()
+	}
+
+	// The writes of invoked chaincodes are discarded if the transaction fails
+	_, status, _ := invokeBatch(stub, map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"tx": "triggerEvent", "args": map[string]interface{}{"eventTag": "archiveLibrary", "args": library("Discarded")}},
+			map[string]interface{}{"tx": "triggerEvent", "args": map[string]interface{}{"eventTag": "missing"}},
+		},
+	})
+	if status != 400 || archive.State[libraryKey("Discarded")] != nil || archive.TxID != "" {
+		log.Println("expected writes of the invoked chaincode to be discarded", status)
+		t.FailNow()
+	}
+
+	err := invokeAndVerify(stub, "triggerEvent", map[string]interface{}{"eventTag": "unknownChaincode", "args": library("Lost")},
+		"tx createAsset of chaincode unknown triggered by event unknownChaincode failed: chaincode unknown is not registered with MockPeerChaincode", 500)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// Transactions on other channels are relayed
+	res = stub.MockInvoke("tx3", [][]byte{[]byte("triggerEvent"), mustMarshal(map[string]interface{}{"eventTag": "auditLibrary", "args": library("Audited")})})
+	if res.GetStatus() != 200 || stub.State[libraryKey("Audited")] != nil {
+		log.Println("expected library to be relayed", res.GetMessage())
+		t.FailNow()
+	}
+	emitted, err := events.DecodeChaincodeEvent(stub.EmittedEvents[len(stub.EmittedEvents)-1])
+	if err != nil || len(emitted) != 2 || emitted[0].Tag != "auditLibrary" || emitted[1].Tag != events.RelayEventTag {
+		log.Println("expected event and relay event", emitted, err)
+		t.FailNow()
+	}
+	relay, err := events.DecodeRelay(emitted[1].Payload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if relay.Channel != "audit" || relay.SourceChannel != "main" || relay.SourceTxID != "tx3" || relay.Transaction != "createAsset" {
+		log.Printf("unexpected relay %#v", relay)
+		t.FailNow()
+	}
+
+	// Relays can be submitted to the target channel
+	audit := mock.NewMockStub("org1MSP", new(testCC))
+	invokeArgs, err := relay.InvokeArgs()
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	res = audit.MockInvoke("relay1", invokeArgs)
+	if res.GetStatus() != 200 || audit.State[libraryKey("Audited")] == nil {
+		log.Println("expected relayed tx to run", res.GetMessage())
+		t.FailNow()
+	}
+}
+
+var loopEventTestTx = tx.Transaction{
+	Tag:    "loopEvent",
+	Label:  "Loop Event",
+	Method: "POST",
+	Args:   tx.ArgList{},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		err := events.CallEvent(stub, "loop", []byte(`{}`))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	},
+}
+
+func TestEventTransactionLoop(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	defer events.InitEventList(testEventTypeList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), loopEventTestTx))
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), events.Event{
+		Tag:         "loop",
+		Type:        events.EventTransaction,
+		Transaction: "loopEvent",
+	}))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	res := stub.MockInvoke("tx1", [][]byte{[]byte("loopEvent"), []byte(`{}`)})
+	if res.GetStatus() != 400 || !strings.Contains(res.GetMessage(), "exceeds the maximum of 8 nested event transactions") {
+		log.Println("expected recursive event transactions to be stopped, got", res.GetStatus(), res.GetMessage())
+		t.FailNow()
+	}
+}
+
+func TestEventTransactionStartupCheck(t *testing.T) {
+	defer events.InitEventList(testEventTypeList)
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), events.Event{
+		Tag:         "missingTx",
+		Type:        events.EventTransaction,
+		Transaction: "missing",
+	}))
+
+	err := tx.StartupCheck()
+	if err == nil || err.Message() != "tx missing of event missingTx does not exist" {
+		log.Println("expected startup check to fail", err)
+		t.FailNow()
+	}
+}
+
+func TestDiscardEvents(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.MockTransactionStart("tx1")
+	wrapper := &sw.StubWrapper{Stub: stub}
+	_ = wrapper.SetEvent("first", []byte("1"))
+	_ = wrapper.SetEvent("second", []byte("2"))
+
+	// Events raised by failed transactions are discarded instead of flushed
+	if discarded := wrapper.DiscardEvents(); discarded != 2 {
+		log.Println("expected 2 discarded events, got", discarded)
+		t.FailNow()
+	}
+	err := wrapper.Flush()
+	stub.MockTransactionEnd("tx1")
+	if err != nil || len(stub.EmittedEvents) != 0 {
+		log.Println("discarded events should not be emitted", err, stub.EmittedEvents)
+		t.FailNow()
+	}
+}
+
+var paymentNoticeTestEvent = events.Event{
+	Tag:       "paymentNotice",
+	Type:      events.EventCustom,
+	Receivers: []string{"org2MSP", "$org3MSP"},
+	Args: []events.Arg{
+		{
+			Tag:      "amount",
+			DataType: "number",
+			Required: true,
+		},
+		{
+			Tag:      "payer",
+			DataType: "string",
+		},
+	},
+	CustomFunction: func(stub *sw.StubWrapper, payload []byte) error {
+		return stub.PutState("lastNotice", payload)
+	},
+}
+
+func TestEventReceivers(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	defer events.InitEventList(testEventTypeList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), triggerEventTestTx))
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), paymentNoticeTestEvent))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	notice := map[string]interface{}{"amount": 10.5, "payer": "org1MSP"}
+	triggerArgs := [][]byte{[]byte("triggerEvent"), mustMarshal(map[string]interface{}{"eventTag": "paymentNotice", "args": notice})}
+
+	// The salt of the hash must not be derivable from the public transaction
+	res := stub.MockInvoke("tx0", triggerArgs)
+	if res.GetStatus() != 400 || res.GetMessage() != "transient field @eventSalt is required to raise event paymentNotice with receivers" {
+		log.Println("expected event without salt to be refused", res.GetStatus(), res.GetMessage())
+		t.FailNow()
+	}
+
+	stub.TransientMap = map[string][]byte{events.EventSaltTransientKey: []byte("secret")}
+	res = stub.MockInvoke("tx1", triggerArgs)
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	// Only the hash of the payload is public
+	emitted, err := events.DecodeChaincodeEvents(stub.EmittedEvents)
+	if err != nil || len(emitted) != 1 {
+		log.Println("expected a single event", emitted, err)
+		t.FailNow()
+	}
+	var public events.PrivateEventPayload
+	err = json.Unmarshal(emitted[0].Payload, &public)
+	if err != nil || public.Hash == "" || !reflect.DeepEqual(public.Receivers, []string{"org2MSP", "org3MSP"}) {
+		log.Println("unexpected public payload", string(emitted[0].Payload))
+		t.FailNow()
+	}
+
+	// The payload is written to the implicit collection of each receiver, along with the salt of its hash
+	unsalted := sha256.Sum256(mustMarshal(notice))
+	if public.Hash == hex.EncodeToString(unsalted[:]) {
+		log.Println("the hash of the payload should be salted")
+		t.FailNow()
+	}
+	for _, msp := range []string{"org2MSP", "org3MSP"} {
+		var record events.PrivateEventRecord
+		_ = json.Unmarshal(stub.PvtState[events.ReceiverCollection(msp)][public.Hash], &record)
+		salt, _ := hex.DecodeString(record.Salt)
+		salted := sha256.Sum256(append(salt, record.Payload...))
+		if string(record.Payload) != string(mustMarshal(notice)) || hex.EncodeToString(salted[:]) != public.Hash {
+			log.Println("receiver should have the payload and its salt", msp, record)
+			t.FailNow()
+		}
+	}
+	if stub.PvtState[events.ReceiverCollection("org1MSP")] != nil {
+		log.Println("only receivers should have the payload")
+		t.FailNow()
+	}
+
+	stub.MockTransactionStart("read")
+	payload, readErr := events.GetPrivatePayload(&sw.StubWrapper{Stub: stub}, "org2MSP", emitted[0].Payload)
+	stub.MockTransactionEnd("read")
+	if readErr != nil || string(payload) != string(mustMarshal(notice)) {
+		log.Println("receiver should read the payload", readErr)
+		t.FailNow()
+	}
+}
+
+func TestEventPayloadSchema(t *testing.T) {
+	defer events.InitEventList(testEventTypeList)
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), paymentNoticeTestEvent))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	run := func(txName string, payload interface{}) (int32, string) {
+		req := map[string]interface{}{
+			"eventTag": "paymentNotice",
+			"payload":  base64.StdEncoding.EncodeToString(mustMarshal(payload)),
+		}
+		res := stub.MockInvoke(txName, [][]byte{[]byte(txName), mustMarshal(req)})
+		return res.GetStatus(), res.GetMessage()
+	}
+
+	for _, txName := range []string{"executeEvent", "runEvent"} {
+		status, msg := run(txName, map[string]interface{}{"payer": "org1MSP"})
+		if status != 400 || msg != "invalid event payload: missing argument 'amount'" {
+			log.Println("expected missing amount to be refused", txName, status, msg)
+			t.FailNow()
+		}
+		status, _ = run(txName, map[string]interface{}{"amount": "ten"})
+		if status != 400 {
+			log.Println("expected invalid amount to be refused", txName, status)
+			t.FailNow()
+		}
+		status, _ = run(txName, []interface{}{10})
+		if status != 400 {
+			log.Println("expected non object payload to be refused", txName, status)
+			t.FailNow()
+		}
+		status, msg = run(txName, map[string]interface{}{"amount": 10})
+		if status != 200 || string(stub.State["lastNotice"]) != `{"amount":10}` {
+			log.Println("expected valid payload to run the custom function", txName, status, msg)
+			t.FailNow()
+		}
+		delete(stub.State, "lastNotice")
+	}
+}
+
+func TestEventStartupCheck(t *testing.T) {
+	defer events.InitEventList(testEventTypeList)
+
+	invalidReceivers := paymentNoticeTestEvent
+	invalidReceivers.Receivers = []string{`$org\dMSP`}
+	invalidArgs := paymentNoticeTestEvent
+	invalidArgs.Args = []events.Arg{{Tag: "amount", DataType: "money"}}
+	relayedReceivers := paymentNoticeTestEvent
+	relayedReceivers.Type = events.EventTransaction
+	relayedReceivers.Transaction = "registerPayment"
+	relayedReceivers.Channel = "payments"
+
+	for _, c := range []struct {
+		event events.Event
+		msg   string
+	}{
+		{invalidReceivers, `receiver $org\dMSP of event paymentNotice must match a single msp`},
+		{invalidArgs, "invalid arg type money in event paymentNotice"},
+		{relayedReceivers, "event paymentNotice with receivers cannot relay tx registerPayment to channel payments"},
+	} {
+		events.InitEventList(append(append([]events.Event{}, testEventTypeList...), c.event))
+		err := tx.StartupCheck()
+		if err == nil || err.Message() != c.msg {
+			log.Println("expected startup check to fail with", c.msg, err)
+			t.FailNow()
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"log"
 	"reflect"
@@ -328,6 +329,74 @@ func TestGetRecursiveWithPvtData(t *testing.T) {
 	stub.MockTransactionEnd("TestGetAsset")
 }
 
+func TestGetRecursiveWithPvtDataHash(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.CollectionMembers["secret"] = []string{"org2MSP", "org3MSP"}
+
+	// State setup
+	setupSecret := map[string]interface{}{
+		"@assetType":   "secret",
+		"@key":         "secret:73a3f9a7-eb91-5f4d-b1bb-c0487e90f40b",
+		"@lastTouchBy": "org2MSP",
+		"@lastTx":      "createAsset",
+		"secretName":   "testSecret",
+		"secret":       "this is very secret",
+	}
+	setupLibrary := map[string]interface{}{
+		"@assetType":   "library",
+		"@key":         "library:37262f3f-5f08-5649-b488-e5abaad266e1",
+		"@lastTouchBy": "org3MSP",
+		"@lastTx":      "createAsset",
+		"name":         "Biblioteca Maria da Silva",
+		"entranceCode": map[string]interface{}{
+			"@assetType": "secret",
+			"@key":       "secret:73a3f9a7-eb91-5f4d-b1bb-c0487e90f40b",
+		},
+	}
+	setupSecretJSON, _ := json.Marshal(setupSecret)
+	setupLibraryJSON, _ := json.Marshal(setupLibrary)
+
+	stub.MockTransactionStart("setupReadAsset")
+	stub.PutPrivateData("secret", "secret:73a3f9a7-eb91-5f4d-b1bb-c0487e90f40b", setupSecretJSON)
+	stub.PutState("library:37262f3f-5f08-5649-b488-e5abaad266e1", setupLibraryJSON)
+	stub.MockTransactionEnd("setupReadAsset")
+
+	libraryKey := assets.Key{
+		"@assetType": "library",
+		"@key":       "library:37262f3f-5f08-5649-b488-e5abaad266e1",
+	}
+	secretHash := sha256.Sum256(setupSecretJSON)
+	expectedResponse := map[string]interface{}{
+		"@assetType":   "library",
+		"@key":         "library:37262f3f-5f08-5649-b488-e5abaad266e1",
+		"@lastTouchBy": "org3MSP",
+		"@lastTx":      "createAsset",
+		"name":         "Biblioteca Maria da Silva",
+		"entranceCode": map[string]interface{}{
+			"@assetType": "secret",
+			"@key":       "secret:73a3f9a7-eb91-5f4d-b1bb-c0487e90f40b",
+			"@hash":      secretHash[:],
+		},
+	}
+
+	stub.MockTransactionStart("TestGetRecursiveWithPvtDataHash")
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	gotAsset, err := libraryKey.GetRecursive(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(gotAsset, expectedResponse) {
+		log.Println("these should be deeply equal")
+		log.Println(expectedResponse)
+		log.Println(gotAsset)
+		t.FailNow()
+	}
+	stub.MockTransactionEnd("TestGetRecursiveWithPvtDataHash")
+}
+
 func TestGetAssetNoKey(t *testing.T) {
 	stub := mock.NewMockStub("org1MSP", new(testCC))
 
@@ -0,0 +1,249 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+var hybridTestAssetType = assets.AssetType{
+	Tag:   "patient",
+	Label: "Patient",
+	Props: []assets.AssetProp{
+		{
+			Required: true,
+			IsKey:    true,
+			Tag:      "id",
+			Label:    "ID",
+			DataType: "string",
+		},
+		{
+			Tag:      "name",
+			Label:    "Name",
+			DataType: "string",
+		},
+		{
+			Tag:        "diagnosis",
+			Label:      "Diagnosis",
+			DataType:   "string",
+			Collection: "clinicCollection",
+		},
+		{
+			Tag:        "insurer",
+			Label:      "Insurer",
+			DataType:   "string",
+			Collection: "billingCollection",
+		},
+	},
+}
+
+func TestHybridAsset(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{hybridTestAssetType}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.CollectionMembers["clinicCollection"] = []string{"org1MSP"}
+
+	patient := map[string]interface{}{
+		"@assetType": "patient",
+		"id":         "P1",
+		"name":       "Maria",
+		"diagnosis":  "Flu",
+		"insurer":    "Acme",
+	}
+	var created []map[string]interface{}
+	res := stub.MockInvoke("createPatient", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{patient}}),
+	})
+	_ = json.Unmarshal(res.GetPayload(), &created)
+	if res.GetStatus() != 200 || len(created) != 1 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	if _, hasDiagnosis := created[0]["diagnosis"]; hasDiagnosis || created[0]["name"] != "Maria" {
+		log.Println("response should only have the public props", created[0])
+		t.FailNow()
+	}
+	key := created[0]["@key"].(string)
+
+	// Private props are stored in their collections under the same key
+	var public, clinic, billing map[string]interface{}
+	_ = json.Unmarshal(stub.State[key], &public)
+	_ = json.Unmarshal(stub.PvtState["clinicCollection"][key], &clinic)
+	_ = json.Unmarshal(stub.PvtState["billingCollection"][key], &billing)
+	if _, hasDiagnosis := public["diagnosis"]; hasDiagnosis || public["name"] != "Maria" {
+		log.Println("unexpected public state", public)
+		t.FailNow()
+	}
+	if clinic["diagnosis"] != "Flu" || clinic["@key"] != key || billing["insurer"] != "Acme" {
+		log.Println("unexpected private state", clinic, billing)
+		t.FailNow()
+	}
+
+	patientKey := map[string]interface{}{"@assetType": "patient", "id": "P1"}
+	read := func(resolve bool) map[string]interface{} {
+		var asset map[string]interface{}
+		res := stub.MockInvoke("readPatient", [][]byte{
+			[]byte("readAsset"),
+			mustMarshal(map[string]interface{}{"key": patientKey, "resolve": resolve}),
+		})
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+			return nil
+		}
+		_ = json.Unmarshal(res.GetPayload(), &asset)
+		return asset
+	}
+
+	// Members get the merged asset
+	for _, resolve := range []bool{false, true} {
+		asset := read(resolve)
+		if asset["name"] != "Maria" || asset["diagnosis"] != "Flu" || asset["insurer"] != "Acme" || asset["@privateHashes"] != nil {
+			log.Println("members should read the whole asset", asset)
+			t.FailNow()
+		}
+	}
+
+	// Non-members get the public part plus the hashes of the private parts they cannot read
+	stub.Name = "org2MSP"
+	for _, resolve := range []bool{false, true} {
+		asset := read(resolve)
+		hashes, _ := asset["@privateHashes"].(map[string]interface{})
+		if _, hasDiagnosis := asset["diagnosis"]; hasDiagnosis || asset["name"] != "Maria" || asset["insurer"] != "Acme" || hashes["clinicCollection"] == nil {
+			log.Println("non-members should read the public part and the hashes", asset)
+			t.FailNow()
+		}
+	}
+
+	var search map[string]interface{}
+	res = stub.MockInvoke("searchPatients", [][]byte{
+		[]byte("search"),
+		mustMarshal(map[string]interface{}{
+			"query": map[string]interface{}{
+				"selector": map[string]interface{}{"@assetType": "patient"},
+			},
+		}),
+	})
+	_ = json.Unmarshal(res.GetPayload(), &search)
+	results, _ := search["result"].([]interface{})
+	if len(results) != 1 || results[0].(map[string]interface{})["insurer"] != "Acme" || results[0].(map[string]interface{})["@privateHashes"] == nil {
+		log.Println("unexpected search result", search)
+		t.FailNow()
+	}
+
+	// Non-members updating public props keep the private props
+	res = stub.MockInvoke("updatePatientName", [][]byte{
+		[]byte("updateAsset"),
+		mustMarshal(map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1", "name": "Maria Silva"}}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	_ = json.Unmarshal(stub.State[key], &public)
+	_ = json.Unmarshal(stub.PvtState["clinicCollection"][key], &clinic)
+	if public["name"] != "Maria Silva" || public["@privateHashes"] != nil || clinic["diagnosis"] != "Flu" {
+		log.Println("unexpected state after update", public, clinic)
+		t.FailNow()
+	}
+
+	stub.Name = "org1MSP"
+	res = stub.MockInvoke("updatePatientDiagnosis", [][]byte{
+		[]byte("updateAsset"),
+		mustMarshal(map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1", "diagnosis": "Cold"}}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	_ = json.Unmarshal(stub.PvtState["clinicCollection"][key], &clinic)
+	if asset := read(false); clinic["diagnosis"] != "Cold" || asset["diagnosis"] != "Cold" || asset["name"] != "Maria Silva" {
+		log.Println("unexpected state after update", clinic, asset)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("deletePatient", [][]byte{
+		[]byte("deleteAsset"),
+		mustMarshal(map[string]interface{}{"key": patientKey}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	if stub.State[key] != nil || stub.PvtState["clinicCollection"][key] != nil || stub.PvtState["billingCollection"][key] != nil {
+		log.Println("all parts of the asset should be deleted")
+		t.FailNow()
+	}
+}
+
+func TestHybridKeyCollection(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+
+	patient := hybridTestAssetType
+	patient.Props = append([]assets.AssetProp{}, patient.Props...)
+	patient.Props[0].Collection = "clinicCollection"
+	assets.InitAssetList(append([]assets.AssetType{patient}, testAssetList...))
+
+	err := assets.StartupCheck()
+	if err == nil || err.Message() != "key property ID of asset patient cannot have a collection" {
+		log.Println("expected key prop collection to be rejected, got", err)
+		t.FailNow()
+	}
+}
+
+func TestHybridNonMemberUpdate(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+
+	patient := hybridTestAssetType
+	patient.Props = append([]assets.AssetProp{}, patient.Props...)
+	patient.Props[2].DefaultValue = "Unknown"
+	assets.InitAssetList(append([]assets.AssetType{patient}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.CollectionMembers["clinicCollection"] = []string{"org1MSP"}
+	res := stub.MockInvoke("createPatient", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{
+			map[string]interface{}{"@assetType": "patient", "id": "P1", "name": "Maria", "diagnosis": "Flu"},
+		}}),
+	})
+	var created []map[string]interface{}
+	_ = json.Unmarshal(res.GetPayload(), &created)
+	if res.GetStatus() != 200 || len(created) != 1 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	key := created[0]["@key"].(string)
+	clinicPart := string(stub.PvtState["clinicCollection"][key])
+
+	// Props of collections the caller cannot read are not default-filled nor written
+	stub.Name = "org2MSP"
+	res = stub.MockInvoke("updatePatientName", [][]byte{
+		[]byte("updateAsset"),
+		mustMarshal(map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1", "name": "Maria Silva"}}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	var public, clinic map[string]interface{}
+	_ = json.Unmarshal(stub.State[key], &public)
+	_ = json.Unmarshal(stub.PvtState["clinicCollection"][key], &clinic)
+	if public["name"] != "Maria Silva" || clinic["diagnosis"] != "Flu" || string(stub.PvtState["clinicCollection"][key]) != clinicPart {
+		log.Println("unexpected state after non-member update", public, clinic)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("updatePatientDiagnosis", [][]byte{
+		[]byte("updateAsset"),
+		mustMarshal(map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1", "diagnosis": "Cold"}}),
+	})
+	if res.GetStatus() != 403 {
+		log.Println("expected non-member update of private prop to fail, got", res.GetStatus(), res.GetMessage())
+		t.FailNow()
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+)
+
+func toJSONValue(v interface{}) interface{} {
+	var out interface{}
+	vJSON, _ := json.Marshal(v)
+	json.Unmarshal(vJSON, &out)
+	return out
+}
+
+func TestAssetTypeJSONSchema(t *testing.T) {
+	schema := assets.FetchAssetType("book").JSONSchema()
+
+	readOnlyString := map[string]interface{}{"type": "string", "readOnly": true}
+	expectedSchema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Book",
+		"description": "Book",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"@assetType":   map[string]interface{}{"const": "book"},
+			"@key":         readOnlyString,
+			"@lastTouchBy": readOnlyString,
+			"@lastTx":      readOnlyString,
+			"@lastUpdated": map[string]interface{}{"type": "string", "format": "date-time", "readOnly": true},
+			"title":        map[string]interface{}{"type": "string", "title": "Book Title"},
+			"author":       map[string]interface{}{"type": "string", "title": "Book Author"},
+			"currentTenant": map[string]interface{}{
+				"$ref":  "#/$defs/person.key",
+				"title": "Current Tenant",
+			},
+			"genres": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+				"title": "Genres",
+			},
+			"published": map[string]interface{}{"type": "string", "format": "date-time", "title": "Publishment Date"},
+		},
+		"required": []interface{}{"@assetType", "title", "author"},
+		"$defs": map[string]interface{}{
+			"person.key": map[string]interface{}{
+				"title": "Person key",
+				"type":  "object",
+				"properties": map[string]interface{}{
+					"@assetType": map[string]interface{}{"const": "person"},
+					"@key":       map[string]interface{}{"type": "string"},
+					"id":         map[string]interface{}{"title": "CPF (Brazilian ID)"},
+				},
+				"anyOf": []interface{}{
+					map[string]interface{}{"required": []interface{}{"@key"}},
+					map[string]interface{}{"required": []interface{}{"id"}},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(toJSONValue(schema), expectedSchema) {
+		log.Println("these should be deeply equal")
+		log.Println(toJSONValue(expectedSchema))
+		log.Println(toJSONValue(schema))
+		t.FailNow()
+	}
+}
+
+func TestDataTypeJSONSchema(t *testing.T) {
+	defs := map[string]interface{}{}
+
+	tests := map[string]map[string]interface{}{
+		"integer":  {"type": "integer"},
+		"@object":  {"type": "object"},
+		"[]number": {"type": "array", "items": map[string]interface{}{"type": "number"}},
+		"language": {"type": "string", "description": "Language of a book", "enum": []interface{}{"en", "pt"}},
+		"->@asset": {"$ref": "#/$defs/@asset.key"},
+	}
+	for dataType, expected := range tests {
+		schema := assets.DataTypeJSONSchema(dataType, defs)
+		if !reflect.DeepEqual(toJSONValue(schema), toJSONValue(expected)) {
+			log.Println("unexpected schema for", dataType, schema)
+			t.FailNow()
+		}
+	}
+
+	if _, defined := defs["@asset.key"]; !defined {
+		log.Println("generic reference should be defined", defs)
+		t.FailNow()
+	}
+}
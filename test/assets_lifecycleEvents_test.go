@@ -0,0 +1,232 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/events"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+var lifecycleTestAssetType = assets.AssetType{
+	Tag:             "ticket",
+	Label:           "Ticket",
+	LifecycleEvents: true,
+	Props: []assets.AssetProp{
+		{
+			Required: true,
+			IsKey:    true,
+			Tag:      "id",
+			Label:    "ID",
+			DataType: "string",
+		},
+		{
+			Tag:      "title",
+			Label:    "Title",
+			DataType: "string",
+		},
+		{
+			Tag:      "priority",
+			Label:    "Priority",
+			DataType: "number",
+		},
+	},
+}
+
+// nextLifecycleEvent returns the only event raised by the last tx of the stub
+func nextLifecycleEvent(t *testing.T, stub *mock.MockStub, name string) assets.LifecycleEventPayload {
+	if len(stub.ChaincodeEventsChannel) != 1 {
+		log.Println("expected a single chaincode event, got", len(stub.ChaincodeEventsChannel))
+		t.FailNow()
+	}
+	emitted, err := events.DecodeChaincodeEvent(<-stub.ChaincodeEventsChannel)
+	if err != nil || len(emitted) != 1 || emitted[0].Tag != name {
+		log.Println("expected event", name, "got", emitted, err)
+		t.FailNow()
+	}
+
+	var payload assets.LifecycleEventPayload
+	err = json.Unmarshal(emitted[0].Payload, &payload)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	return payload
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{lifecycleTestAssetType}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	ticket := map[string]interface{}{
+		"@assetType": "ticket",
+		"id":         "T1",
+		"title":      "Broken printer",
+		"priority":   2,
+	}
+	res := stub.MockInvoke("createTicket", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{ticket}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	created := nextLifecycleEvent(t, stub, assets.AssetCreatedEvent)
+	if created.AssetType != "ticket" || created.TxID != "createTicket" || created.MSP != "org1MSP" || created.Old != nil ||
+		!reflect.DeepEqual(created.Changed, []string{"id", "priority", "title"}) || created.New["title"] != "Broken printer" {
+		log.Printf("unexpected created event %#v", created)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("updateTicket", [][]byte{[]byte("updateAsset"), mustMarshal(map[string]interface{}{
+		"update": map[string]interface{}{"@key": created.Key, "priority": 2, "title": "Broken scanner"},
+	})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	updated := nextLifecycleEvent(t, stub, assets.AssetUpdatedEvent)
+	if updated.Key != created.Key || !reflect.DeepEqual(updated.Changed, []string{"title"}) ||
+		updated.Old["title"] != "Broken printer" || updated.New["title"] != "Broken scanner" {
+		log.Printf("unexpected updated event %#v", updated)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("deleteTicket", [][]byte{[]byte("deleteAsset"), mustMarshal(map[string]interface{}{
+		"key": map[string]interface{}{"@key": created.Key},
+	})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	deleted := nextLifecycleEvent(t, stub, assets.AssetDeletedEvent)
+	if deleted.New != nil || deleted.Old["title"] != "Broken scanner" || !reflect.DeepEqual(deleted.Changed, []string{"id", "priority", "title"}) {
+		log.Printf("unexpected deleted event %#v", deleted)
+		t.FailNow()
+	}
+
+	// Asset types without lifecycle events emit nothing
+	res = stub.MockInvoke("createPerson", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{
+		map[string]interface{}{"@assetType": "person", "name": "Maria", "id": "31820792048"},
+	}})})
+	if res.GetStatus() != 200 || len(stub.ChaincodeEventsChannel) != 0 {
+		log.Println("unexpected event", res.GetMessage())
+		t.FailNow()
+	}
+}
+
+func TestLifecycleEventsRedacted(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	patientType := hybridTestAssetType
+	patientType.LifecycleEvents = true
+	assets.InitAssetList(append([]assets.AssetType{patientType}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.CollectionMembers["clinicCollection"] = []string{"org1MSP"}
+	res := stub.MockInvoke("createPatient", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{
+		map[string]interface{}{"@assetType": "patient", "id": "P1", "name": "Maria", "diagnosis": "Flu"},
+	}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	created := nextLifecycleEvent(t, stub, assets.AssetCreatedEvent)
+	if _, hasDiagnosis := created.New["diagnosis"]; hasDiagnosis || !created.Redacted || created.New["name"] != "Maria" ||
+		!reflect.DeepEqual(created.Changed, []string{"diagnosis", "id", "name"}) {
+		log.Printf("private props should be redacted %#v", created)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("updatePatient", [][]byte{[]byte("updateAsset"), mustMarshal(map[string]interface{}{
+		"update": map[string]interface{}{"@key": created.Key, "diagnosis": "Cold"},
+	})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	updated := nextLifecycleEvent(t, stub, assets.AssetUpdatedEvent)
+	if _, hasDiagnosis := updated.Old["diagnosis"]; hasDiagnosis || !reflect.DeepEqual(updated.Changed, []string{"diagnosis"}) {
+		log.Printf("private props should be redacted %#v", updated)
+		t.FailNow()
+	}
+}
+
+func TestLifecycleEventsPut(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{lifecycleTestAssetType}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	put := func(txID string, ticket map[string]interface{}) {
+		stub.MockTransactionStart(txID)
+		defer stub.MockTransactionEnd(txID)
+		sw := &sw.StubWrapper{
+			Stub: stub,
+		}
+		_, err := assets.PutRecursive(sw, ticket)
+		if err == nil {
+			err = sw.Flush()
+		}
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+	}
+
+	// Upserts emit assetCreated or assetUpdated depending on whether the asset existed
+	put("putTicket", map[string]interface{}{"@assetType": "ticket", "id": "T1", "title": "Broken printer"})
+	created := nextLifecycleEvent(t, stub, assets.AssetCreatedEvent)
+	if created.Old != nil || created.New["title"] != "Broken printer" {
+		log.Printf("unexpected created event %#v", created)
+		t.FailNow()
+	}
+
+	put("putTicketAgain", map[string]interface{}{"@assetType": "ticket", "id": "T1", "title": "Broken scanner"})
+	updated := nextLifecycleEvent(t, stub, assets.AssetUpdatedEvent)
+	if updated.Old["title"] != "Broken printer" || updated.New["title"] != "Broken scanner" || !reflect.DeepEqual(updated.Changed, []string{"title"}) {
+		log.Printf("unexpected updated event %#v", updated)
+		t.FailNow()
+	}
+}
+
+func TestLifecycleEventsTransferOwnership(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	vehicleType := ownerTestAssetType
+	vehicleType.LifecycleEvents = true
+	assets.InitAssetList(append([]assets.AssetType{vehicleType}, testAssetList...))
+
+	alice := newTestCertStub(t, "alice", "org1MSP", "client", nil)
+	bobID, _ := (&sw.StubWrapper{Stub: newTestCertStub(t, "bob", "org1MSP", "client", nil)}).GetID()
+
+	vehicle := map[string]interface{}{
+		"@assetType": "vehicle",
+		"plate":      "ABC1234",
+		"color":      "red",
+	}
+	res := alice.MockInvoke("createVehicle", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{vehicle}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	nextLifecycleEvent(t, alice, assets.AssetCreatedEvent)
+
+	transfer := map[string]interface{}{
+		"key":   map[string]interface{}{"@assetType": "vehicle", "plate": "ABC1234"},
+		"owner": map[string]interface{}{"msp": "org1MSP", "id": bobID},
+	}
+	res = alice.MockInvoke("transferVehicle", [][]byte{[]byte("transferOwnership"), mustMarshal(transfer)})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	// Ownership transfers are updates of the owner
+	transferred := nextLifecycleEvent(t, alice, assets.AssetUpdatedEvent)
+	newOwner, _ := transferred.New["@owner"].(map[string]interface{})
+	if !reflect.DeepEqual(transferred.Changed, []string{"@owner"}) || newOwner["id"] != bobID || transferred.Old["@owner"] == nil {
+		log.Printf("unexpected transfer event %#v", transferred)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+var ownerTestAssetType = assets.AssetType{
+	Tag:   "vehicle",
+	Label: "Vehicle",
+	Owned: true,
+	OwnerDelegates: []accesscontrol.Caller{
+		{MSP: "org3MSP"},
+	},
+	Props: []assets.AssetProp{
+		{
+			Required: true,
+			IsKey:    true,
+			Tag:      "plate",
+			Label:    "Plate",
+			DataType: "string",
+		},
+		{
+			Tag:      "color",
+			Label:    "Color",
+			DataType: "string",
+		},
+	},
+}
+
+func TestOwnedAsset(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{ownerTestAssetType}, testAssetList...))
+
+	alice := newTestCertStub(t, "alice", "org1MSP", "client", nil)
+	bob := newTestCertStub(t, "bob", "org1MSP", "client", nil)
+	aliceID, _ := (&sw.StubWrapper{Stub: alice}).GetID()
+	bobID, _ := (&sw.StubWrapper{Stub: bob}).GetID()
+
+	// Owners given by the caller are ignored
+	vehicle := map[string]interface{}{
+		"@assetType": "vehicle",
+		"plate":      "ABC1234",
+		"color":      "red",
+		"@owner":     map[string]interface{}{"msp": "org1MSP", "id": bobID},
+	}
+	res := alice.MockInvoke("createVehicle", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{vehicle}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	key, _ := assets.NewKey(map[string]interface{}{"@assetType": "vehicle", "plate": "ABC1234"})
+	state := alice.State
+	var stored map[string]interface{}
+	_ = json.Unmarshal(state[key.Key()], &stored)
+	if owner := assets.Asset(stored).Owner(); owner["msp"] != "org1MSP" || owner["id"] != aliceID {
+		log.Println("creator should be recorded as the owner", stored)
+		t.FailNow()
+	}
+
+	invoke := func(stub *mock.MockStub, txName string, req map[string]interface{}) int32 {
+		stub.State = state
+		res := stub.MockInvoke(txName, [][]byte{[]byte(txName), mustMarshal(req)})
+		return res.GetStatus()
+	}
+	repaint := func(color string) map[string]interface{} {
+		return map[string]interface{}{"update": map[string]interface{}{"@assetType": "vehicle", "plate": "ABC1234", "color": color}}
+	}
+
+	for _, caller := range []struct {
+		name   string
+		stub   *mock.MockStub
+		status int32
+	}{
+		{"another org member", bob, 403},
+		{"owner", alice, 200},
+		{"owner org admin", newTestCertStub(t, "carol", "org1MSP", "admin", nil), 200},
+		{"another org admin", newTestCertStub(t, "dave", "org2MSP", "admin", nil), 403},
+		{"delegate", newTestCertStub(t, "erin", "org3MSP", "client", nil), 200},
+	} {
+		if status := invoke(caller.stub, "updateAsset", repaint("blue")); status != caller.status {
+			log.Printf("expected update by %s to return %d, got %d", caller.name, caller.status, status)
+			t.FailNow()
+		}
+	}
+
+	if status := invoke(bob, "createAsset", map[string]interface{}{"asset": []interface{}{vehicle}}); status != 409 {
+		log.Println("expected create over existing asset to fail, got", status)
+		t.FailNow()
+	}
+
+	transfer := map[string]interface{}{
+		"key":   map[string]interface{}{"@assetType": "vehicle", "plate": "ABC1234"},
+		"owner": map[string]interface{}{"msp": "org1MSP", "id": bobID},
+	}
+	if status := invoke(bob, "transferOwnership", transfer); status != 403 {
+		log.Println("expected transfer by non-owner to fail, got", status)
+		t.FailNow()
+	}
+	if status := invoke(newTestCertStub(t, "erin", "org3MSP", "client", nil), "transferOwnership", transfer); status != 403 {
+		log.Println("expected transfer by delegate to fail, got", status)
+		t.FailNow()
+	}
+	if status := invoke(alice, "transferOwnership", transfer); status != 200 {
+		log.Println("expected transfer by owner to succeed, got", status)
+		t.FailNow()
+	}
+
+	_ = json.Unmarshal(state[key.Key()], &stored)
+	if owner := assets.Asset(stored).Owner(); owner["id"] != bobID || stored["color"] != "blue" {
+		log.Println("ownership should be transferred", stored)
+		t.FailNow()
+	}
+
+	deleteReq := map[string]interface{}{"key": map[string]interface{}{"@assetType": "vehicle", "plate": "ABC1234"}}
+	if status := invoke(alice, "deleteAsset", deleteReq); status != 403 {
+		log.Println("expected delete by previous owner to fail, got", status)
+		t.FailNow()
+	}
+	if status := invoke(bob, "deleteAsset", deleteReq); status != 200 {
+		log.Println("expected delete by owner to succeed, got", status)
+		t.FailNow()
+	}
+}
+
+func TestTransferOwnershipNotOwned(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	person := map[string]interface{}{
+		"@assetType": "person",
+		"id":         "31820792048",
+		"name":       "Maria",
+	}
+	res := stub.MockInvoke("createPerson", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{person}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	req := map[string]interface{}{
+		"key":   map[string]interface{}{"@assetType": "person", "id": "31820792048"},
+		"owner": map[string]interface{}{"msp": "org2MSP", "id": "someone"},
+	}
+	err := invokeAndVerify(stub, "transferOwnership", req, "failed to transfer ownership: assets of type person do not have owners", 400)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+}
@@ -110,6 +110,8 @@ func TestPutAssetWithSubAsset(t *testing.T) {
 		t.FailNow()
 	}
 
+	stub.MockTransactionEnd("TestPutAsset")
+
 	stateJSON := stub.State["book:a36a2920-c405-51c3-b584-dcd758338cb5"]
 	var state map[string]interface{}
 	err = json.Unmarshal(stateJSON, &state)
@@ -204,6 +206,8 @@ func TestPutNewAssetRecursive(t *testing.T) {
 		"published": "2019-05-06T22:12:41Z",
 	}
 
+	stub.MockTransactionEnd("TestPutAsset")
+
 	stateJSON := stub.State["book:a36a2920-c405-51c3-b584-dcd758338cb5"]
 	var state map[string]interface{}
 	err = json.Unmarshal(stateJSON, &state)
@@ -317,6 +321,8 @@ func TestUpdateRecursive(t *testing.T) {
 		"published": "2022-05-06T22:12:41Z",
 	}
 
+	stub.MockTransactionEnd("TestUpdateAsset")
+
 	stateJSON := stub.State["book:a36a2920-c405-51c3-b584-dcd758338cb5"]
 	var state map[string]interface{}
 	err = json.Unmarshal(stateJSON, &state)
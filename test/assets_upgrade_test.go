@@ -0,0 +1,174 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// setupOutdatedTicket writes a ticket in the shape of version 0 and returns its key
+func setupOutdatedTicket(stub *mock.MockStub) assets.Key {
+	key, _ := assets.NewKey(map[string]interface{}{
+		"@assetType": "ticket",
+		"code":       "T1",
+	})
+
+	stub.MockTransactionStart("setupOutdatedTicket")
+	state, _ := json.Marshal(map[string]interface{}{
+		"@assetType": "ticket",
+		"@key":       key.Key(),
+		"code":       "T1",
+		"title":      "Broken printer",
+	})
+	stub.PutState(key.Key(), state)
+	stub.MockTransactionEnd("setupOutdatedTicket")
+
+	return key
+}
+
+func TestGetUpgradesAsset(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{ticketV1}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	key := setupOutdatedTicket(stub)
+
+	stub.MockTransactionStart("TestGetUpgradesAsset")
+	wrapper := &sw.StubWrapper{Stub: stub}
+	asset, err := key.Get(wrapper)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if (*asset)["summary"] != "Broken printer" || (*asset)["priority"] != int64(1) || asset.SchemaVersion() != 1 {
+		log.Println("asset was not upgraded", *asset)
+		t.FailNow()
+	}
+	if _, hasTitle := (*asset)["title"]; hasTitle {
+		log.Println("asset was not upgraded", *asset)
+		t.FailNow()
+	}
+
+	recursive, err := key.GetRecursive(wrapper)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if recursive["summary"] != "Broken printer" || assets.Asset(recursive).SchemaVersion() != 1 {
+		log.Println("asset was not upgraded", recursive)
+		t.FailNow()
+	}
+	stub.MockTransactionEnd("TestGetUpgradesAsset")
+
+	// Reads do not rewrite the asset
+	var state map[string]interface{}
+	_ = json.Unmarshal(stub.State[key.Key()], &state)
+	if state["title"] != "Broken printer" || state["@schemaVersion"] != nil {
+		log.Println("asset should not be written on read", state)
+		t.FailNow()
+	}
+
+	req := map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": "ticket",
+			"code":       "T1",
+		},
+	}
+	res := stub.MockInvoke("readAsset", [][]byte{[]byte("readAsset"), mustMarshal(req)})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	var read map[string]interface{}
+	_ = json.Unmarshal(res.GetPayload(), &read)
+	if read["summary"] != "Broken printer" || read["@schemaVersion"] != 1.0 {
+		log.Println("readAsset should return the upgraded asset", read)
+		t.FailNow()
+	}
+}
+
+func TestUpdateWriteBack(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+
+	ticket := ticketV1
+	ticket.WriteBack = true
+	assets.InitAssetList(append([]assets.AssetType{ticket}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	key := setupOutdatedTicket(stub)
+
+	req := map[string]interface{}{
+		"update": map[string]interface{}{
+			"@assetType": "ticket",
+			"code":       "T1",
+			"priority":   3,
+		},
+	}
+	res := stub.MockInvoke("updateAsset", [][]byte{[]byte("updateAsset"), mustMarshal(req)})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	var state map[string]interface{}
+	_ = json.Unmarshal(stub.State[key.Key()], &state)
+	if state["priority"] != 3.0 || state["summary"] != "Broken printer" || state["@schemaVersion"] != 1.0 {
+		log.Println("upgraded asset should be written back", state)
+		t.FailNow()
+	}
+	if _, hasTitle := state["title"]; hasTitle {
+		log.Println("upgraded asset should be written back", state)
+		t.FailNow()
+	}
+}
+
+func TestUpdateWithoutWriteBack(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+
+	// Version 1 only adds the optional "priority" prop, so version 0 assets are still valid
+	ticket := ticketV0
+	ticket.Version = 1
+	ticket.Props = append(append([]assets.AssetProp{}, ticketV0.Props...), assets.AssetProp{
+		Tag:      "priority",
+		Label:    "Priority",
+		DataType: "integer",
+	})
+	ticket.Migrations = map[int]assets.Migration{
+		0: func(old assets.Asset) (assets.Asset, error) {
+			old["priority"] = 1
+			return old, nil
+		},
+	}
+	assets.InitAssetList(append([]assets.AssetType{ticket}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	key := setupOutdatedTicket(stub)
+
+	req := map[string]interface{}{
+		"update": map[string]interface{}{
+			"@assetType": "ticket",
+			"code":       "T1",
+			"title":      "Printer on fire",
+		},
+	}
+	res := stub.MockInvoke("updateAsset", [][]byte{[]byte("updateAsset"), mustMarshal(req)})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	var state map[string]interface{}
+	_ = json.Unmarshal(stub.State[key.Key()], &state)
+	if state["title"] != "Printer on fire" || state["priority"] != nil {
+		log.Println("asset should be updated in its stored shape", state)
+		t.FailNow()
+	}
+	if _, hasVersion := state["@schemaVersion"]; hasVersion {
+		log.Println("outdated asset should keep its version", state)
+		t.FailNow()
+	}
+}
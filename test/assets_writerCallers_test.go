@@ -0,0 +1,164 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+var writerCallersTestAssetType = assets.AssetType{
+	Tag:   "report",
+	Label: "Report",
+	WriterCallers: []accesscontrol.Caller{
+		{MSP: `$org\dMSP`},
+	},
+	DeleterCallers: []accesscontrol.Caller{
+		{MSP: "org1MSP", OU: "admin"},
+	},
+	Props: []assets.AssetProp{
+		{
+			Required: true,
+			IsKey:    true,
+			Tag:      "code",
+			Label:    "Code",
+			DataType: "string",
+		},
+		{
+			Tag:      "text",
+			Label:    "Text",
+			DataType: "string",
+		},
+		{
+			Tag:      "approved",
+			Label:    "Approved",
+			DataType: "boolean",
+			WriterCallers: []accesscontrol.Caller{
+				{MSP: "org2MSP", Attributes: map[string]string{"role": "auditor"}},
+			},
+		},
+	},
+}
+
+// newTestCertStub returns a mock stub whose creator has a certificate with the common name, OU and attributes
+func newTestCertStub(t *testing.T, cn, msp, ou string, attrs map[string]string) *mock.MockStub {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:         cn,
+			OrganizationalUnit: []string{ou},
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	if attrs != nil {
+		attrsJSON, _ := json.Marshal(map[string]interface{}{"attrs": attrs})
+		template.ExtraExtensions = []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}, Value: attrsJSON},
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	stub, err := mock.NewMockStubWithCert(msp, new(testCC), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	return stub
+}
+
+func TestWriterCallers(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{writerCallersTestAssetType}, testAssetList...))
+
+	report := map[string]interface{}{
+		"@assetType": "report",
+		"code":       "R1",
+		"text":       "Quarterly report",
+	}
+	draft := map[string]interface{}{
+		"@assetType": "report",
+		"code":       "R2",
+		"text":       "Draft",
+	}
+	draftKey := map[string]interface{}{"@assetType": "report", "code": "R2"}
+
+	// Asset type writer callers
+	stub := newTestCertStub(t, "user", "orgXMSP", "client", nil)
+	err := invokeAndVerify(stub, "createAsset", map[string]interface{}{"asset": []interface{}{report}},
+		"failed to write asset to ledger: failed to write asset to ledger: failed write permission check: caller cannot write assets of type 'report'", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	stub = newTestCertStub(t, "user", "org1MSP", "client", nil)
+	res := stub.MockInvoke("createReport", [][]byte{[]byte("createAsset"), mustMarshal(map[string]interface{}{"asset": []interface{}{report, draft}})})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	// Property writer callers
+	approve := map[string]interface{}{"update": map[string]interface{}{"@assetType": "report", "code": "R1", "approved": true}}
+	state := stub.State
+	for _, caller := range []struct {
+		msp    string
+		attrs  map[string]string
+		status int32
+	}{
+		{"org1MSP", map[string]string{"role": "auditor"}, 403},
+		{"org2MSP", map[string]string{"role": "clerk"}, 403},
+		{"org2MSP", map[string]string{"role": "auditor"}, 200},
+	} {
+		stub = newTestCertStub(t, "user", caller.msp, "client", caller.attrs)
+		stub.State = state
+		res = stub.MockInvoke("approveReport", [][]byte{[]byte("updateAsset"), mustMarshal(approve)})
+		if res.GetStatus() != caller.status {
+			log.Printf("expected status %d for %s with %v, got %d: %s", caller.status, caller.msp, caller.attrs, res.GetStatus(), res.GetMessage())
+			t.FailNow()
+		}
+	}
+
+	// Asset type deleter callers
+	for _, caller := range []struct {
+		msp    string
+		ou     string
+		status int32
+	}{
+		{"org2MSP", "admin", 403},
+		{"org1MSP", "client", 403},
+		{"org1MSP", "admin", 200},
+	} {
+		stub = newTestCertStub(t, "user", caller.msp, caller.ou, nil)
+		stub.State = state
+		res = stub.MockInvoke("deleteReport", [][]byte{[]byte("deleteAsset"), mustMarshal(map[string]interface{}{"key": draftKey})})
+		if res.GetStatus() != caller.status {
+			log.Printf("expected status %d for %s/%s, got %d: %s", caller.status, caller.msp, caller.ou, res.GetStatus(), res.GetMessage())
+			t.FailNow()
+		}
+	}
+}
@@ -23,6 +23,8 @@ var testTxList = []tx.Transaction{
 	tx.UpdateAssetType,
 	tx.DeleteAssetType,
 	tx.LoadAssetTypeList,
+	tx.Batch,
+	tx.TransferOwnership,
 }
 
 var testAssetList = []assets.AssetType{
@@ -237,6 +239,21 @@ var testCustomDataTypes = map[string]assets.DataType{
 			return cpf, cpf, nil
 		},
 	},
+	"language": {
+		AcceptedFormats: []string{"string"},
+		Description:     "Language of a book",
+		DropDownValues: map[string]interface{}{
+			"English":    "en",
+			"Portuguese": "pt",
+		},
+		Parse: func(data interface{}) (string, interface{}, errors.ICCError) {
+			language, ok := data.(string)
+			if !ok || (language != "en" && language != "pt") {
+				return "", nil, errors.NewCCError("language must be 'en' or 'pt'", 400)
+			}
+			return language, language, nil
+		},
+	},
 }
 
 var testEventTypeList = []events.Event{
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/events"
+	"github.com/hyperledger-labs/cc-tools/listener"
+	"github.com/hyperledger-labs/cc-tools/listener/listenertest"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+var auditLogTestEvent = events.Event{
+	Tag:  "auditLog",
+	Type: events.EventCustom,
+}
+
+func TestListener(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	defer events.InitEventList(testEventTypeList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), raiseEventsTestTx, triggerEventTestTx))
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), auditLogTestEvent))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.TransientMap = map[string][]byte{events.EventSaltTransientKey: []byte("secret")}
+	invoke := func(txID, txName string, req map[string]interface{}) {
+		res := stub.MockInvoke(txID, [][]byte{[]byte(txName), mustMarshal(req)})
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+			t.FailNow()
+		}
+	}
+	invoke("tx1", "raiseEvents", map[string]interface{}{"logs": []string{"first", "second"}})
+	invoke("tx2", "triggerEvent", map[string]interface{}{"eventTag": "auditLog", "args": map[string]interface{}{"entry": 1}})
+
+	checkpointer := &listener.MemoryCheckpointer{}
+	l := listener.New(listenertest.NewMockSource(stub), checkpointer)
+
+	var logs []listener.Delivery
+	l.Handle("createLibraryLog", func(ctx context.Context, delivery listener.Delivery) error {
+		logs = append(logs, delivery)
+		return nil
+	})
+	var audits []string
+	err := l.HandleCustom("auditLog", func(ctx context.Context, payload []byte) error {
+		audits = append(audits, string(payload))
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	err = l.HandleCustom("createLibraryLog", nil)
+	if err == nil || err.Error() != "event createLibraryLog is not of type 'EventCustom'" {
+		log.Println("expected custom handlers to require EventCustom events", err)
+		t.FailNow()
+	}
+
+	err = l.Run(context.Background())
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if len(logs) != 2 || logs[0].TxID != "tx1" || logs[1].BlockNumber != 0 || logs[0].Event == nil || logs[0].Event.Label != "Create Library Log" {
+		log.Printf("unexpected log deliveries %#v", logs)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(audits, []string{`{"entry":1}`}) {
+		log.Println("unexpected audit deliveries", audits)
+		t.FailNow()
+	}
+	if lastBlock, ok, _ := checkpointer.LastBlock(); !ok || lastBlock != 1 {
+		log.Println("expected checkpoint at block 1, got", lastBlock, ok)
+		t.FailNow()
+	}
+
+	// Runs resume from the checkpoint
+	invoke("tx3", "triggerEvent", map[string]interface{}{"eventTag": "auditLog", "args": map[string]interface{}{"entry": 2}})
+	err = l.Run(context.Background())
+	if err != nil || len(logs) != 2 || !reflect.DeepEqual(audits, []string{`{"entry":1}`, `{"entry":2}`}) {
+		log.Println("expected only new events to be delivered", err, audits)
+		t.FailNow()
+	}
+
+	// Replays deliver past events again
+	audits = nil
+	err = l.Replay(context.Background(), 1)
+	if err != nil || len(logs) != 2 || !reflect.DeepEqual(audits, []string{`{"entry":1}`, `{"entry":2}`}) {
+		log.Println("expected events to be replayed from block 1", err, audits)
+		t.FailNow()
+	}
+}
+
+func TestListenerHandlerError(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	defer events.InitEventList(testEventTypeList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), triggerEventTestTx))
+	events.InitEventList(append(append([]events.Event{}, testEventTypeList...), auditLogTestEvent))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	for _, txID := range []string{"tx1", "tx2", "tx3"} {
+		res := stub.MockInvoke(txID, [][]byte{[]byte("triggerEvent"), mustMarshal(map[string]interface{}{"eventTag": "auditLog"})})
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+			t.FailNow()
+		}
+	}
+
+	checkpointer := listener.FileCheckpointer{Path: filepath.Join(t.TempDir(), "checkpoint")}
+	source := listenertest.NewMockSource(stub)
+	failing := listener.New(source, checkpointer)
+	failing.HandleDefault(func(ctx context.Context, delivery listener.Delivery) error {
+		if delivery.TxID == "tx2" {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	err := failing.Run(context.Background())
+	if err == nil || err.Error() != "handler of event auditLog failed at block 1: unavailable" {
+		log.Println("expected handler error", err)
+		t.FailNow()
+	}
+	if lastBlock, ok, _ := checkpointer.LastBlock(); !ok || lastBlock != 0 {
+		log.Println("expected checkpoint at block 0, got", lastBlock, ok)
+		t.FailNow()
+	}
+
+	// A new listener with the same checkpoint resumes from the failed block
+	var delivered []string
+	resumed := listener.New(source, checkpointer)
+	resumed.HandleDefault(func(ctx context.Context, delivery listener.Delivery) error {
+		delivered = append(delivered, delivery.TxID)
+		return nil
+	})
+	err = resumed.Run(context.Background())
+	if err != nil || !reflect.DeepEqual(delivered, []string{"tx2", "tx3"}) {
+		log.Println("expected failed block to be delivered again", err, delivered)
+		t.FailNow()
+	}
+	if lastBlock, _, _ := checkpointer.LastBlock(); lastBlock != 2 {
+		log.Println("expected checkpoint at block 2, got", lastBlock)
+		t.FailNow()
+	}
+}
@@ -36,6 +36,8 @@ func TestMain(m *testing.M) {
 
 	assets.InitAssetList(testAssetList)
 
+	registerBindingStructs()
+
 	err = assets.StartupCheck()
 	if err != nil {
 		log.Println(err)
@@ -0,0 +1,196 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+func setupQueryStub() *mock.MockStub {
+	stub := mock.NewMockStub("queryTest", nil)
+	stub.MockTransactionStart("init")
+	docs := map[string]interface{}{
+		"marble1": map[string]interface{}{"docType": "marble", "color": "red", "size": 5, "owner": map[string]interface{}{"name": "tom", "age": 30}, "tags": []interface{}{"shiny", "round"}},
+		"marble2": map[string]interface{}{"docType": "marble", "color": "blue", "size": 3, "owner": map[string]interface{}{"name": "jerry", "age": 25}, "tags": []interface{}{"round"}},
+		"marble3": map[string]interface{}{"docType": "marble", "color": "green", "size": 10, "owner": map[string]interface{}{"name": "tom", "age": 30}},
+		"marble4": map[string]interface{}{"docType": "marble", "color": "red", "size": 1, "owner": map[string]interface{}{"name": "anna", "age": 41}, "tags": []interface{}{}},
+		"car1":    map[string]interface{}{"docType": "car", "color": "red"},
+	}
+	for k, v := range docs {
+		b, _ := json.Marshal(v)
+		stub.PutState(k, b)
+	}
+	stub.PutState("raw", []byte("not a json document"))
+	stub.MockTransactionEnd("init")
+	return stub
+}
+
+func queryKeys(iter shim.StateQueryIteratorInterface) []string {
+	keys := make([]string, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil
+		}
+		keys = append(keys, kv.Key)
+	}
+	iter.Close()
+	return keys
+}
+
+func TestMockQueryOperators(t *testing.T) {
+	stub := setupQueryStub()
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`{"selector":{"color":"red"}}`, []string{"car1", "marble1", "marble4"}},
+		{`{"selector":{"docType":"marble","color":{"$eq":"red"}}}`, []string{"marble1", "marble4"}},
+		{`{"selector":{"docType":"marble","color":{"$ne":"red"}}}`, []string{"marble2", "marble3"}},
+		{`{"selector":{"size":{"$gt":3}}}`, []string{"marble1", "marble3"}},
+		{`{"selector":{"size":{"$gte":3,"$lt":10}}}`, []string{"marble1", "marble2"}},
+		{`{"selector":{"size":{"$lte":1}}}`, []string{"marble4"}},
+		{`{"selector":{"color":{"$in":["blue","green"]}}}`, []string{"marble2", "marble3"}},
+		{`{"selector":{"docType":"marble","color":{"$nin":["blue","green"]}}}`, []string{"marble1", "marble4"}},
+		{`{"selector":{"tags":{"$exists":false},"docType":"marble"}}`, []string{"marble3"}},
+		{`{"selector":{"owner.name":{"$regex":"^t"}}}`, []string{"marble1", "marble3"}},
+		{`{"selector":{"owner":{"name":"jerry"}}}`, []string{"marble2"}},
+		{`{"selector":{"owner":{"age":{"$gt":29}}}}`, []string{"marble1", "marble3", "marble4"}},
+		{`{"selector":{"$and":[{"color":"red"},{"docType":"marble"}]}}`, []string{"marble1", "marble4"}},
+		{`{"selector":{"$or":[{"color":"blue"},{"size":10}]}}`, []string{"marble2", "marble3"}},
+		{`{"selector":{"docType":"marble","$nor":[{"color":"blue"},{"size":10}]}}`, []string{"marble1", "marble4"}},
+		{`{"selector":{"docType":"marble","$not":{"color":"red"}}}`, []string{"marble2", "marble3"}},
+		{`{"selector":{"tags":{"$elemMatch":{"$eq":"shiny"}}}}`, []string{"marble1"}},
+		{`{"selector":{"tags":{"$allMatch":{"$eq":"round"}}}}`, []string{"marble2"}},
+		{`{"selector":{"tags":{"$all":["round","shiny"]}}}`, []string{"marble1"}},
+		{`{"selector":{"tags":{"$size":0}}}`, []string{"marble4"}},
+		{`{"selector":{"size":{"$mod":[5,0]}}}`, []string{"marble1", "marble3"}},
+		{`{"selector":{"color":{"$type":"string"},"size":{"$exists":false}}}`, []string{"car1"}},
+	}
+
+	for _, test := range tests {
+		iter, err := stub.GetQueryResult(test.query)
+		if err != nil {
+			log.Println(test.query, err)
+			t.FailNow()
+		}
+		keys := queryKeys(iter)
+		if !reflect.DeepEqual(keys, test.expected) {
+			log.Println(test.query)
+			log.Println("expected", test.expected, "got", keys)
+			t.FailNow()
+		}
+	}
+}
+
+func TestMockQuerySortFieldsLimit(t *testing.T) {
+	stub := setupQueryStub()
+
+	iter, err := stub.GetQueryResult(`{"selector":{"docType":"marble"},"sort":[{"size":"desc"}],"fields":["color","owner.name"],"limit":2}`)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	expectedKeys := []string{"marble3", "marble1"}
+	expectedValues := []map[string]interface{}{
+		{"color": "green", "owner": map[string]interface{}{"name": "tom"}},
+		{"color": "red", "owner": map[string]interface{}{"name": "tom"}},
+	}
+
+	i := 0
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		if i >= len(expectedKeys) || kv.Key != expectedKeys[i] {
+			log.Println("unexpected key", kv.Key)
+			t.FailNow()
+		}
+		var value map[string]interface{}
+		json.Unmarshal(kv.Value, &value)
+		if !reflect.DeepEqual(value, expectedValues[i]) {
+			log.Println("expected", expectedValues[i], "got", value)
+			t.FailNow()
+		}
+		i++
+	}
+	if i != len(expectedKeys) {
+		log.Println("expected", len(expectedKeys), "results, got", i)
+		t.FailNow()
+	}
+}
+
+func TestMockQueryInvalid(t *testing.T) {
+	stub := setupQueryStub()
+
+	invalidQueries := []string{
+		`not json`,
+		`{"sort":["size"]}`,
+		`{"selector":{"size":{"$foo":1}}}`,
+		`{"selector":{"$or":{"size":1}}}`,
+		`{"selector":{"color":{"$regex":"("}}}`,
+		`{"selector":{},"limit":-1}`,
+	}
+	for _, query := range invalidQueries {
+		_, err := stub.GetQueryResult(query)
+		if err == nil {
+			log.Println("expected error for query", query)
+			t.FailNow()
+		}
+	}
+}
+
+func TestMockQueryWithPagination(t *testing.T) {
+	stub := setupQueryStub()
+	query := `{"selector":{"docType":"marble"},"sort":[{"size":"asc"}]}`
+
+	expectedPages := [][]string{{"marble4", "marble2"}, {"marble1", "marble3"}, {}}
+	bookmark := ""
+	for _, expected := range expectedPages {
+		iter, metadata, err := stub.GetQueryResultWithPagination(query, 2, bookmark)
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+		keys := queryKeys(iter)
+		if !reflect.DeepEqual(keys, expected) {
+			log.Println("expected", expected, "got", keys)
+			t.FailNow()
+		}
+		if metadata.FetchedRecordsCount != int32(len(expected)) {
+			log.Println("expected", len(expected), "fetched records, got", metadata.FetchedRecordsCount)
+			t.FailNow()
+		}
+		bookmark = metadata.Bookmark
+	}
+}
+
+func TestMockPrivateDataQueryResult(t *testing.T) {
+	stub := mock.NewMockStub("queryTest", nil)
+	stub.MockTransactionStart("init")
+	stub.PutPrivateData("collection", "b", []byte(`{"value":2}`))
+	stub.PutPrivateData("collection", "a", []byte(`{"value":1}`))
+	stub.PutPrivateData("other", "c", []byte(`{"value":3}`))
+	stub.MockTransactionEnd("init")
+
+	iter, err := stub.GetPrivateDataQueryResult("collection", `{"selector":{"value":{"$gt":0}}}`)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	keys := queryKeys(iter)
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		log.Println("expected [a b] got", keys)
+		t.FailNow()
+	}
+
+	iter, err = stub.GetPrivateDataQueryResult("empty", `{"selector":{}}`)
+	if err != nil || iter.HasNext() {
+		log.Println("expected empty result")
+		t.FailNow()
+	}
+}
@@ -4,6 +4,7 @@
 package test
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"log"
 	"reflect"
@@ -249,3 +250,252 @@ func TestPutEmptyState(t *testing.T) {
 	stub.MockTransactionEnd("5")
 
 }
+
+func TestGetHistoryForKey(t *testing.T) {
+	stub := mock.NewMockStub("historyTest", nil)
+
+	stub.MockTransactionStart("tx1")
+	stub.PutState("key", []byte("v1"))
+	stub.MockTransactionEnd("tx1")
+
+	// Only the last write of a transaction is recorded
+	stub.MockTransactionStart("tx2")
+	stub.PutState("key", []byte("v2"))
+	stub.PutState("key", []byte("v3"))
+	stub.MockTransactionEnd("tx2")
+
+	stub.MockTransactionStart("tx3")
+	stub.DelState("key")
+	stub.MockTransactionEnd("tx3")
+
+	iter, err := stub.GetHistoryForKey("key")
+	assert.NoError(t, err)
+
+	expectedTxIDs := []string{"tx3", "tx2", "tx1"}
+	expectedValues := [][]byte{nil, []byte("v3"), []byte("v1")}
+	i := 0
+	for iter.HasNext() {
+		modification, err := iter.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTxIDs[i], modification.TxId)
+		assert.Equal(t, expectedValues[i], modification.Value)
+		assert.Equal(t, i == 0, modification.IsDelete)
+		assert.NotNil(t, modification.Timestamp)
+		i++
+	}
+	assert.Equal(t, 3, i)
+	assert.NoError(t, iter.Close())
+}
+
+func TestGetStateByRangeWithPagination(t *testing.T) {
+	stub := mock.NewMockStub("rangeTest", nil)
+	stub.MockTransactionStart("init")
+	for _, key := range []string{"1", "0", "5", "3", "4", "6"} {
+		stub.PutState(key, []byte(key))
+	}
+	compositeKey, _ := stub.CreateCompositeKey("marble", []string{"set-1"})
+	stub.PutState(compositeKey, []byte("composite"))
+	stub.MockTransactionEnd("init")
+
+	// Composite keys are not included in an open-ended range
+	expectedPages := [][]string{{"0", "1", "3"}, {"4", "5", "6"}}
+	expectedBookmarks := []string{"4", ""}
+	bookmark := ""
+	for i, expected := range expectedPages {
+		iter, metadata, err := stub.GetStateByRangeWithPagination("", "", 3, bookmark)
+		assert.NoError(t, err)
+		keys := make([]string, 0)
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, kv.Key, string(kv.Value))
+			keys = append(keys, kv.Key)
+		}
+		assert.Equal(t, expected, keys)
+		assert.Equal(t, int32(len(expected)), metadata.FetchedRecordsCount)
+		assert.Equal(t, expectedBookmarks[i], metadata.Bookmark)
+		bookmark = metadata.Bookmark
+	}
+
+	iter, metadata, err := stub.GetStateByRangeWithPagination("1", "5", 2, "")
+	assert.NoError(t, err)
+	assert.True(t, iter.HasNext())
+	assert.Equal(t, int32(2), metadata.FetchedRecordsCount)
+	assert.Equal(t, "4", metadata.Bookmark)
+
+	iter, metadata, err = stub.GetStateByRangeWithPagination("1", "5", 2, metadata.Bookmark)
+	assert.NoError(t, err)
+	kv, _ := iter.Next()
+	assert.Equal(t, "4", kv.Key)
+	assert.False(t, iter.HasNext())
+	assert.Equal(t, int32(1), metadata.FetchedRecordsCount)
+	assert.Equal(t, "", metadata.Bookmark)
+}
+
+func TestGetStateByPartialCompositeKeyWithPagination(t *testing.T) {
+	stub := mock.NewMockStub("GetStateByPartialCompositeKeyWithPaginationTest", nil)
+	stub.MockTransactionStart("init")
+	colors := []string{"red", "blue", "green", "yellow", "white"}
+	for _, color := range colors {
+		key, _ := stub.CreateCompositeKey("marble", []string{"set-1", color})
+		stub.PutState(key, []byte(color))
+	}
+	otherKey, _ := stub.CreateCompositeKey("marble", []string{"set-2", "black"})
+	stub.PutState(otherKey, []byte("black"))
+	stub.MockTransactionEnd("init")
+
+	expectedPages := [][]string{{"blue", "green"}, {"red", "white"}, {"yellow"}}
+	bookmark := ""
+	for i, expected := range expectedPages {
+		iter, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination("marble", []string{"set-1"}, 2, bookmark)
+		assert.NoError(t, err)
+		values := make([]string, 0)
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			assert.NoError(t, err)
+			values = append(values, string(kv.Value))
+		}
+		assert.Equal(t, expected, values)
+		assert.Equal(t, int32(len(expected)), metadata.FetchedRecordsCount)
+		if i == len(expectedPages)-1 {
+			assert.Equal(t, "", metadata.Bookmark)
+		} else {
+			assert.NotEqual(t, "", metadata.Bookmark)
+		}
+		bookmark = metadata.Bookmark
+	}
+}
+
+func TestPrivateDataRangeQueries(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", nil)
+	stub.MockTransactionStart("init")
+	for _, key := range []string{"b", "a", "d", "c"} {
+		stub.PutPrivateData("collection", key, []byte(key))
+	}
+	for _, color := range []string{"red", "blue"} {
+		compositeKey, _ := stub.CreateCompositeKey("marble", []string{"set-1", color})
+		stub.PutPrivateData("collection", compositeKey, []byte(color))
+	}
+	stub.PutPrivateData("other", "a", []byte("other"))
+	stub.MockTransactionEnd("init")
+
+	iter, err := stub.GetPrivateDataByRange("collection", "", "")
+	assert.NoError(t, err)
+	keys := make([]string, 0)
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		keys = append(keys, kv.Key)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, keys)
+
+	iter, err = stub.GetPrivateDataByRange("collection", "b", "d")
+	assert.NoError(t, err)
+	keys = make([]string, 0)
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		keys = append(keys, kv.Key)
+	}
+	assert.Equal(t, []string{"b", "c"}, keys)
+
+	iter, err = stub.GetPrivateDataByPartialCompositeKey("collection", "marble", []string{"set-1"})
+	assert.NoError(t, err)
+	values := make([]string, 0)
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		values = append(values, string(kv.Value))
+	}
+	assert.Equal(t, []string{"blue", "red"}, values)
+
+	stub.MockTransactionStart("delete")
+	assert.NoError(t, stub.DelPrivateData("collection", "a"))
+	assert.NoError(t, stub.PurgePrivateData("collection", "b"))
+	stub.MockTransactionEnd("delete")
+
+	iter, err = stub.GetPrivateDataByRange("collection", "", "c")
+	assert.NoError(t, err)
+	assert.False(t, iter.HasNext())
+}
+
+func TestPrivateDataCollectionMembership(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", nil)
+	stub.CollectionMembers["secret"] = []string{"org2MSP", "org3MSP"}
+
+	stub.MockTransactionStart("init")
+	assert.NoError(t, stub.PutPrivateData("secret", "key", []byte("value")))
+	stub.MockTransactionEnd("init")
+
+	_, err := stub.GetPrivateData("secret", "key")
+	assert.Error(t, err)
+	_, err = stub.GetPrivateDataByRange("secret", "", "")
+	assert.Error(t, err)
+	_, err = stub.GetPrivateDataQueryResult("secret", `{"selector":{}}`)
+	assert.Error(t, err)
+
+	hash, err := stub.GetPrivateDataHash("secret", "key")
+	assert.NoError(t, err)
+	expectedHash := sha256.Sum256([]byte("value"))
+	assert.Equal(t, expectedHash[:], hash)
+
+	hash, err = stub.GetPrivateDataHash("secret", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, hash)
+
+	stub.Name = "org2MSP"
+	value, err := stub.GetPrivateData("secret", "key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestMockTransactionBuffersWrites(t *testing.T) {
+	stub := mock.NewMockStub("bufferTest", nil)
+
+	stub.MockTransactionStart("tx1")
+	assert.NoError(t, stub.PutState("key", []byte("value")))
+	assert.NoError(t, stub.PutPrivateData("collection", "key", []byte("private")))
+	assert.NoError(t, stub.SetEvent("first", []byte("1")))
+	assert.NoError(t, stub.SetEvent("second", []byte("2")))
+
+	// Writes are not visible before the transaction is committed
+	val, err := stub.GetState("key")
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+	val, err = stub.GetPrivateData("collection", "key")
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+	assert.Equal(t, 0, len(stub.ChaincodeEventsChannel))
+	stub.MockTransactionEnd("tx1")
+
+	val, err = stub.GetState("key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+	val, err = stub.GetPrivateData("collection", "key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("private"), val)
+
+	// Only the last event of the transaction is emitted
+	assert.Equal(t, 1, len(stub.ChaincodeEventsChannel))
+	event := <-stub.ChaincodeEventsChannel
+	assert.Equal(t, "second", event.EventName)
+	assert.Equal(t, "tx1", event.TxId)
+	assert.Equal(t, 1, len(stub.EmittedEvents))
+
+	stub.MockTransactionStart("tx2")
+	assert.NoError(t, stub.DelState("key"))
+	assert.NoError(t, stub.PutState("other", []byte("other")))
+	assert.NoError(t, stub.DelPrivateData("collection", "key"))
+	assert.NoError(t, stub.SetEvent("rolledBack", nil))
+	stub.MockTransactionRollback("tx2")
+
+	val, _ = stub.GetState("key")
+	assert.Equal(t, []byte("value"), val)
+	val, _ = stub.GetState("other")
+	assert.Nil(t, val)
+	val, _ = stub.GetPrivateData("collection", "key")
+	assert.Equal(t, []byte("private"), val)
+	assert.Equal(t, 0, len(stub.ChaincodeEventsChannel))
+	assert.Equal(t, 1, len(stub.History["key"]))
+	assert.Equal(t, 0, len(stub.History["other"]))
+
+	assert.Error(t, stub.PutState("key", []byte("value")))
+	assert.Error(t, stub.DelState("key"))
+}
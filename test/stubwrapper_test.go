@@ -0,0 +1,266 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+func iteratorKeys(t *testing.T, it shim.StateQueryIteratorInterface) []string {
+	keys := make([]string, 0)
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+		keys = append(keys, kv.Key)
+	}
+	it.Close()
+	return keys
+}
+
+func TestStubWrapperOverlay(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	stub.MockTransactionStart("setup")
+	stub.PutState("a", []byte(`{"color":"red"}`))
+	stub.PutState("c", []byte(`{"color":"blue"}`))
+	stub.PutState("e", []byte(`{"color":"red"}`))
+	idx1, _ := stub.CreateCompositeKey("index", []string{"1"})
+	idx3, _ := stub.CreateCompositeKey("index", []string{"3"})
+	stub.PutState(idx1, []byte{0x00})
+	stub.PutState(idx3, []byte{0x00})
+	stub.MockTransactionEnd("setup")
+
+	stub.MockTransactionStart("overlay")
+	defer stub.MockTransactionEnd("overlay")
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	sw.PutState("b", []byte(`{"color":"red"}`))
+	sw.PutState("e", []byte(`{"color":"green"}`))
+	sw.DelState("a")
+	idx2, _ := stub.CreateCompositeKey("index", []string{"2"})
+	sw.PutState(idx2, []byte{0x00})
+	sw.DelState(idx1)
+
+	value, _ := sw.GetState("a")
+	if value != nil {
+		log.Println("deleted key should read as nil")
+		t.FailNow()
+	}
+
+	it, _ := sw.GetStateByRange("", "")
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{"b", "c", "e"}) {
+		log.Println("unexpected range keys", keys)
+		t.FailNow()
+	}
+
+	it, _ = sw.GetStateByPartialCompositeKey("index", []string{})
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{idx2, idx3}) {
+		log.Println("unexpected composite keys", keys)
+		t.FailNow()
+	}
+
+	it, _ = sw.GetQueryResult(`{"selector":{"color":"red"}}`)
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{"b"}) {
+		log.Println("unexpected query keys", keys)
+		t.FailNow()
+	}
+
+	it, _ = sw.GetQueryResult(`{"selector":{"color":{"$exists":true}},"sort":[{"color":"desc"}]}`)
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{"b", "e", "c"}) {
+		log.Println("unexpected sorted query keys", keys)
+		t.FailNow()
+	}
+
+	// Committed-only reads ignore the write set
+	sw.CommittedReadsOnly = true
+	value, _ = sw.GetState("a")
+	if value == nil {
+		log.Println("committed value should be read")
+		t.FailNow()
+	}
+	it, _ = sw.GetStateByPartialCompositeKey("index", []string{})
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{idx1, idx3}) {
+		log.Println("unexpected committed composite keys", keys)
+		t.FailNow()
+	}
+	it, _ = sw.GetQueryResult(`{"selector":{"color":"red"}}`)
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{"a", "e"}) {
+		log.Println("unexpected committed query keys", keys)
+		t.FailNow()
+	}
+}
+
+func TestStubWrapperPrivateOverlay(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	stub.MockTransactionStart("setup")
+	stub.PutPrivateData("collection", "a", []byte(`{"value":1}`))
+	stub.PutPrivateData("collection", "b", []byte(`{"value":2}`))
+	stub.MockTransactionEnd("setup")
+
+	stub.MockTransactionStart("overlay")
+	defer stub.MockTransactionEnd("overlay")
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	sw.DelPrivateData("collection", "a")
+	sw.PutPrivateData("collection", "c", []byte(`{"value":3}`))
+
+	value, _ := sw.GetPrivateData("collection", "a")
+	hash, _ := sw.GetPrivateDataHash("collection", "a")
+	if value != nil || hash != nil {
+		log.Println("deleted private key should read as nil")
+		t.FailNow()
+	}
+	if _, isWritten := sw.WriteSet["a"]; isWritten {
+		log.Println("private deletion should not be recorded in the public write set")
+		t.FailNow()
+	}
+
+	it, _ := sw.GetPrivateDataByRange("collection", "", "")
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{"b", "c"}) {
+		log.Println("unexpected private range keys", keys)
+		t.FailNow()
+	}
+
+	it, _ = sw.GetPrivateDataQueryResult("collection", `{"selector":{"value":{"$gte":1}}}`)
+	if keys := iteratorKeys(t, it); !reflect.DeepEqual(keys, []string{"b", "c"}) {
+		log.Println("unexpected private query keys", keys)
+		t.FailNow()
+	}
+}
+
+func TestDeleteReferencedInSameTransaction(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	person, err := assets.NewAsset(map[string]interface{}{
+		"@assetType": "person",
+		"name":       "Maria",
+		"id":         "31820792048",
+	})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	book, err := assets.NewAsset(map[string]interface{}{
+		"@assetType": "book",
+		"title":      "Meu Nome é Maria",
+		"author":     "Maria Viana",
+		"currentTenant": map[string]interface{}{
+			"@assetType": "person",
+			"id":         "31820792048",
+		},
+	})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	stub.MockTransactionStart("TestDeleteReferencedInSameTransaction")
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	_, err = person.PutNew(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	_, err = book.PutNew(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// The reference written in this transaction must block the deletion
+	personKey, _ := assets.NewKey(person)
+	_, err = personKey.Delete(sw)
+	if err == nil || err.Status() != 400 {
+		log.Println("expected referenced asset deletion to fail")
+		t.FailNow()
+	}
+
+	// Once the referrer is deleted, the reference no longer exists
+	bookKey, _ := assets.NewKey(book)
+	_, err = bookKey.Delete(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	_, err = personKey.Delete(sw)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	stub.MockTransactionEnd("TestDeleteReferencedInSameTransaction")
+
+	if len(stub.State) != 0 {
+		stateJSON, _ := json.Marshal(stub.State)
+		log.Println("state should be empty", string(stateJSON))
+		t.FailNow()
+	}
+}
+
+func TestStubWrapperSavepoint(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	stub.MockTransactionStart("setup")
+	stub.PutState("a", []byte("committed"))
+	stub.MockTransactionEnd("setup")
+
+	stub.MockTransactionStart("savepoint")
+	sw := &sw.StubWrapper{
+		Stub: stub,
+	}
+	sw.PutState("a", []byte("before"))
+	sp := sw.Savepoint()
+	sw.PutState("a", []byte("after"))
+	sw.PutState("b", []byte("after"))
+	sw.PutPrivateData("collection", "c", []byte("after"))
+	inner := sw.Savepoint()
+	sw.DelState("a")
+	err := sw.RollbackTo(inner)
+	if err != nil || string(sw.WriteSet["a"]) != "after" {
+		log.Println("nested rollback should restore the write", err)
+		t.FailNow()
+	}
+
+	err = sw.RollbackTo(sp)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	_, hasB := sw.WriteSet["b"]
+	_, hasC := sw.PvtWriteSet["collection"]["c"]
+	if string(sw.WriteSet["a"]) != "before" || hasB || hasC {
+		log.Println("unexpected write sets after rollback", sw.WriteSet, sw.PvtWriteSet)
+		t.FailNow()
+	}
+
+	sw.PutState("d", []byte("kept"))
+	err = sw.Flush()
+	stub.MockTransactionEnd("savepoint")
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// Rolled back keys are not written at all
+	if string(stub.State["a"]) != "before" || string(stub.State["d"]) != "kept" {
+		log.Println("unexpected state after flush", string(stub.State["a"]), string(stub.State["d"]))
+		t.FailNow()
+	}
+	if len(stub.History["b"]) != 0 || len(stub.History["a"]) != 2 || stub.PvtState["collection"]["c"] != nil {
+		log.Println("rolled back writes should not reach the ledger", stub.History)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+var authorizeTestTx = tx.Transaction{
+	Tag:    "registerPayment",
+	Label:  "Register Payment",
+	Method: "POST",
+	Args: tx.ArgList{
+		{
+			Tag:      "payer",
+			DataType: "string",
+			Required: true,
+		},
+		{
+			Tag:      "amount",
+			DataType: "number",
+			Required: true,
+		},
+	},
+	Authorize: func(stub *sw.StubWrapper, req map[string]interface{}, caller *accesscontrol.Identity) errors.ICCError {
+		if payer := req["payer"].(string); payer != caller.MSP {
+			return errors.NewCCError(fmt.Sprintf("%s cannot register payments of %s", caller.MSP, payer), 403)
+		}
+
+		if req["amount"].(float64) <= 0 {
+			return errors.NewCCError("amount must be positive", 400)
+		}
+
+		if req["amount"].(float64) > 1000 {
+			approver, _, err := caller.GetAttributeValue("approver")
+			if err != nil {
+				return errors.WrapErrorWithStatus(err, "failed to get approver attribute", 500)
+			}
+			if approver != "true" {
+				return errors.NewCCError("payments above 1000 require an approver", 403)
+			}
+		}
+
+		return nil
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		return []byte(`"registered"`), nil
+	},
+}
+
+func TestAuthorize(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), authorizeTestTx))
+
+	clerk := newTestCertStub(t, "bob", "org1MSP", "client", nil)
+	approver := newTestCertStub(t, "alice", "org1MSP", "client", map[string]string{"approver": "true"})
+
+	err := invokeAndVerify(clerk, "registerPayment", map[string]interface{}{"payer": "org1MSP", "amount": 100}, "registered", 200)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	err = invokeAndVerify(clerk, "registerPayment", map[string]interface{}{"payer": "org2MSP", "amount": 100},
+		"caller not authorized: org1MSP cannot register payments of org2MSP", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	err = invokeAndVerify(clerk, "registerPayment", map[string]interface{}{"payer": "org1MSP", "amount": 5000},
+		"caller not authorized: payments above 1000 require an approver", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	// Denied requests are always refused with status 403
+	err = invokeAndVerify(clerk, "registerPayment", map[string]interface{}{"payer": "org1MSP", "amount": -1},
+		"caller not authorized: amount must be positive", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	err = invokeAndVerify(approver, "registerPayment", map[string]interface{}{"payer": "org1MSP", "amount": 5000}, "registered", 200)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// Batch operations are authorized with their own args
+	results, status, msg := invokeBatch(clerk, map[string]interface{}{
+		"mode": "bestEffort",
+		"operations": []interface{}{
+			map[string]interface{}{"tx": "registerPayment", "args": map[string]interface{}{"payer": "org1MSP", "amount": 10}},
+			map[string]interface{}{"tx": "registerPayment", "args": map[string]interface{}{"payer": "org1MSP", "amount": 5000}},
+		},
+	})
+	if status != 200 {
+		log.Println(msg)
+		t.FailNow()
+	}
+	if len(results) != 2 || results[0].Status != 200 || results[1].Status != 403 || results[1].Message != "caller not authorized: payments above 1000 require an approver" {
+		log.Printf("unexpected batch results %#v", results)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,213 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+type batchResult struct {
+	Tx      string          `json:"tx"`
+	Status  int32           `json:"status"`
+	Result  json.RawMessage `json:"result"`
+	Message string          `json:"message"`
+}
+
+func invokeBatch(stub *mock.MockStub, req map[string]interface{}) ([]batchResult, int32, string) {
+	reqBytes, _ := json.Marshal(req)
+	res := stub.MockInvoke("batch", [][]byte{
+		[]byte("batch"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		return nil, res.GetStatus(), res.GetMessage()
+	}
+
+	var results []batchResult
+	err := json.Unmarshal(res.GetPayload(), &results)
+	if err != nil {
+		return nil, 500, err.Error()
+	}
+	return results, res.GetStatus(), ""
+}
+
+var batchPerson = map[string]interface{}{
+	"@assetType": "person",
+	"name":       "Maria",
+	"id":         "318.207.920-48",
+}
+
+var batchBook = map[string]interface{}{
+	"@assetType": "book",
+	"title":      "Meu Nome é Maria",
+	"author":     "Maria Viana",
+	"currentTenant": map[string]interface{}{
+		"@assetType": "person",
+		"id":         "318.207.920-48",
+	},
+}
+
+func TestBatchAtomic(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	req := map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{
+				"tx":   "createAsset",
+				"args": map[string]interface{}{"asset": []interface{}{batchPerson}},
+			},
+			// The book references the person created in the same batch
+			map[string]interface{}{
+				"tx":   "createAsset",
+				"args": map[string]interface{}{"asset": []interface{}{batchBook}},
+			},
+			map[string]interface{}{
+				"tx": "readAsset",
+				"args": map[string]interface{}{
+					"key": map[string]interface{}{"@assetType": "person", "id": "318.207.920-48"},
+				},
+			},
+		},
+	}
+	results, status, msg := invokeBatch(stub, req)
+	if status != 200 {
+		log.Println(msg)
+		t.FailNow()
+	}
+	if len(results) != 3 {
+		log.Println("expected 3 results, got", len(results))
+		t.FailNow()
+	}
+	for _, result := range results {
+		if result.Status != 200 || len(result.Result) == 0 {
+			log.Printf("unexpected result %#v\n", result)
+			t.FailNow()
+		}
+	}
+
+	var person map[string]interface{}
+	json.Unmarshal(results[2].Result, &person)
+	if person["name"] != "Maria" {
+		log.Println("unexpected readAsset result", person)
+		t.FailNow()
+	}
+
+	if isEmpty(stub, "person:47061146-c642-51a1-844a-bf0b17cb5e19") || isEmpty(stub, "book:a36a2920-c405-51c3-b584-dcd758338cb5") {
+		log.Println("batch writes should be committed")
+		t.FailNow()
+	}
+}
+
+func TestBatchAtomicFailure(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	req := map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{
+				"tx":   "createAsset",
+				"args": map[string]interface{}{"asset": []interface{}{batchPerson}},
+			},
+			map[string]interface{}{
+				"tx":   "createAsset",
+				"args": map[string]interface{}{"asset": []interface{}{batchPerson}},
+			},
+		},
+	}
+	_, status, msg := invokeBatch(stub, req)
+	if status != 409 {
+		log.Println("expected 409, got", status, msg)
+		t.FailNow()
+	}
+
+	if !isEmpty(stub, "person:47061146-c642-51a1-844a-bf0b17cb5e19") {
+		log.Println("a failed atomic batch must not write anything")
+		t.FailNow()
+	}
+}
+
+func TestBatchBestEffort(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	req := map[string]interface{}{
+		"mode": "bestEffort",
+		"operations": []interface{}{
+			map[string]interface{}{
+				"tx":   "createAsset",
+				"args": map[string]interface{}{"asset": []interface{}{batchPerson}},
+			},
+			// Fails after writing the book, which must be discarded
+			map[string]interface{}{
+				"tx": "createAsset",
+				"args": map[string]interface{}{"asset": []interface{}{
+					map[string]interface{}{
+						"@assetType": "book",
+						"title":      "Meu Nome é Maria",
+						"author":     "Maria Viana",
+					},
+					batchPerson,
+				}},
+			},
+			map[string]interface{}{
+				"tx": "unknownTx",
+			},
+			map[string]interface{}{
+				"tx":   "batch",
+				"args": map[string]interface{}{"operations": []interface{}{}},
+			},
+			map[string]interface{}{
+				"tx": "updateAsset",
+				"args": map[string]interface{}{
+					"update": map[string]interface{}{"@assetType": "person", "id": "318.207.920-48", "height": 1.7},
+				},
+			},
+		},
+	}
+	results, status, msg := invokeBatch(stub, req)
+	if status != 200 {
+		log.Println(msg)
+		t.FailNow()
+	}
+
+	expectedStatus := []int32{200, 409, 400, 400, 200}
+	if len(results) != len(expectedStatus) {
+		log.Println("expected", len(expectedStatus), "results, got", len(results))
+		t.FailNow()
+	}
+	for i, result := range results {
+		if result.Status != expectedStatus[i] {
+			log.Printf("unexpected result %d: %#v\n", i, result)
+			t.FailNow()
+		}
+		if result.Status != 200 && result.Message == "" {
+			log.Printf("failed operation %d should have a message\n", i)
+			t.FailNow()
+		}
+	}
+
+	var person map[string]interface{}
+	json.Unmarshal(stub.State["person:47061146-c642-51a1-844a-bf0b17cb5e19"], &person)
+	if person["height"] != 1.7 {
+		log.Println("valid operations should be written", person)
+		t.FailNow()
+	}
+	if !isEmpty(stub, "book:a36a2920-c405-51c3-b584-dcd758338cb5") {
+		log.Println("writes of failed operations should be discarded")
+		t.FailNow()
+	}
+}
+
+func TestBatchInvalidMode(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	req := map[string]interface{}{
+		"mode":       "sometimes",
+		"operations": []interface{}{map[string]interface{}{"tx": "getHeader"}},
+	}
+	err := invokeAndVerify(stub, "batch", req, "invalid batch mode 'sometimes'", 400)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+}
@@ -297,3 +297,38 @@ func TestCreatePrivate(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestCreateAssetRollback(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	person := map[string]interface{}{
+		"@assetType": "person",
+		"name":       "Maria",
+		"id":         "318.207.920-48",
+	}
+	invalidPerson := map[string]interface{}{
+		"@assetType": "person",
+		"id":         "318.207.920-48",
+	}
+	req := map[string]interface{}{
+		"asset": []map[string]interface{}{person, invalidPerson},
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("createAsset", [][]byte{
+		[]byte("createAsset"),
+		reqBytes,
+	})
+	if res.GetStatus() == 200 {
+		log.Println("expected createAsset to fail")
+		t.FailNow()
+	}
+
+	// The first asset must not be written when the transaction fails
+	if !isEmpty(stub, "person:47061146-c642-51a1-844a-bf0b17cb5e19") {
+		log.Println("state should be empty after a failed transaction")
+		t.FailNow()
+	}
+}
@@ -21,6 +21,16 @@ func TestGetDataTypes(t *testing.T) {
 			"acceptedFormats": nil,
 			"DropDownValues":  nil,
 		},
+		"language": map[string]interface{}{
+			"acceptedFormats": []interface{}{
+				"string",
+			},
+			"description": "Language of a book",
+			"DropDownValues": map[string]interface{}{
+				"English":    "en",
+				"Portuguese": "pt",
+			},
+		},
 		"datetime": map[string]interface{}{
 			"acceptedFormats": []interface{}{
 				"string",
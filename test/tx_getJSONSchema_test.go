@@ -0,0 +1,93 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/mock"
+)
+
+func getJSONSchema(stub *mock.MockStub, req map[string]interface{}) (map[string]interface{}, int32) {
+	reqBytes, _ := json.Marshal(req)
+	res := stub.MockInvoke("getJSONSchema", [][]byte{
+		[]byte("getJSONSchema"),
+		reqBytes,
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		return nil, res.GetStatus()
+	}
+
+	var schema map[string]interface{}
+	json.Unmarshal(res.GetPayload(), &schema)
+	return schema, res.GetStatus()
+}
+
+func TestGetJSONSchema(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	schema, status := getJSONSchema(stub, map[string]interface{}{"assetType": "person"})
+	if status != 200 || schema["title"] != "Person" || !reflect.DeepEqual(schema["required"], []interface{}{"@assetType", "id", "name"}) {
+		log.Println("unexpected asset type schema", schema)
+		t.FailNow()
+	}
+
+	schema, status = getJSONSchema(stub, map[string]interface{}{"txName": "readAsset"})
+	expectedSchema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "Read Asset",
+		"type":    "object",
+		"required": []interface{}{
+			"key",
+		},
+	}
+	if status != 200 {
+		t.FailNow()
+	}
+	for k, v := range expectedSchema {
+		if !reflect.DeepEqual(schema[k], v) {
+			log.Println("unexpected", k, schema[k])
+			t.FailNow()
+		}
+	}
+	keySchema := schema["properties"].(map[string]interface{})["key"].(map[string]interface{})
+	if len(keySchema["anyOf"].([]interface{})) != len(testAssetList) {
+		log.Println("key arg should accept keys of every asset type", keySchema)
+		t.FailNow()
+	}
+	if _, defined := schema["$defs"].(map[string]interface{})["book.key"]; !defined {
+		log.Println("key definitions should be included", schema["$defs"])
+		t.FailNow()
+	}
+
+	schema, status = getJSONSchema(stub, map[string]interface{}{"txName": "createAsset"})
+	assetArg := schema["properties"].(map[string]interface{})["asset"].(map[string]interface{})
+	if status != 200 || assetArg["type"] != "array" || assetArg["minItems"] != 1.0 {
+		log.Println("unexpected createAsset schema", assetArg)
+		t.FailNow()
+	}
+
+	schema, status = getJSONSchema(stub, nil)
+	defs, ok := schema["$defs"].(map[string]interface{})
+	if status != 200 || !ok || len(defs) < len(testAssetList) {
+		log.Println("unexpected schema list", schema)
+		t.FailNow()
+	}
+	for _, assetType := range testAssetList {
+		if _, defined := defs[assetType.Tag]; !defined {
+			log.Println("missing schema for", assetType.Tag)
+			t.FailNow()
+		}
+	}
+
+	_, status = getJSONSchema(stub, map[string]interface{}{"assetType": "magazine"})
+	if status != 404 {
+		t.FailNow()
+	}
+	_, status = getJSONSchema(stub, map[string]interface{}{"assetType": "book", "txName": "readAsset"})
+	if status != 400 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,212 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+func TestGetOpenAPI(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	res := stub.MockInvoke("getOpenAPI", [][]byte{
+		[]byte("getOpenAPI"),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	if strings.Contains(string(res.GetPayload()), "#/$defs/") {
+		log.Println("references should point to the document components")
+		t.FailNow()
+	}
+
+	var doc map[string]interface{}
+	json.Unmarshal(res.GetPayload(), &doc)
+	if doc["openapi"] != "3.1.0" || doc["info"].(map[string]interface{})["title"] != "CC Tools Test" {
+		log.Println("unexpected document header", doc["openapi"], doc["info"])
+		t.FailNow()
+	}
+
+	paths := doc["paths"].(map[string]interface{})
+	getOperation := func(path, method string) map[string]interface{} {
+		pathItem, ok := paths[path].(map[string]interface{})
+		if !ok {
+			log.Println("missing path", path)
+			t.FailNow()
+		}
+		operation, ok := pathItem[method].(map[string]interface{})
+		if !ok {
+			log.Println("missing operation", method, path)
+			t.FailNow()
+		}
+		return operation
+	}
+	responseCodes := func(operation map[string]interface{}) []string {
+		codes := []string{}
+		for _, code := range []string{"200", "400", "403", "404", "409", "500"} {
+			if _, ok := operation["responses"].(map[string]interface{})[code]; ok {
+				codes = append(codes, code)
+			}
+		}
+		return codes
+	}
+
+	createAsset := getOperation("/api/invoke/createAsset", "post")
+	if createAsset["x-cc-tools-tx"] != "createAsset" || createAsset["requestBody"] == nil {
+		log.Println("unexpected createAsset operation", createAsset)
+		t.FailNow()
+	}
+	if codes := responseCodes(createAsset); !reflect.DeepEqual(codes, []string{"200", "400", "403", "409", "500"}) {
+		log.Println("unexpected createAsset responses", codes)
+		t.FailNow()
+	}
+
+	readAsset := getOperation("/api/query/readAsset", "get")
+	param := readAsset["parameters"].([]interface{})[0].(map[string]interface{})
+	if param["name"] != "@request" || param["in"] != "query" || param["required"] != true {
+		log.Println("unexpected readAsset parameter", param)
+		t.FailNow()
+	}
+	if codes := responseCodes(readAsset); !reflect.DeepEqual(codes, []string{"200", "400", "404", "500"}) {
+		log.Println("unexpected readAsset responses", codes)
+		t.FailNow()
+	}
+
+	// Asset type CRUD paths
+	for _, method := range []string{"post", "get", "put", "delete"} {
+		getOperation("/api/assets/book", method)
+	}
+	getOperation("/api/assets/book/history", "get")
+	createBook := getOperation("/api/assets/book", "post")
+	schema := createBook["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	items := schema["properties"].(map[string]interface{})["asset"].(map[string]interface{})["items"]
+	if !reflect.DeepEqual(items, map[string]interface{}{"$ref": "#/components/schemas/book"}) {
+		log.Println("unexpected createAsset book schema", items)
+		t.FailNow()
+	}
+
+	components := doc["components"].(map[string]interface{})
+	schemas := components["schemas"].(map[string]interface{})
+	for _, name := range []string{"person", "book", "person.key", "dataType.language", "error"} {
+		if _, ok := schemas[name]; !ok {
+			log.Println("missing schema", name)
+			t.FailNow()
+		}
+	}
+	if _, ok := components["responses"].(map[string]interface{})["NotFound"]; !ok {
+		log.Println("missing error responses", components["responses"])
+		t.FailNow()
+	}
+
+	webhook := doc["webhooks"].(map[string]interface{})["createLibraryLog"].(map[string]interface{})["post"].(map[string]interface{})
+	if webhook["x-cc-tools-event"].(map[string]interface{})["type"] != "log" {
+		log.Println("unexpected event webhook", webhook)
+		t.FailNow()
+	}
+}
+
+func TestOpenAPISecurity(t *testing.T) {
+	restrictedTx := tx.Transaction{
+		Tag:    "restrictedTx",
+		Label:  "Restricted Tx",
+		Method: "POST",
+		Callers: []accesscontrol.Caller{
+			{MSP: "org1MSP", OU: "admin"},
+			{MSP: `$org\dMSP`, Attributes: map[string]string{"role": "auditor", "level": "2"}},
+		},
+		Args: tx.ArgList{},
+	}
+	tx.InitTxList(append([]tx.Transaction{restrictedTx}, testTxList...))
+	defer tx.InitTxList(testTxList)
+
+	paths := tx.OpenAPI()["paths"].(map[string]interface{})
+	operation := paths["/api/invoke/restrictedTx"].(map[string]interface{})["post"].(map[string]interface{})
+	expectedSecurity := []interface{}{
+		map[string]interface{}{"fabricIdentity": []string{"msp:org1MSP", "ou:admin"}},
+		map[string]interface{}{"fabricIdentity": []string{`msp:$org\dMSP`, "attr:level=2", "attr:role=auditor"}},
+	}
+	if !reflect.DeepEqual(operation["security"], expectedSecurity) {
+		log.Println("unexpected security requirements", operation["security"])
+		t.FailNow()
+	}
+	if _, forbidden := operation["responses"].(map[string]interface{})["403"]; !forbidden {
+		log.Println("restricted tx should document 403 responses")
+		t.FailNow()
+	}
+}
+
+func TestOpenAPIComponents(t *testing.T) {
+	doc := tx.OpenAPI()
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(docJSON, &decoded)
+
+	// Component names must match the pattern required by the OpenAPI specification
+	validName := regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+	components := decoded["components"].(map[string]interface{})
+	for section, entries := range components {
+		for name := range entries.(map[string]interface{}) {
+			if !validName.MatchString(name) {
+				log.Printf("invalid component name %s in %s", name, section)
+				t.FailNow()
+			}
+		}
+	}
+	schemas := components["schemas"].(map[string]interface{})
+	for _, name := range []string{"error", "asset.key", "dataType.object"} {
+		if _, ok := schemas[name]; !ok {
+			log.Println("missing schema", name)
+			t.FailNow()
+		}
+	}
+
+	// Every reference must resolve to a component of the document
+	var checkRefs func(v interface{})
+	checkRefs = func(v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for k, elem := range value {
+				if ref, isString := elem.(string); isString && k == "$ref" {
+					parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+					section, _ := components[parts[0]].(map[string]interface{})
+					if len(parts) != 2 || section[parts[1]] == nil {
+						log.Println("unresolved reference", ref)
+						t.FailNow()
+					}
+					continue
+				}
+				checkRefs(elem)
+			}
+		case []interface{}:
+			for _, elem := range value {
+				checkRefs(elem)
+			}
+		}
+	}
+	checkRefs(decoded)
+
+	// Operation IDs must be unique
+	operationIDs := map[string]bool{}
+	for _, pathItem := range decoded["paths"].(map[string]interface{}) {
+		for _, operation := range pathItem.(map[string]interface{}) {
+			id := operation.(map[string]interface{})["operationId"].(string)
+			if operationIDs[id] {
+				log.Println("duplicate operation id", id)
+				t.FailNow()
+			}
+			operationIDs[id] = true
+		}
+	}
+}
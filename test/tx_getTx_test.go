@@ -46,6 +46,16 @@ func TestGetTx(t *testing.T) {
 			"label":       "Load Asset Type List from blockchain",
 			"tag":         "loadAssetTypeList",
 		},
+		map[string]interface{}{
+			"description": "Batch runs a list of transactions as a single transaction",
+			"label":       "Batch",
+			"tag":         "batch",
+		},
+		map[string]interface{}{
+			"description": "TransferOwnership records a new owner for an asset of an owned asset type",
+			"label":       "Transfer Ownership",
+			"tag":         "transferOwnership",
+		},
 		map[string]interface{}{
 			"description": "",
 			"label":       "Get Tx",
@@ -61,6 +71,16 @@ func TestGetTx(t *testing.T) {
 			"label":       "Get Schema",
 			"tag":         "getSchema",
 		},
+		map[string]interface{}{
+			"description": "GetJSONSchema returns Draft 2020-12 JSON Schemas of asset types and transaction arguments",
+			"label":       "Get JSON Schema",
+			"tag":         "getJSONSchema",
+		},
+		map[string]interface{}{
+			"description": "GetOpenAPI returns an OpenAPI 3.1 document generated from the transactions, asset types, data types and events",
+			"label":       "Get OpenAPI",
+			"tag":         "getOpenAPI",
+		},
 		map[string]interface{}{
 			"description": "GetDataTypes returns the primary data type map",
 			"label":       "Get DataTypes",
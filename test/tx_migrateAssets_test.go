@@ -0,0 +1,361 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+// migrateAssetsTestTx is the migrateAssets tx restricted to org1MSP, as chaincodes must add it
+var migrateAssetsTestTx = func() tx.Transaction {
+	migrateAssets := tx.MigrateAssets
+	migrateAssets.Callers = []accesscontrol.Caller{{MSP: "org1MSP"}}
+	return migrateAssets
+}()
+
+var ticketV0 = assets.AssetType{
+	Tag:         "ticket",
+	Label:       "Ticket",
+	Description: "Support ticket",
+
+	Props: []assets.AssetProp{
+		{
+			Required: true,
+			IsKey:    true,
+			Tag:      "code",
+			Label:    "Code",
+			DataType: "string",
+		},
+		{
+			Tag:      "title",
+			Label:    "Title",
+			DataType: "string",
+		},
+	},
+}
+
+// ticketV1 renames "title" to "summary" and adds the required "priority" prop
+var ticketV1 = assets.AssetType{
+	Tag:         "ticket",
+	Label:       "Ticket",
+	Description: "Support ticket",
+	Version:     1,
+
+	Props: []assets.AssetProp{
+		{
+			Required: true,
+			IsKey:    true,
+			Tag:      "code",
+			Label:    "Code",
+			DataType: "string",
+		},
+		{
+			Tag:      "summary",
+			Label:    "Summary",
+			DataType: "string",
+		},
+		{
+			Required: true,
+			Tag:      "priority",
+			Label:    "Priority",
+			DataType: "integer",
+		},
+	},
+	Migrations: map[int]assets.Migration{
+		0: func(old assets.Asset) (assets.Asset, error) {
+			old["summary"] = old["title"]
+			delete(old, "title")
+			old["priority"] = 1
+			return old, nil
+		},
+	},
+}
+
+func TestMigrateAssets(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), migrateAssetsTestTx))
+
+	assets.InitAssetList(append([]assets.AssetType{ticketV0}, testAssetList...))
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	keys := []string{}
+	for _, code := range []string{"T1", "T2", "T3"} {
+		req := map[string]interface{}{
+			"asset": []map[string]interface{}{
+				{
+					"@assetType": "ticket",
+					"code":       code,
+					"title":      "Ticket " + code,
+				},
+			},
+		}
+		reqBytes, _ := json.Marshal(req)
+		res := stub.MockInvoke("createAsset", [][]byte{[]byte("createAsset"), reqBytes})
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+			t.FailNow()
+		}
+
+		var created []map[string]interface{}
+		err := json.Unmarshal(res.GetPayload(), &created)
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+		if _, stamped := created[0]["@schemaVersion"]; stamped {
+			log.Println("unversioned asset type should not stamp @schemaVersion")
+			t.FailNow()
+		}
+		keys = append(keys, created[0]["@key"].(string))
+	}
+
+	assets.InitAssetList(append([]assets.AssetType{ticketV1}, testAssetList...))
+
+	req := map[string]interface{}{
+		"assetType": "ticket",
+		"pageSize":  2,
+	}
+	err := invokeAndVerify(stub, "migrateAssets", req, map[string]interface{}{
+		"assetType": "ticket",
+		"version":   1.0,
+		"lastKey":   sortedKeys(keys)[1],
+		"migrated":  2.0,
+		"done":      false,
+	}, 200)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	err = invokeAndVerify(stub, "migrateAssets", req, map[string]interface{}{
+		"assetType": "ticket",
+		"version":   1.0,
+		"lastKey":   sortedKeys(keys)[2],
+		"migrated":  3.0,
+		"done":      true,
+	}, 200)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	err = invokeAndVerify(stub, "migrateAssets", req, "failed to migrate assets: assets of type 'ticket' were already migrated to version 1", 409)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	for _, key := range keys {
+		var state map[string]interface{}
+		err = json.Unmarshal(stub.State[key], &state)
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+		if _, hasTitle := state["title"]; hasTitle || state["summary"] == nil || state["priority"] != 1.0 || state["@schemaVersion"] != 1.0 {
+			log.Println("asset was not migrated", state)
+			t.FailNow()
+		}
+	}
+
+	// New assets are written with the current version
+	req = map[string]interface{}{
+		"asset": []map[string]interface{}{
+			{
+				"@assetType": "ticket",
+				"code":       "T4",
+				"priority":   2,
+			},
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+	res := stub.MockInvoke("createAsset", [][]byte{[]byte("createAsset"), reqBytes})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	var created []map[string]interface{}
+	err = json.Unmarshal(res.GetPayload(), &created)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if created[0]["@schemaVersion"] != 1.0 {
+		log.Println("expected @schemaVersion 1, got", created[0]["@schemaVersion"])
+		t.FailNow()
+	}
+}
+
+func TestMigrateAssetsUnversioned(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), migrateAssetsTestTx))
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	req := map[string]interface{}{
+		"assetType": "person",
+	}
+	err := invokeAndVerify(stub, "migrateAssets", req, "failed to migrate assets: asset type 'person' is not versioned", 400)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	req["assetType"] = "inexistent"
+	err = invokeAndVerify(stub, "migrateAssets", req, "failed to migrate assets: asset type named inexistent does not exist", 404)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+}
+
+func TestMigrateAssetsCallers(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), migrateAssetsTestTx))
+
+	stub := mock.NewMockStub("org2MSP", new(testCC))
+	req := map[string]interface{}{
+		"assetType": "person",
+	}
+	err := invokeAndVerify(stub, "migrateAssets", req, "current caller not allowed", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// Chaincodes must restrict the callers of migrateAssets
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), tx.MigrateAssets))
+	startupErr := tx.StartupCheck()
+	if startupErr == nil || startupErr.Message() != "tx migrateAssets must restrict its callers" {
+		log.Println("expected startup check to fail, got", startupErr)
+		t.FailNow()
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+
+	noop := func(old assets.Asset) (assets.Asset, error) { return old, nil }
+
+	ticketV3 := ticketV1
+	ticketV3.Version = 3
+	ticketV3.Migrations = map[int]assets.Migration{0: noop, 2: noop}
+	assets.InitAssetList(append([]assets.AssetType{ticketV3}, testAssetList...))
+	err := assets.StartupCheck()
+	if err == nil || err.Message() != "asset type 'ticket' has no migration from version 1" {
+		log.Println("expected missing migration error, got", err)
+		t.FailNow()
+	}
+
+	ticketV3.Migrations = map[int]assets.Migration{1: noop, 2: noop, 3: noop}
+	assets.InitAssetList(append([]assets.AssetType{ticketV3}, testAssetList...))
+	err = assets.StartupCheck()
+	if err == nil || err.Message() != "asset type 'ticket' has migration from version 3, out of the range [0, 3)" {
+		log.Println("expected out of range migration error, got", err)
+		t.FailNow()
+	}
+
+	// Assets older than the first migration cannot be upgraded
+	ticketV3.Migrations = map[int]assets.Migration{1: noop, 2: noop}
+	asset := assets.Asset{
+		"@assetType":     "ticket",
+		"@schemaVersion": 1,
+		"code":           "T1",
+		"priority":       1,
+	}
+	migrated, err := ticketV3.Migrate(asset)
+	if err != nil || migrated.SchemaVersion() != 3 {
+		log.Println("expected asset to be migrated to version 3, got", migrated, err)
+		t.FailNow()
+	}
+
+	asset["@schemaVersion"] = 0
+	_, err = ticketV3.Migrate(asset)
+	if err == nil || err.Message() != "asset type 'ticket' has no migration from version 0" {
+		log.Println("expected missing migration error, got", err)
+		t.FailNow()
+	}
+}
+
+func sortedKeys(keys []string) []string {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestMigrateHybridAssets(t *testing.T) {
+	defer assets.InitAssetList(testAssetList)
+	defer tx.InitTxList(testTxList)
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), migrateAssetsTestTx))
+	assets.InitAssetList(append([]assets.AssetType{hybridTestAssetType}, testAssetList...))
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	stub.CollectionMembers["clinicCollection"] = []string{"org1MSP"}
+	res := stub.MockInvoke("createPatient", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{map[string]interface{}{
+			"@assetType": "patient",
+			"id":         "P1",
+			"name":       "Maria",
+			"diagnosis":  "Flu",
+		}}}),
+	})
+	var created []map[string]interface{}
+	_ = json.Unmarshal(res.GetPayload(), &created)
+	if res.GetStatus() != 200 || len(created) != 1 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	key := created[0]["@key"].(string)
+
+	// Version 1 renames the private "diagnosis" prop to "condition"
+	patientV1 := hybridTestAssetType
+	patientV1.Version = 1
+	patientV1.Props = append([]assets.AssetProp{}, hybridTestAssetType.Props...)
+	patientV1.Props[2].Tag = "condition"
+	patientV1.Props[2].Label = "Condition"
+	patientV1.Migrations = map[int]assets.Migration{
+		0: func(old assets.Asset) (assets.Asset, error) {
+			old["condition"] = old["diagnosis"]
+			delete(old, "diagnosis")
+			return old, nil
+		},
+	}
+	assets.InitAssetList(append([]assets.AssetType{patientV1}, testAssetList...))
+
+	// Private props of collections the caller cannot read cannot be migrated
+	stub.CollectionMembers["clinicCollection"] = []string{"org2MSP"}
+	req := map[string]interface{}{
+		"assetType": "patient",
+	}
+	err := invokeAndVerify(stub, "migrateAssets", req, "failed to migrate assets: cannot migrate asset "+key+" without access to collection clinicCollection", 403)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	stub.CollectionMembers["clinicCollection"] = []string{"org1MSP"}
+	res = stub.MockInvoke("migratePatients", [][]byte{[]byte("migrateAssets"), mustMarshal(req)})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	var public, clinic map[string]interface{}
+	_ = json.Unmarshal(stub.State[key], &public)
+	_ = json.Unmarshal(stub.PvtState["clinicCollection"][key], &clinic)
+	if _, hasCondition := public["condition"]; hasCondition || public["@schemaVersion"] != 1.0 {
+		log.Println("unexpected public state", public)
+		t.FailNow()
+	}
+	if _, hasDiagnosis := clinic["diagnosis"]; hasDiagnosis || clinic["condition"] != "Flu" {
+		log.Println("private props were not migrated", clinic)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+func TestReadAssetHistory(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	person := map[string]interface{}{
+		"@assetType": "person",
+		"name":       "Maria",
+		"id":         "318.207.920-48",
+	}
+	personKey := map[string]interface{}{
+		"@assetType": "person",
+		"id":         "318.207.920-48",
+	}
+
+	res := stub.MockInvoke("createHistory", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{person}}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	update := map[string]interface{}{
+		"@assetType": "person",
+		"id":         "318.207.920-48",
+		"height":     1.66,
+	}
+	res = stub.MockInvoke("updateHistory", [][]byte{
+		[]byte("updateAsset"),
+		mustMarshal(map[string]interface{}{"update": update}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("deleteHistory", [][]byte{
+		[]byte("deleteAsset"),
+		mustMarshal(map[string]interface{}{"key": personKey}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("readHistory", [][]byte{
+		[]byte("readAssetHistory"),
+		mustMarshal(map[string]interface{}{"key": personKey}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	var history []map[string]interface{}
+	err := json.Unmarshal(res.GetPayload(), &history)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// History is returned from the newest to the oldest version
+	expectedTxIDs := []string{"deleteHistory", "updateHistory", "createHistory"}
+	expectedDeletes := []bool{true, false, false}
+	if len(history) != len(expectedTxIDs) {
+		log.Println("expected", len(expectedTxIDs), "entries, got", len(history))
+		t.FailNow()
+	}
+	for i, entry := range history {
+		if entry["_txId"] != expectedTxIDs[i] || entry["_isDelete"] != expectedDeletes[i] {
+			log.Printf("unexpected history entry %d: %#v\n", i, entry)
+			t.FailNow()
+		}
+		if _, err := time.Parse(time.RFC3339, entry["_timestamp"].(string)); err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+	}
+	if history[1]["height"] != 1.66 || history[2]["height"] != 0.0 {
+		log.Println("unexpected history values")
+		t.FailNow()
+	}
+}
+
+func TestReadAssetHistoryNotFound(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+
+	req := map[string]interface{}{
+		"key": map[string]interface{}{
+			"@assetType": "person",
+			"id":         "318.207.920-48",
+		},
+	}
+	err := invokeAndVerify(stub, "readAssetHistory", req, "history not found", 404)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestAssetHistoryDeletes(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	personKey := map[string]interface{}{
+		"@assetType": "person",
+		"id":         "318.207.920-48",
+	}
+
+	res := stub.MockInvoke("createHistory", [][]byte{
+		[]byte("createAsset"),
+		mustMarshal(map[string]interface{}{"asset": []interface{}{map[string]interface{}{
+			"@assetType": "person",
+			"name":       "Maria",
+			"id":         "318.207.920-48",
+		}}}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+	res = stub.MockInvoke("deleteHistory", [][]byte{
+		[]byte("deleteAsset"),
+		mustMarshal(map[string]interface{}{"key": personKey}),
+	})
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	key, _ := assets.NewKey(personKey)
+	stub.MockTransactionStart("readHistory")
+	history, err := assets.History(&sw.StubWrapper{Stub: stub}, key.Key(), false)
+	stub.MockTransactionEnd("readHistory")
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	// Deletions are returned flagged, without a value, most recent first
+	if len(history.Result) != 2 || history.Result[1]["name"] != "Maria" || history.Result[1]["_isDelete"] != nil {
+		log.Println("unexpected history", history.Result)
+		t.FailNow()
+	}
+	deleted := history.Result[0]
+	if deleted["_isDelete"] != true || deleted["_txId"] != "deleteHistory" || deleted["@key"] != key.Key() || deleted["name"] != nil {
+		log.Println("unexpected deletion entry", deleted)
+		t.FailNow()
+	}
+}
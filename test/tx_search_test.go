@@ -2,6 +2,8 @@ package test
 
 import (
 	"encoding/json"
+	"log"
+	"reflect"
 	"testing"
 
 	"github.com/hyperledger-labs/cc-tools/mock"
@@ -41,3 +43,131 @@ func TestSearchEmptyQuery(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func setupSearchState(stub *mock.MockStub) []map[string]interface{} {
+	people := []map[string]interface{}{
+		{
+			"@key":         "person:47061146-c642-51a1-844a-bf0b17cb5e19",
+			"@lastTouchBy": "org1MSP",
+			"@lastTx":      "createAsset",
+			"@assetType":   "person",
+			"name":         "Maria",
+			"id":           "31820792048",
+			"height":       1.6,
+		},
+		{
+			"@key":         "person:5d8bc8e1-7a36-5ef7-a1ac-e4a5b6b81e61",
+			"@lastTouchBy": "org1MSP",
+			"@lastTx":      "createAsset",
+			"@assetType":   "person",
+			"name":         "Jose",
+			"id":           "05473512087",
+			"height":       1.8,
+		},
+		{
+			"@key":         "person:c4b4fe03-1d86-5ec2-9b5b-c8c7bd0e77b4",
+			"@lastTouchBy": "org1MSP",
+			"@lastTx":      "createAsset",
+			"@assetType":   "person",
+			"name":         "Ana",
+			"id":           "71243485051",
+			"height":       1.7,
+		},
+	}
+	for _, person := range people {
+		personJSON, _ := json.Marshal(person)
+		stub.State[person["@key"].(string)] = personJSON
+	}
+	stub.State["library:3cab201f-9e2b-579d-b7b2-72297ed17c49"] = []byte(`{"@assetType":"library","@key":"library:3cab201f-9e2b-579d-b7b2-72297ed17c49","name":"Biblioteca"}`)
+
+	return people
+}
+
+func TestSearch(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	people := setupSearchState(stub)
+
+	req := map[string]interface{}{
+		"query": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"@assetType": "person",
+				"height": map[string]interface{}{
+					"$gt": 1.65,
+				},
+			},
+			"sort": []interface{}{
+				map[string]interface{}{"height": "desc"},
+			},
+		},
+	}
+	expectedResponse := map[string]interface{}{
+		"result":   []interface{}{toInterfaceMap(people[1]), toInterfaceMap(people[2])},
+		"metadata": nil,
+	}
+
+	err := invokeAndVerify(stub, "search", req, expectedResponse, 200)
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+}
+
+func TestSearchWithPagination(t *testing.T) {
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	people := setupSearchState(stub)
+
+	expectedPages := [][]interface{}{
+		{toInterfaceMap(people[0]), toInterfaceMap(people[1])},
+		{toInterfaceMap(people[2])},
+	}
+
+	bookmark := ""
+	for _, expectedResult := range expectedPages {
+		query := map[string]interface{}{
+			"selector": map[string]interface{}{
+				"@assetType": "person",
+			},
+			"limit": 2,
+		}
+		if bookmark != "" {
+			query["bookmark"] = bookmark
+		}
+		reqBytes, _ := json.Marshal(map[string]interface{}{"query": query})
+		res := stub.MockInvoke("search", [][]byte{
+			[]byte("search"),
+			reqBytes,
+		})
+		if res.GetStatus() != 200 {
+			log.Println(res.GetMessage())
+			t.FailNow()
+		}
+
+		var response struct {
+			Result   []interface{}          `json:"result"`
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		err := json.Unmarshal(res.GetPayload(), &response)
+		if err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(response.Result, expectedResult) {
+			log.Println("these should be equal")
+			log.Printf("%#v\n", response.Result)
+			log.Printf("%#v\n", expectedResult)
+			t.FailNow()
+		}
+		if response.Metadata["fetched_records_count"] != float64(len(expectedResult)) {
+			log.Println("unexpected metadata", response.Metadata)
+			t.FailNow()
+		}
+		bookmark, _ = response.Metadata["bookmark"].(string)
+	}
+}
+
+func toInterfaceMap(in map[string]interface{}) map[string]interface{} {
+	var out map[string]interface{}
+	inJSON, _ := json.Marshal(in)
+	json.Unmarshal(inJSON, &out)
+	return out
+}
@@ -0,0 +1,297 @@
+package test
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/mock"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	tx "github.com/hyperledger-labs/cc-tools/transactions"
+)
+
+var setRiskLimitTx = tx.Transaction{
+	Tag:    "setRiskLimit",
+	Label:  "Set Risk Limit",
+	Method: "PUT",
+	Quorum: &tx.Quorum{
+		Approvers:  []accesscontrol.Caller{{MSP: `$org[123]MSP`}},
+		Approvals:  2,
+		Rejections: 2,
+	},
+	Args: tx.ArgList{
+		{
+			Tag:      "limit",
+			DataType: "integer",
+			Required: true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		limit := req["limit"].(int64)
+		if limit < 0 {
+			return nil, errors.NewCCError("limit cannot be negative", 400)
+		}
+		err := stub.PutState("riskLimit", []byte(strconv.FormatInt(limit, 10)))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(`"limit set"`), nil
+	},
+}
+
+func initProposalTxList(t *testing.T, txs ...tx.Transaction) {
+	tx.InitTxList(append(append([]tx.Transaction{}, testTxList...), txs...))
+	err := tx.StartupCheck()
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+}
+
+func TestTxProposal(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	initProposalTxList(t, setRiskLimitTx)
+
+	state := map[string][]byte{}
+	stubs := map[string]*mock.MockStub{}
+	for _, msp := range []string{"org1MSP", "org2MSP", "org3MSP", "org4MSP"} {
+		stubs[msp] = mock.NewMockStub(msp, new(testCC))
+		stubs[msp].State = state
+	}
+	// Proposals are identified by the tx ID, so each invoke needs its own
+	txCount := 0
+	invoke := func(msp, txName string, req map[string]interface{}) (map[string]interface{}, int32, string) {
+		txCount++
+		res := stubs[msp].MockInvoke(txName+strconv.Itoa(txCount), [][]byte{[]byte(txName), mustMarshal(req)})
+		var payload map[string]interface{}
+		_ = json.Unmarshal(res.GetPayload(), &payload)
+		return payload, res.GetStatus(), res.GetMessage()
+	}
+
+	_, status, msg := invoke("org1MSP", "setRiskLimit", map[string]interface{}{"limit": 10})
+	if status != 403 || msg != "tx setRiskLimit requires approval, propose it with proposeTx" {
+		log.Println("expected direct call to be refused, got", status, msg)
+		t.FailNow()
+	}
+
+	// Proposals are validated when proposed
+	_, status, _ = invoke("org1MSP", "proposeTx", map[string]interface{}{"txName": "setRiskLimit", "args": map[string]interface{}{}})
+	if status != 400 {
+		log.Println("expected proposal with missing args to fail, got", status)
+		t.FailNow()
+	}
+	_, status, _ = invoke("org1MSP", "proposeTx", map[string]interface{}{"txName": "createAsset", "args": map[string]interface{}{}})
+	if status != 400 {
+		log.Println("expected proposal of tx without quorum to fail, got", status)
+		t.FailNow()
+	}
+
+	proposal, status, msg := invoke("org1MSP", "proposeTx", map[string]interface{}{"txName": "setRiskLimit", "args": map[string]interface{}{"limit": 10}})
+	if status != 200 || proposal["status"] != "pending" || proposal["proposer"] != "org1MSP" {
+		log.Println("unexpected proposal", status, msg, proposal)
+		t.FailNow()
+	}
+	proposalKey := map[string]interface{}{"proposal": map[string]interface{}{"@key": proposal["@key"]}}
+
+	for _, vote := range []struct {
+		msp    string
+		txName string
+		status int32
+	}{
+		{"org1MSP", "approveTx", 409}, // the proposer already approved
+		{"org4MSP", "approveTx", 403},
+		{"org2MSP", "approveTx", 200},
+		{"org3MSP", "approveTx", 409}, // the proposal was executed
+	} {
+		proposal, status, msg = invoke(vote.msp, vote.txName, proposalKey)
+		if status != vote.status {
+			log.Printf("expected %s by %s to return %d, got %d: %s", vote.txName, vote.msp, vote.status, status, msg)
+			t.FailNow()
+		}
+		if vote.msp == "org2MSP" && (proposal["status"] != "executed" || proposal["result"] != `"limit set"`) {
+			log.Println("proposal should be executed", proposal)
+			t.FailNow()
+		}
+	}
+	if string(state["riskLimit"]) != "10" {
+		log.Println("proposed tx should be run", string(state["riskLimit"]))
+		t.FailNow()
+	}
+
+	// Rejections
+	proposal, _, _ = invoke("org1MSP", "proposeTx", map[string]interface{}{"txName": "setRiskLimit", "args": map[string]interface{}{"limit": 20}})
+	proposalKey = map[string]interface{}{"proposal": map[string]interface{}{"@key": proposal["@key"]}}
+	proposal, _, _ = invoke("org2MSP", "rejectTx", proposalKey)
+	if proposal["status"] != "pending" {
+		log.Println("proposal should be pending until enough rejections", proposal)
+		t.FailNow()
+	}
+	proposal, _, _ = invoke("org3MSP", "rejectTx", proposalKey)
+	if proposal["status"] != "rejected" || string(state["riskLimit"]) != "10" {
+		log.Println("proposal should be rejected", proposal)
+		t.FailNow()
+	}
+
+	// Failed txs are recorded without their writes
+	proposal, _, _ = invoke("org1MSP", "proposeTx", map[string]interface{}{"txName": "setRiskLimit", "args": map[string]interface{}{"limit": -1}})
+	proposalKey = map[string]interface{}{"proposal": map[string]interface{}{"@key": proposal["@key"]}}
+	proposal, status, _ = invoke("org3MSP", "approveTx", proposalKey)
+	if status != 200 || proposal["status"] != "failed" || proposal["result"] != "limit cannot be negative" || string(state["riskLimit"]) != "10" {
+		log.Println("proposal should fail", proposal)
+		t.FailNow()
+	}
+
+	// Proposals are stored as assets
+	_, status, _ = invoke("org4MSP", "getSchema", map[string]interface{}{"assetType": "txProposal"})
+	if status != 200 {
+		log.Println("txProposal should be in the schema")
+		t.FailNow()
+	}
+	_, status, _ = invoke("org1MSP", "updateAsset", map[string]interface{}{"update": map[string]interface{}{"@key": proposal["@key"], "status": "pending"}})
+	if status != 403 {
+		log.Println("proposals should only be written by the proposal txs, got", status)
+		t.FailNow()
+	}
+}
+
+func TestTxProposalExpiry(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	expiring := setRiskLimitTx
+	expiring.Quorum = &tx.Quorum{
+		Approvers: setRiskLimitTx.Quorum.Approvers,
+		Approvals: 2,
+		Expiry:    time.Millisecond,
+	}
+	initProposalTxList(t, expiring)
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	res := stub.MockInvoke("proposeTx", [][]byte{[]byte("proposeTx"), mustMarshal(map[string]interface{}{"txName": "setRiskLimit", "args": map[string]interface{}{"limit": 10}})})
+	var proposal map[string]interface{}
+	_ = json.Unmarshal(res.GetPayload(), &proposal)
+	if res.GetStatus() != 200 || proposal["expiresAt"] == nil {
+		log.Println("unexpected proposal", res.GetMessage(), proposal)
+		t.FailNow()
+	}
+
+	// Late votes are not counted and mark the proposal as expired
+	time.Sleep(5 * time.Millisecond)
+	stub.Name = "org2MSP"
+	stub.Creator, _ = mock.NewMockStub("org2MSP", nil).GetCreator()
+	res = stub.MockInvoke("approveTx", [][]byte{[]byte("approveTx"), mustMarshal(map[string]interface{}{"proposal": map[string]interface{}{"@key": proposal["@key"]}})})
+	_ = json.Unmarshal(res.GetPayload(), &proposal)
+	if res.GetStatus() != 200 || proposal["status"] != "expired" || len(proposal["approvals"].([]interface{})) != 1 || stub.State["riskLimit"] != nil {
+		log.Println("expected late vote to expire the proposal, got", res.GetStatus(), res.GetMessage(), proposal)
+		t.FailNow()
+	}
+
+	stub.Name = "org3MSP"
+	stub.Creator, _ = mock.NewMockStub("org3MSP", nil).GetCreator()
+	res = stub.MockInvoke("rejectTx", [][]byte{[]byte("rejectTx"), mustMarshal(map[string]interface{}{"proposal": map[string]interface{}{"@key": proposal["@key"]}})})
+	if res.GetStatus() != 409 || res.GetMessage() != "proposal is expired" {
+		log.Println("expected expired proposal to refuse votes, got", res.GetStatus(), res.GetMessage())
+		t.FailNow()
+	}
+}
+
+func TestTxProposalExecutorCallers(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	restricted := setRiskLimitTx
+	restricted.Callers = []accesscontrol.Caller{{MSP: "org1MSP"}}
+	initProposalTxList(t, restricted)
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	res := stub.MockInvoke("proposeTx", [][]byte{[]byte("proposeTx"), mustMarshal(map[string]interface{}{"txName": "setRiskLimit", "args": map[string]interface{}{"limit": 10}})})
+	var proposal map[string]interface{}
+	_ = json.Unmarshal(res.GetPayload(), &proposal)
+	if res.GetStatus() != 200 {
+		log.Println(res.GetMessage())
+		t.FailNow()
+	}
+
+	// The tx runs with the identity of the last approver, which must be allowed to call it
+	stub.Name = "org2MSP"
+	stub.Creator, _ = mock.NewMockStub("org2MSP", nil).GetCreator()
+	res = stub.MockInvoke("approveTx", [][]byte{[]byte("approveTx"), mustMarshal(map[string]interface{}{"proposal": map[string]interface{}{"@key": proposal["@key"]}})})
+	_ = json.Unmarshal(res.GetPayload(), &proposal)
+	if res.GetStatus() != 200 || proposal["status"] != "failed" || stub.State["riskLimit"] != nil {
+		log.Println("expected proposal to fail for an approver which cannot call the tx", res.GetMessage(), proposal)
+		t.FailNow()
+	}
+}
+
+func TestTxProposalDisabled(t *testing.T) {
+	if tx.FetchTx("proposeTx") != nil || assets.FetchAssetType("txProposal") != nil {
+		log.Println("proposals should only be enabled by txs with a quorum")
+		t.FailNow()
+	}
+}
+
+func TestTxProposalPrivateArgs(t *testing.T) {
+	defer tx.InitTxList(testTxList)
+	defer assets.InitAssetList(testAssetList)
+	assets.InitAssetList(append([]assets.AssetType{hybridTestAssetType}, testAssetList...))
+	updatePatientTx := tx.Transaction{
+		Tag:    "reviewPatient",
+		Label:  "Review Patient",
+		Method: "PUT",
+		Quorum: setRiskLimitTx.Quorum,
+		Args: tx.ArgList{
+			{
+				Tag:      "update",
+				DataType: "@object",
+				Required: true,
+			},
+			{
+				Tag:      "notes",
+				DataType: "string",
+				Private:  true,
+			},
+		},
+		Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+			return nil, nil
+		},
+	}
+	initProposalTxList(t, updatePatientTx)
+
+	stub := mock.NewMockStub("org1MSP", new(testCC))
+	propose := func(args map[string]interface{}) (int32, string) {
+		res := stub.MockInvoke("proposeTx", [][]byte{[]byte("proposeTx"), mustMarshal(map[string]interface{}{"txName": "reviewPatient", "args": args})})
+		return res.GetStatus(), res.GetMessage()
+	}
+
+	// Proposals are public, so their args cannot have private data
+	for _, c := range []struct {
+		args map[string]interface{}
+		msg  string
+	}{
+		{
+			map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1", "diagnosis": "Flu"}},
+			"proposals of tx reviewPatient cannot have prop diagnosis of asset type patient stored in collection clinicCollection",
+		},
+		{
+			map[string]interface{}{"update": map[string]interface{}{"refs": []interface{}{map[string]interface{}{"@assetType": "secret", "secretName": "S1", "secret": "shh"}}}},
+			"proposals of tx reviewPatient cannot have assets of private asset type secret",
+		},
+		{
+			map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1"}, "notes": "confidential"},
+			"proposals of tx reviewPatient cannot have private arg notes",
+		},
+	} {
+		status, msg := propose(c.args)
+		if status != 400 || msg != c.msg {
+			log.Println("expected proposal to be refused with", c.msg, "got", status, msg)
+			t.FailNow()
+		}
+	}
+
+	status, msg := propose(map[string]interface{}{"update": map[string]interface{}{"@assetType": "patient", "id": "P1", "name": "Maria"}})
+	if status != 200 {
+		log.Println(msg)
+		t.FailNow()
+	}
+}
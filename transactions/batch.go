@@ -0,0 +1,143 @@
+package transactions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// BatchResult is the outcome of a single operation of a batch.
+type BatchResult struct {
+	Tx      string          `json:"tx"`
+	Status  int32           `json:"status"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Batch runs a list of transactions within a single Fabric transaction
+var Batch = Transaction{
+	Tag:         "batch",
+	Label:       "Batch",
+	Description: "Batch runs a list of transactions as a single transaction",
+	Method:      "POST",
+
+	MetaTx: true,
+	Args: ArgList{
+		{
+			Tag:         "operations",
+			Description: "List of operations in the format {\"tx\": <tx tag>, \"args\": <tx args>}.",
+			DataType:    "[]@object",
+			Required:    true,
+		},
+		{
+			Tag:         "mode",
+			Description: "Either 'atomic' (default), where any failed operation fails the batch, or 'bestEffort', where the writes of failed operations are discarded.",
+			DataType:    "string",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		// This is safe to do because validation is done before calling routine
+		operations := req["operations"].([]interface{})
+
+		mode, _ := req["mode"].(string)
+		if mode == "" {
+			mode = "atomic"
+		}
+		if mode != "atomic" && mode != "bestEffort" {
+			return nil, errors.NewCCError(fmt.Sprintf("invalid batch mode '%s'", mode), 400)
+		}
+
+		results := make([]BatchResult, 0, len(operations))
+		for i, opInterface := range operations {
+			// This is safe to do because validation is done before calling routine
+			op := opInterface.(map[string]interface{})
+			txName, _ := op["tx"].(string)
+
+			savepoint := stub.Savepoint()
+			response, err := runBatchOperation(stub, op)
+			if err != nil {
+				if mode == "atomic" {
+					return nil, errors.WrapErrorWithStatus(err, fmt.Sprintf("operation %d (%s) failed", i, txName), err.Status())
+				}
+
+				rollbackErr := stub.RollbackTo(savepoint)
+				if rollbackErr != nil {
+					return nil, errors.WrapError(rollbackErr, fmt.Sprintf("failed to discard writes of operation %d (%s)", i, txName))
+				}
+
+				results = append(results, BatchResult{
+					Tx:      txName,
+					Status:  err.Status(),
+					Message: err.Message(),
+				})
+				continue
+			}
+
+			result := BatchResult{
+				Tx:     txName,
+				Status: 200,
+			}
+			if json.Valid(response) {
+				result.Result = response
+			} else if len(response) > 0 {
+				result.Result, _ = json.Marshal(string(response))
+			}
+			results = append(results, result)
+		}
+
+		resultsJSON, err := json.Marshal(results)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to marshal response")
+		}
+
+		return resultsJSON, nil
+	},
+}
+
+// runBatchOperation validates and runs a single operation of a batch.
+func runBatchOperation(stub *sw.StubWrapper, op map[string]interface{}) ([]byte, errors.ICCError) {
+	txName, ok := op["tx"].(string)
+	if !ok {
+		return nil, errors.NewCCError("operation must have a 'tx' string", 400)
+	}
+
+	tx := FetchTx(txName)
+	if tx == nil {
+		return nil, errors.NewCCError(fmt.Sprintf("tx named %s does not exist", txName), 400)
+	}
+	if tx.Tag == "batch" {
+		return nil, errors.NewCCError("batch operations cannot be nested", 400)
+	}
+
+	var args map[string]interface{}
+	if argsInterface, exists := op["args"]; exists && argsInterface != nil {
+		args, ok = argsInterface.(map[string]interface{})
+		if !ok {
+			return nil, errors.NewCCError("operation 'args' must be an object", 400)
+		}
+	}
+
+	reqMap, err := tx.validateArgs(args, nil)
+	if err != nil {
+		return nil, errors.WrapError(err, "unable to get args")
+	}
+
+	err = tx.checkQuorum()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.checkCallers(stub.Stub)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.authorize(stub, reqMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.Routine(stub, reqMap)
+}
@@ -39,6 +39,11 @@ var CreateAsset = Transaction{
 				return nil, errors.WrapError(err, "failed to write asset to ledger")
 			}
 
+			err = assets.Redact(stub, res)
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to redact asset")
+			}
+
 			responses = append(responses, res)
 		}
 
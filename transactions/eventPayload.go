@@ -0,0 +1,45 @@
+package transactions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/events"
+)
+
+// eventArgList returns the payload schema of the event as an ArgList.
+func eventArgList(event events.Event) ArgList {
+	args := ArgList{}
+	for _, arg := range event.Args {
+		args = append(args, Argument{
+			Tag:         arg.Tag,
+			Label:       arg.Label,
+			Description: arg.Description,
+			DataType:    arg.DataType,
+			Required:    arg.Required,
+		})
+	}
+	return args
+}
+
+// validateEventPayload validates the payload against the event schema, if any.
+func validateEventPayload(event events.Event, payload []byte) errors.ICCError {
+	if len(event.Args) == 0 {
+		return nil
+	}
+
+	var req map[string]interface{}
+	err := json.Unmarshal(payload, &req)
+	if err != nil {
+		return errors.WrapErrorWithStatus(err, fmt.Sprintf("payload of event %s must be a JSON object", event.Tag), http.StatusBadRequest)
+	}
+
+	_, iccErr := Transaction{Args: eventArgList(event)}.validateArgs(req, nil)
+	if iccErr != nil {
+		return errors.WrapError(iccErr, "invalid event payload")
+	}
+
+	return nil
+}
@@ -49,6 +49,16 @@ var ExecuteEvent = Transaction{
 			return nil, errors.NewCCError("event is not of type 'EventCustom'", http.StatusBadRequest)
 		}
 
+		policyErr := event.CheckPolicy(stub)
+		if policyErr != nil {
+			return nil, policyErr
+		}
+
+		payloadErr := validateEventPayload(*event, payload)
+		if payloadErr != nil {
+			return nil, payloadErr
+		}
+
 		err := event.CustomFunction(stub, payload)
 		if err != nil {
 			return nil, errors.WrapError(err, "error executing custom function")
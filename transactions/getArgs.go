@@ -18,8 +18,6 @@ func (tx Transaction) GetArgs(stub shim.ChaincodeStubInterface) (map[string]inte
 	// Extract the function and args from the transaction proposal
 	_, args := stub.GetFunctionAndParameters()
 
-	reqMap := make(map[string]interface{})
-
 	// Prepare request arguments
 	var req map[string]interface{}
 	var transientReq map[string]interface{}
@@ -46,6 +44,14 @@ func (tx Transaction) GetArgs(stub shim.ChaincodeStubInterface) (map[string]inte
 		}
 	}
 
+	return tx.validateArgs(req, transientReq)
+}
+
+// validateArgs validates the public and transient request objects against the
+// tx argument definitions and assembles a map with the parsed key/values.
+func (tx Transaction) validateArgs(req, transientReq map[string]interface{}) (map[string]interface{}, errors.ICCError) {
+	reqMap := make(map[string]interface{})
+
 	cleanUp(req)
 	cleanUp(transientReq)
 
@@ -0,0 +1,74 @@
+package transactions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// GetJSONSchema returns the JSON Schema of an asset type, of the args of a tx or of every asset type
+var GetJSONSchema = Transaction{
+	Tag:         "getJSONSchema",
+	Label:       "Get JSON Schema",
+	Description: "GetJSONSchema returns Draft 2020-12 JSON Schemas of asset types and transaction arguments",
+	Method:      "GET",
+
+	ReadOnly: true,
+	MetaTx:   true,
+	Args: ArgList{
+		{
+			Tag:         "assetType",
+			DataType:    "string",
+			Description: "The name of the asset type of which you want to fetch the schema.",
+		},
+		{
+			Tag:         "txName",
+			DataType:    "string",
+			Description: "The name of the transaction of which you want to fetch the arguments schema. Leave both empty to fetch the schemas of every asset type.",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		assetTypeName, _ := req["assetType"].(string)
+		txName, _ := req["txName"].(string)
+
+		var schema map[string]interface{}
+		switch {
+		case assetTypeName != "" && txName != "":
+			return nil, errors.NewCCError("only one of 'assetType' and 'txName' can be set", 400)
+		case assetTypeName != "":
+			assetTypeDef := assets.FetchAssetType(assetTypeName)
+			if assetTypeDef == nil {
+				return nil, errors.NewCCError(fmt.Sprintf("asset type named %s does not exist", assetTypeName), 404)
+			}
+			schema = assetTypeDef.JSONSchema()
+		case txName != "":
+			txDef := FetchTx(txName)
+			if txDef == nil {
+				return nil, errors.NewCCError(fmt.Sprintf("transaction named %s does not exist", txName), 404)
+			}
+			schema = txDef.Args.JSONSchema()
+			schema["title"] = txDef.Label
+			if txDef.Description != "" {
+				schema["description"] = txDef.Description
+			}
+		default:
+			defs := map[string]interface{}{}
+			for _, assetTypeDef := range assets.AssetTypeList() {
+				assets.AssetJSONSchema(assetTypeDef.Tag, defs)
+			}
+			schema = map[string]interface{}{
+				"$schema": assets.JSONSchemaDialect,
+				"$defs":   defs,
+			}
+		}
+
+		schemaBytes, err := json.Marshal(schema)
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error marshaling schema", 500)
+		}
+		return schemaBytes, nil
+	},
+}
@@ -0,0 +1,27 @@
+package transactions
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// GetOpenAPI returns the OpenAPI document describing the chaincode's HTTP API
+var GetOpenAPI = Transaction{
+	Tag:         "getOpenAPI",
+	Label:       "Get OpenAPI",
+	Description: "GetOpenAPI returns an OpenAPI 3.1 document generated from the transactions, asset types, data types and events",
+	Method:      "GET",
+
+	ReadOnly: true,
+	MetaTx:   true,
+	Args:     ArgList{},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		docBytes, err := json.Marshal(OpenAPI())
+		if err != nil {
+			return nil, errors.WrapErrorWithStatus(err, "error marshaling OpenAPI document", 500)
+		}
+		return docBytes, nil
+	},
+}
@@ -0,0 +1,99 @@
+package transactions
+
+import (
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+)
+
+// JSONSchema returns the Draft 2020-12 JSON Schema of the request object accepted by a tx with these args.
+func (l ArgList) JSONSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+
+	schema := l.jsonSchema("", defs)
+	schema["$schema"] = assets.JSONSchemaDialect
+	if len(defs) > 0 {
+		schema["$defs"] = defs
+	}
+
+	return schema
+}
+
+// jsonSchema returns the schema of the request object, adding the definitions it references to defs.
+// If assetTypeTag is set, asset and key args only accept assets of that type.
+func (l ArgList) jsonSchema(assetTypeTag string, defs map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, arg := range l {
+		argSchema := argJSONSchema(arg.DataType, assetTypeTag, defs)
+		if arg.Label != "" {
+			argSchema["title"] = arg.Label
+		}
+		if arg.Description != "" {
+			argSchema["description"] = arg.Description
+		}
+		if arg.Required && strings.HasPrefix(arg.DataType, "[]") {
+			argSchema["minItems"] = 1
+		}
+		if arg.Private {
+			argSchema["x-cc-tools-private"] = true
+		}
+		properties[arg.Tag] = argSchema
+
+		if arg.Required {
+			required = append(required, arg.Tag)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// argJSONSchema returns the schema of an argument data type, adding the definitions it references to defs.
+func argJSONSchema(dataType, assetTypeTag string, defs map[string]interface{}) map[string]interface{} {
+	if strings.HasPrefix(dataType, "[]") {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": argJSONSchema(strings.TrimPrefix(dataType, "[]"), assetTypeTag, defs),
+		}
+	}
+
+	switch dataType {
+	case "@asset":
+		if assetTypeTag != "" {
+			return assets.AssetJSONSchema(assetTypeTag, defs)
+		}
+		oneOf := []interface{}{}
+		for _, assetType := range assets.AssetTypeList() {
+			oneOf = append(oneOf, assets.AssetJSONSchema(assetType.Tag, defs))
+		}
+		return map[string]interface{}{
+			"oneOf": oneOf,
+		}
+	case "@key", "@update":
+		if assetTypeTag != "" {
+			return assets.KeyJSONSchema(assetTypeTag, defs)
+		}
+		// A key with only "@key" is valid for every asset type, so the alternatives may overlap
+		anyOf := []interface{}{}
+		for _, assetType := range assets.AssetTypeList() {
+			anyOf = append(anyOf, assets.KeyJSONSchema(assetType.Tag, defs))
+		}
+		return map[string]interface{}{
+			"anyOf": anyOf,
+		}
+	case "@query":
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"selector": map[string]interface{}{"type": "object"},
+			},
+			"required": []string{"selector"},
+		}
+	}
+
+	return assets.DataTypeJSONSchema(dataType, defs)
+}
@@ -0,0 +1,59 @@
+package transactions
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// defaultMigrationPageSize is the number of assets migrated by each call when pageSize is not set
+const defaultMigrationPageSize = 100
+
+// MigrateAssets upgrades the assets of a type to the current version of the asset type.
+// Since it rewrites every asset of the type, it is not a basic tx: chaincodes must add it to
+// their tx list restricting its Callers or Policy to the organizations allowed to migrate assets.
+var MigrateAssets = Transaction{
+	Tag:         "migrateAssets",
+	Label:       "Migrate Assets",
+	Description: "MigrateAssets upgrades a page of assets of a type to the current asset type version, resuming from the previous call",
+	Method:      "POST",
+
+	MetaTx: true,
+	Args: ArgList{
+		{
+			Tag:         "assetType",
+			Label:       "Asset Type",
+			Description: "Tag of the asset type whose assets will be migrated.",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "pageSize",
+			Label:       "Page Size",
+			Description: "Maximum number of assets visited by this call. Defaults to 100.",
+			DataType:    "integer",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		assetTypeTag, _ := req["assetType"].(string)
+
+		pageSize := defaultMigrationPageSize
+		if pageSizeInt, ok := req["pageSize"].(int64); ok {
+			pageSize = int(pageSizeInt)
+		}
+
+		checkpoint, err := assets.MigrateAssets(stub, assetTypeTag, pageSize)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to migrate assets")
+		}
+
+		checkpointJSON, nerr := json.Marshal(checkpoint)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "failed to marshal response", 500)
+		}
+
+		return checkpointJSON, nil
+	},
+}
@@ -0,0 +1,352 @@
+package transactions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/events"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification followed by OpenAPI
+const OpenAPIVersion = "3.1.0"
+
+// errorResponses maps the ICCError status codes to reusable OpenAPI responses
+var errorResponses = map[int]struct {
+	name        string
+	description string
+}{
+	400: {"BadRequest", "Invalid request or arguments"},
+	403: {"Forbidden", "Caller is not allowed to run the transaction"},
+	404: {"NotFound", "Asset not found"},
+	409: {"Conflict", "Asset already exists"},
+	500: {"InternalError", "Internal chaincode error"},
+}
+
+// assetTypeOperations are the txs exposed on the paths of each asset type, along with the path suffix
+var assetTypeOperations = []struct {
+	tx     string
+	suffix string
+}{
+	{"createAsset", ""},
+	{"readAsset", ""},
+	{"updateAsset", ""},
+	{"deleteAsset", ""},
+	{"readAssetHistory", "/history"},
+}
+
+// OpenAPI returns an OpenAPI 3.1 document describing the HTTP API of the chaincode.
+// Every tx is exposed on /api/invoke/<tx> (or /api/query/<tx> if read only), with the request
+// as the JSON body or, for GET methods, as the JSON encoded "@request" query parameter.
+// The asset CRUD txs are also exposed for each asset type on /api/assets/<asset type>.
+// Operations carry the tx tag in the "x-cc-tools-tx" extension and events are described as webhooks.
+func OpenAPI() map[string]interface{} {
+	defs := map[string]interface{}{}
+
+	paths := map[string]interface{}{}
+	for _, tx := range TxList() {
+		path := "/api/invoke/" + tx.Tag
+		if tx.ReadOnly {
+			path = "/api/query/" + tx.Tag
+		}
+		method, operation := openAPIOperation(tx, tx.Tag, tx.Args.jsonSchema("", defs))
+		paths[path] = map[string]interface{}{
+			method: operation,
+		}
+	}
+
+	for _, assetType := range assets.AssetTypeList() {
+		for _, op := range assetTypeOperations {
+			tx := FetchTx(op.tx)
+			if tx == nil {
+				continue
+			}
+
+			path := "/api/assets/" + assetType.Tag + op.suffix
+			pathItem, exists := paths[path].(map[string]interface{})
+			if !exists {
+				pathItem = map[string]interface{}{}
+				paths[path] = pathItem
+			}
+
+			operationID := fmt.Sprintf("%s.%s", assetType.Tag, tx.Tag)
+			method, operation := openAPIOperation(*tx, operationID, tx.Args.jsonSchema(assetType.Tag, defs))
+			if _, taken := pathItem[method]; taken {
+				continue
+			}
+			operation["summary"] = fmt.Sprintf("%s (%s)", tx.Label, assetType.Label)
+			operation["tags"] = []string{assetType.Tag}
+			pathItem[method] = operation
+		}
+	}
+
+	for _, assetType := range assets.AssetTypeList() {
+		assets.AssetJSONSchema(assetType.Tag, defs)
+	}
+	for name := range assets.DataTypeMap() {
+		if strings.HasPrefix(name, "->") {
+			continue
+		}
+		defs["dataType."+name] = assets.DataTypeJSONSchema(name, defs)
+	}
+	defs["@error"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status":  map[string]interface{}{"type": "integer"},
+			"message": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"status", "message"},
+	}
+
+	// Component names may only have letters, digits, dots, hyphens and underscores
+	schemas := map[string]interface{}{}
+	for name, def := range defs {
+		schemas[componentName(name)] = def
+	}
+
+	responses := map[string]interface{}{}
+	for _, response := range errorResponses {
+		responses[response.name] = map[string]interface{}{
+			"description": response.description,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{"$ref": "#/$defs/@error"},
+				},
+			},
+		}
+	}
+
+	webhooks := map[string]interface{}{}
+	for _, event := range events.EventList() {
+		webhooks[event.Tag] = map[string]interface{}{
+			"post": openAPIWebhook(event),
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi":           OpenAPIVersion,
+		"jsonSchemaDialect": assets.JSONSchemaDialect,
+		"info": map[string]interface{}{
+			"title":              header.Name,
+			"version":            header.Version,
+			"x-cc-tools-version": header.CCToolsVersion,
+		},
+		"paths":    paths,
+		"webhooks": webhooks,
+		"components": map[string]interface{}{
+			"schemas":   schemas,
+			"responses": responses,
+			"securitySchemes": map[string]interface{}{
+				"fabricIdentity": map[string]interface{}{
+					"type":        "mutualTLS",
+					"description": "Fabric identity of the caller. Scopes are the MSP (msp:<MSP>), OU (ou:<OU>) and attributes (attr:<name>=<value>) it must have, where MSPs starting with '$' are regular expressions.",
+				},
+			},
+		},
+	}
+
+	return rewriteRefs(doc).(map[string]interface{})
+}
+
+// openAPIOperation returns the HTTP method and the OpenAPI operation of a tx with the given request schema
+func openAPIOperation(tx Transaction, operationID string, requestSchema map[string]interface{}) (string, map[string]interface{}) {
+	method := strings.ToLower(tx.Method)
+	if method == "" {
+		method = "post"
+	}
+
+	tag := "transactions"
+	if tx.MetaTx {
+		tag = "meta"
+	}
+
+	operation := map[string]interface{}{
+		"operationId":   operationID,
+		"summary":       tx.Label,
+		"tags":          []string{tag},
+		"x-cc-tools-tx": tx.Tag,
+	}
+	if tx.Description != "" {
+		operation["description"] = tx.Description
+	}
+
+	content := map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": requestSchema,
+		},
+	}
+	if method == "get" {
+		operation["parameters"] = []interface{}{
+			map[string]interface{}{
+				"name":     "@request",
+				"in":       "query",
+				"required": len(requestSchema["required"].([]string)) > 0,
+				"content":  content,
+			},
+		}
+	} else {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  content,
+		}
+	}
+
+	responses := map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "Transaction response",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{},
+				},
+			},
+		},
+	}
+	for _, status := range txErrorStatuses(tx) {
+		responses[fmt.Sprintf("%d", status)] = map[string]interface{}{
+			"$ref": "#/components/responses/" + errorResponses[status].name,
+		}
+	}
+	operation["responses"] = responses
+
+	if len(tx.Callers) > 0 {
+		security := []interface{}{}
+		for _, caller := range tx.Callers {
+			security = append(security, map[string]interface{}{
+				"fabricIdentity": callerScopes(caller),
+			})
+		}
+		operation["security"] = security
+	}
+
+	return method, operation
+}
+
+// txErrorStatuses returns the ICCError status codes a tx is expected to return
+func txErrorStatuses(tx Transaction) []int {
+	statuses := []int{400}
+	if len(tx.Callers) > 0 || !tx.ReadOnly {
+		statuses = append(statuses, 403)
+	}
+
+	var readsKeys, writesAssets bool
+	for _, arg := range tx.Args {
+		dataType := strings.TrimPrefix(arg.DataType, "[]")
+		switch {
+		case dataType == "@key" || dataType == "@update" || strings.HasPrefix(dataType, "->"):
+			readsKeys = true
+		case dataType == "@asset":
+			writesAssets = !tx.ReadOnly
+		}
+	}
+	if readsKeys {
+		statuses = append(statuses, 404)
+	}
+	if writesAssets {
+		statuses = append(statuses, 409)
+	}
+
+	return append(statuses, 500)
+}
+
+// callerScopes renders a caller as the scopes of a security requirement
+func callerScopes(caller accesscontrol.Caller) []string {
+	scopes := []string{}
+	if caller.MSP != "" {
+		scopes = append(scopes, "msp:"+caller.MSP)
+	}
+	if caller.OU != "" {
+		scopes = append(scopes, "ou:"+caller.OU)
+	}
+
+	attrs := make([]string, 0, len(caller.Attributes))
+	for name, value := range caller.Attributes {
+		attrs = append(attrs, fmt.Sprintf("attr:%s=%s", name, value))
+	}
+	sort.Strings(attrs)
+
+	return append(scopes, attrs...)
+}
+
+// openAPIWebhook describes an event as the operation receiving it
+func openAPIWebhook(event events.Event) map[string]interface{} {
+	eventTypes := map[events.EventType]string{
+		events.EventLog:         "log",
+		events.EventTransaction: "transaction",
+		events.EventCustom:      "custom",
+	}
+
+	eventInfo := map[string]interface{}{
+		"type": eventTypes[event.Type],
+	}
+	if len(event.Receivers) > 0 {
+		eventInfo["receivers"] = event.Receivers
+	}
+	if event.Type == events.EventTransaction {
+		eventInfo["transaction"] = event.Transaction
+		if event.Channel != "" {
+			eventInfo["channel"] = event.Channel
+		}
+		if event.Chaincode != "" {
+			eventInfo["chaincode"] = event.Chaincode
+		}
+	}
+
+	webhook := map[string]interface{}{
+		"operationId": "event." + event.Tag,
+		"summary":     event.Label,
+		"requestBody": map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{},
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Event received",
+			},
+		},
+		"x-cc-tools-event": eventInfo,
+	}
+	if event.Description != "" {
+		webhook["description"] = event.Description
+	}
+
+	return webhook
+}
+
+// invalidComponentChars matches the characters not allowed in the names of OpenAPI components
+var invalidComponentChars = regexp.MustCompile(`[^a-zA-Z0-9.\-_]`)
+
+// componentName returns the name of the component of a JSON Schema definition. The "@" of
+// internal names is dropped, so "@error" is named "error" and "dataType.@object" is named
+// "dataType.object", and any other character not allowed in component names becomes "_".
+func componentName(def string) string {
+	name := strings.ReplaceAll(def, "@", "")
+	return invalidComponentChars.ReplaceAllString(name, "_")
+}
+
+// rewriteRefs points the JSON Schema references to $defs at the document components
+func rewriteRefs(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range value {
+			if ref, isString := elem.(string); isString && k == "$ref" {
+				if def := strings.TrimPrefix(ref, "#/$defs/"); def != ref {
+					def = strings.NewReplacer("~1", "/", "~0", "~").Replace(def)
+					value[k] = "#/components/schemas/" + componentName(def)
+				}
+				continue
+			}
+			value[k] = rewriteRefs(elem)
+		}
+	case []interface{}:
+		for i, elem := range value {
+			value[i] = rewriteRefs(elem)
+		}
+	}
+	return v
+}
@@ -51,10 +51,21 @@ var ReadAsset = Transaction{
 				return nil, errors.WrapErrorWithStatus(err, "failed to serialize asset", 500)
 			}
 		} else {
-			assetJSON, err = key.GetBytes(stub)
+			var asset *assets.Asset
+			asset, err = key.Get(stub)
 			if err != nil {
 				return nil, errors.WrapError(err, "failed to get asset state")
 			}
+
+			err = assets.Redact(stub, *asset)
+			if err != nil {
+				return nil, errors.WrapError(err, "failed to redact asset")
+			}
+
+			assetJSON, err = json.Marshal(asset)
+			if err != nil {
+				return nil, errors.WrapErrorWithStatus(err, "failed to serialize asset", 500)
+			}
 		}
 
 		return assetJSON, nil
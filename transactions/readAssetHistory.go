@@ -65,6 +65,11 @@ var ReadAssetHistory = Transaction{
 					if err != nil {
 						return nil, errors.WrapError(err, "failed to unmarshal queryResponse's values")
 					}
+
+					redactErr := assets.Redact(stub, data)
+					if redactErr != nil {
+						return nil, errors.WrapError(redactErr, "failed to redact history entry")
+					}
 				}
 				data["_txId"] = queryResponse.TxId
 				data["_isDelete"] = queryResponse.IsDelete
@@ -101,6 +106,11 @@ var ReadAssetHistory = Transaction{
 						if err != nil {
 							return nil, errors.WrapError(err, "failed to unmarshal queryResponse's values")
 						}
+
+						redactErr := assets.Redact(stub, response)
+						if redactErr != nil {
+							return nil, errors.WrapError(redactErr, "failed to redact history entry")
+						}
 					}
 					response["_txId"] = queryResponse.TxId
 					response["_isDelete"] = queryResponse.IsDelete
@@ -2,6 +2,7 @@ package transactions
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/assets"
@@ -32,22 +33,99 @@ func Run(stub shim.ChaincodeStubInterface) ([]byte, errors.ICCError) {
 		Stub: stub,
 	}
 
+	response, runErr := tx.run(sw, reqMap)
+	if runErr != nil {
+		// The events raised before the failure are not emitted, since the tx is not committed
+		if discarded := sw.DiscardEvents(); discarded > 0 {
+			log.Printf("tx %s failed, discarding %d raised events: %s", txName, discarded, runErr.Message())
+		}
+		return nil, runErr
+	}
+
+	return response, nil
+}
+
+// run checks the permissions of the caller and runs the routine of the tx, flushing
+// the writes and events of the transaction if it succeeds.
+func (tx Transaction) run(stub *sw.StubWrapper, reqMap map[string]interface{}) ([]byte, errors.ICCError) {
 	if assets.GetEnabledDynamicAssetType() {
-		err := assets.RestoreAssetList(sw, false)
+		err := assets.RestoreAssetList(stub, false)
 		if err != nil {
 			return nil, errors.WrapError(err, "failed to restore asset list")
 		}
 	}
 
+	// Verify if tx requires approval
+	quorumErr := tx.checkQuorum()
+	if quorumErr != nil {
+		return nil, quorumErr
+	}
+
 	// Verify callers permissions
+	permErr := tx.checkCallers(stub.Stub)
+	if permErr != nil {
+		return nil, permErr
+	}
+
+	// Verify request specific permissions
+	authErr := tx.authorize(stub, reqMap)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	response, routineErr := tx.Routine(stub, reqMap)
+	if routineErr != nil {
+		return nil, routineErr
+	}
+
+	// Send the writes deferred by savepoints to the stub and set the events
+	// raised by the routine in a single envelope event
+	flushErr := stub.Flush()
+	if flushErr != nil {
+		return nil, errors.WrapError(flushErr, "failed to flush transaction")
+	}
+
+	return response, nil
+}
+
+// authorize runs the Authorize function of the tx, if any, with the parsed request.
+func (tx Transaction) authorize(stub *sw.StubWrapper, reqMap map[string]interface{}) errors.ICCError {
+	if tx.Authorize == nil {
+		return nil
+	}
+
+	caller, err := accesscontrol.GetIdentity(stub.Stub)
+	if err != nil {
+		return errors.WrapError(err, "failed to get caller identity")
+	}
+
+	authErr := tx.Authorize(stub, reqMap, caller)
+	if authErr != nil {
+		return errors.WrapErrorWithStatus(authErr, "caller not authorized", 403)
+	}
+
+	return nil
+}
+
+// checkCallers verifies if the current caller is allowed to run the tx.
+func (tx Transaction) checkCallers(stub shim.ChaincodeStubInterface) errors.ICCError {
 	callPermission, err := accesscontrol.AllowCaller(stub, tx.Callers)
 	if err != nil {
-		return nil, errors.WrapError(err, "failed to check permissions")
+		return errors.WrapError(err, "failed to check permissions")
 	}
 
 	if !callPermission {
-		return nil, errors.NewCCError("current caller not allowed", 403)
+		return errors.NewCCError("current caller not allowed", 403)
+	}
+
+	policyPermission, err := accesscontrol.CheckPolicy(stub, tx.Policy)
+	if err != nil {
+		return errors.WrapError(err, "failed to check policy")
+	}
+
+	if !policyPermission {
+		return errors.NewCCError(fmt.Sprintf("current caller not allowed by policy %s", tx.Policy), 403)
 	}
 
-	return tx.Routine(sw, reqMap)
+	return nil
 }
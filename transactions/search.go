@@ -47,7 +47,7 @@ var Search = Transaction{
 
 		response, err := assets.Search(stub, query, privateCollection, resolve)
 		if err != nil {
-			return nil, errors.WrapErrorWithStatus(err, "query error", 500)
+			return nil, errors.WrapError(err, "query error")
 		}
 
 		responseJSON, err := json.Marshal(response)
@@ -5,8 +5,10 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/assets"
 	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/events"
 )
 
 // StartupCheck verifies if tx definitions are properly coded, returning an error if they're not.
@@ -26,31 +28,94 @@ func StartupCheck() errors.ICCError {
 			}
 		}
 
-		argSet := map[string]interface{}{}
-		for _, arg := range tx.Args {
-			if _, duplicate := argSet[arg.Tag]; duplicate {
-				return errors.NewCCError(fmt.Sprintf("duplicate arg tag %s in tx %s", arg.Tag, txName), 500)
+		if tx.Quorum != nil {
+			if tx.Quorum.Approvals < 1 {
+				return errors.NewCCError(fmt.Sprintf("quorum of tx %s must require at least one approval", txName), 500)
 			}
-			argSet[arg.Tag] = struct{}{}
-
-			dtype := strings.TrimPrefix(arg.DataType, "[]")
-			if dtype != "@asset" &&
-				dtype != "@key" &&
-				dtype != "@update" &&
-				dtype != "@query" &&
-				dtype != "@object" {
-				if strings.HasPrefix(dtype, "->") {
-					dtype = strings.TrimPrefix(dtype, "->")
-					if assets.FetchAssetType(dtype) == nil && dtype != "@asset" {
-						return errors.NewCCError(fmt.Sprintf("invalid arg type %s in tx %s", arg.DataType, txName), 500)
-					}
-				} else {
-					if assets.FetchDataType(dtype) == nil {
-						return errors.NewCCError(fmt.Sprintf("invalid arg type %s in tx %s", arg.DataType, txName), 500)
+			if len(tx.Quorum.Approvers) == 0 {
+				return errors.NewCCError(fmt.Sprintf("quorum of tx %s has no approvers", txName), 500)
+			}
+			for _, c := range tx.Quorum.Approvers {
+				if len(c.MSP) > 1 && c.MSP[0] == '$' {
+					_, err := regexp.Compile(c.MSP[1:])
+					if err != nil {
+						return errors.WrapErrorWithStatus(err, fmt.Sprintf("invalid approver msp regular expression %s for tx %s", c.MSP, txName), 500)
 					}
 				}
 			}
 		}
+
+		if txName == MigrateAssets.Tag && len(tx.Callers) == 0 && tx.Policy == "" {
+			return errors.NewCCError(fmt.Sprintf("tx %s must restrict its callers", txName), 500)
+		}
+
+		if tx.Policy != "" && accesscontrol.FetchPolicy(tx.Policy) == nil {
+			return errors.NewCCError(fmt.Sprintf("policy %s of tx %s is not registered", tx.Policy, txName), 500)
+		}
+
+		err := checkArgList(tx.Args, "tx "+txName)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, event := range events.EventList() {
+		if event.Policy != "" && accesscontrol.FetchPolicy(event.Policy) == nil {
+			return errors.NewCCError(fmt.Sprintf("policy %s of event %s is not registered", event.Policy, event.Tag), 500)
+		}
+		if event.Type == events.EventTransaction {
+			if event.Transaction == "" {
+				return errors.NewCCError(fmt.Sprintf("event %s of type EventTransaction must have a transaction", event.Tag), 500)
+			}
+			if event.IsLocal("") && FetchTx(event.Transaction) == nil {
+				return errors.NewCCError(fmt.Sprintf("tx %s of event %s does not exist", event.Transaction, event.Tag), 500)
+			}
+			if event.Channel != "" && len(event.Receivers) > 0 {
+				return errors.NewCCError(fmt.Sprintf("event %s with receivers cannot relay tx %s to channel %s", event.Tag, event.Transaction, event.Channel), 500)
+			}
+		}
+		for _, receiver := range event.Receivers {
+			if _, isSingleMSP := events.ReceiverMSP(receiver); !isSingleMSP {
+				return errors.NewCCError(fmt.Sprintf("receiver %s of event %s must match a single msp", receiver, event.Tag), 500)
+			}
+		}
+
+		err := checkArgList(eventArgList(event), "event "+event.Tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkArgList verifies if the args have unique tags and valid data types.
+func checkArgList(args ArgList, owner string) errors.ICCError {
+	argSet := map[string]interface{}{}
+	for _, arg := range args {
+		if _, duplicate := argSet[arg.Tag]; duplicate {
+			return errors.NewCCError(fmt.Sprintf("duplicate arg tag %s in %s", arg.Tag, owner), 500)
+		}
+		argSet[arg.Tag] = struct{}{}
+
+		dtype := strings.TrimPrefix(arg.DataType, "[]")
+		if dtype != "@asset" &&
+			dtype != "@key" &&
+			dtype != "@update" &&
+			dtype != "@query" &&
+			dtype != "@object" {
+			if strings.HasPrefix(dtype, "->") {
+				dtype = strings.TrimPrefix(dtype, "->")
+				if assets.FetchAssetType(dtype) == nil && dtype != "@asset" {
+					return errors.NewCCError(fmt.Sprintf("invalid arg type %s in %s", arg.DataType, owner), 500)
+				}
+			} else {
+				if assets.FetchDataType(dtype) == nil {
+					return errors.NewCCError(fmt.Sprintf("invalid arg type %s in %s", arg.DataType, owner), 500)
+				}
+			}
+		}
 	}
+
 	return nil
 }
@@ -14,6 +14,10 @@ type Transaction struct {
 	// read by unauthorized organizations, this should be done with Private Data.
 	Callers []accesscontrol.Caller `json:"callers,omitempty"`
 
+	// Policy is the name of a policy registered with accesscontrol.RegisterPolicy
+	// which callers must also satisfy to run this transaction.
+	Policy string `json:"policy,omitempty"`
+
 	// Tag is how the tx will be called.
 	Tag string `json:"tag"`
 
@@ -36,6 +40,16 @@ type Transaction struct {
 	// but an internal process of the chaincode e.g. listing available asset types.
 	MetaTx bool `json:"metaTx"`
 
+	// Quorum is the set of organizations which must approve the tx before it runs. Txs
+	// with a quorum cannot be called directly, they run through proposeTx and approveTx.
+	Quorum *Quorum `json:"quorum,omitempty"`
+
+	// Authorize is an optional function called with the parsed request and the identity of the
+	// caller after the args are validated and before the routine runs. It returns an error with
+	// the reason to deny the request, e.g. when an amount requires an approver, which is always
+	// returned with status 403.
+	Authorize func(*sw.StubWrapper, map[string]interface{}, *accesscontrol.Identity) errors.ICCError `json:"-"`
+
 	// Routine is the function called when running the tx. It is where the tx logic can be programmed.
 	Routine func(*sw.StubWrapper, map[string]interface{}) ([]byte, errors.ICCError) `json:"-"`
 }
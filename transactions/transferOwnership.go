@@ -0,0 +1,56 @@
+package transactions
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+)
+
+// TransferOwnership records a new owner for an asset of an owned asset type
+var TransferOwnership = Transaction{
+	Tag:         "transferOwnership",
+	Label:       "Transfer Ownership",
+	Description: "TransferOwnership records a new owner for an asset of an owned asset type",
+	Method:      "PUT",
+
+	MetaTx: true,
+	Args: ArgList{
+		{
+			Tag:         "key",
+			Description: "Key of the asset to be transferred.",
+			DataType:    "@key",
+			Required:    true,
+		},
+		{
+			Tag:         "owner",
+			Label:       "New Owner",
+			Description: "Identity of the new owner, with its 'msp' and 'id'.",
+			DataType:    "@object",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		// This is safe to do because validation is done before calling routine
+		key := req["key"].(assets.Key)
+		owner := req["owner"].(map[string]interface{})
+
+		response, err := key.TransferOwnership(stub, owner)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to transfer ownership")
+		}
+
+		err = assets.Redact(stub, response)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to redact asset")
+		}
+
+		resBytes, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "failed to marshal response", 500)
+		}
+
+		return resBytes, nil
+	},
+}
@@ -3,6 +3,9 @@ package transactions
 import (
 	"github.com/hyperledger-labs/cc-tools/accesscontrol"
 	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	"github.com/hyperledger-labs/cc-tools/events"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
 )
 
 var txList = []Transaction{}
@@ -11,6 +14,8 @@ var basicTxs = []Transaction{
 	getTx,
 	GetHeader,
 	GetSchema,
+	GetJSONSchema,
+	GetOpenAPI,
 	GetDataTypes,
 	GetEvents,
 	ExecuteEvent,
@@ -63,4 +68,15 @@ func InitTxList(l []Transaction) {
 		}
 		txList = append(txList, dynamicAssetTypesTxs...)
 	}
+	initTxProposals()
+	events.SetTxRunner(runEventTx)
+}
+
+// runEventTx runs a tx triggered by an event of type EventTransaction as part of the current
+// transaction, with the same checks as the operations of a batch.
+func runEventTx(stub *sw.StubWrapper, txName string, args map[string]interface{}) ([]byte, errors.ICCError) {
+	return runBatchOperation(stub, map[string]interface{}{
+		"tx":   txName,
+		"args": args,
+	})
 }
@@ -0,0 +1,440 @@
+package transactions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger-labs/cc-tools/accesscontrol"
+	"github.com/hyperledger-labs/cc-tools/assets"
+	"github.com/hyperledger-labs/cc-tools/errors"
+	sw "github.com/hyperledger-labs/cc-tools/stubwrapper"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Quorum defines the organizations which must approve a transaction before it runs.
+type Quorum struct {
+	// Approvers are the callers which can vote on the proposals of the transaction.
+	// Each organization has a single vote.
+	Approvers []accesscontrol.Caller `json:"approvers"`
+
+	// Approvals is the number of organizations which must approve a proposal for the transaction to run.
+	Approvals int `json:"approvals"`
+
+	// Rejections is the number of organizations which must reject a proposal for it to be
+	// discarded. Defaults to 1.
+	Rejections int `json:"rejections,omitempty"`
+
+	// Expiry is how long proposals accept votes. Proposals do not expire when it is zero.
+	Expiry time.Duration `json:"expiry,omitempty"`
+}
+
+// Proposal statuses
+const (
+	ProposalPending  = "pending"
+	ProposalExecuted = "executed"
+	ProposalFailed   = "failed"
+	ProposalRejected = "rejected"
+	ProposalExpired  = "expired"
+)
+
+// proposalTxs are the transactions included in the tx list when some tx requires approval
+var proposalTxs = []Transaction{
+	ProposeTx,
+	ApproveTx,
+	RejectTx,
+}
+
+// ProposeTx stores a proposal to run a transaction which requires approval. Since proposals are
+// public, their args cannot have private args nor data of private collections.
+var ProposeTx = Transaction{
+	Tag:         "proposeTx",
+	Label:       "Propose Transaction",
+	Description: "ProposeTx stores a proposal to run a transaction once it is approved by a quorum of organizations",
+	Method:      "POST",
+
+	MetaTx: true,
+	Args: ArgList{
+		{
+			Tag:         "txName",
+			Label:       "Transaction",
+			Description: "Tag of the transaction to be run.",
+			DataType:    "string",
+			Required:    true,
+		},
+		{
+			Tag:         "args",
+			Label:       "Arguments",
+			Description: "Arguments of the transaction.",
+			DataType:    "@object",
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		txName := req["txName"].(string)
+		args, ok := req["args"].(map[string]interface{})
+		if !ok {
+			args = map[string]interface{}{}
+		}
+
+		tx := FetchTx(txName)
+		if tx == nil {
+			return nil, errors.NewCCError(fmt.Sprintf("tx named %s does not exist", txName), 400)
+		}
+		if tx.Quorum == nil {
+			return nil, errors.NewCCError(fmt.Sprintf("tx %s does not require approval", txName), 400)
+		}
+
+		// The proposer must be allowed to request the tx
+		reqMap, err := tx.validateArgs(args, nil)
+		if err != nil {
+			return nil, errors.WrapError(err, "unable to get args")
+		}
+		err = tx.checkPublicArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.checkCallers(stub.Stub)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.authorize(stub, reqMap)
+		if err != nil {
+			return nil, err
+		}
+
+		proposer, nerr := accesscontrol.GetIdentity(stub.Stub)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "failed to get caller identity", 500)
+		}
+		txTimestamp, nerr := stub.Stub.GetTxTimestamp()
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "failed to get tx timestamp", 500)
+		}
+
+		proposal := map[string]interface{}{
+			"@assetType": "txProposal",
+			"id":         stub.Stub.GetTxID(),
+			"txName":     txName,
+			"args":       args,
+			"proposer":   proposer.MSP,
+			"approvals":  []interface{}{},
+			"rejections": []interface{}{},
+			"status":     ProposalPending,
+		}
+		if tx.Quorum.Expiry > 0 {
+			proposal["expiresAt"] = txTimestamp.AsTime().Add(tx.Quorum.Expiry).Format(time.RFC3339Nano)
+		}
+
+		// Proposing counts as the approval of the proposer organization, if it is an approver
+		isApprover, nerr := accesscontrol.AllowCaller(stub.Stub, tx.Quorum.Approvers)
+		if nerr != nil {
+			return nil, errors.WrapError(nerr, "failed to check approvers")
+		}
+		if isApprover {
+			proposal["approvals"] = []interface{}{proposer.MSP}
+			err = tx.executeIfApproved(stub, proposal)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		proposalAsset, err := assets.NewAsset(proposal)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to create proposal")
+		}
+		response, err := proposalAsset.PutNew(stub)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to write proposal")
+		}
+
+		responseJSON, nerr := json.Marshal(response)
+		if nerr != nil {
+			return nil, errors.WrapErrorWithStatus(nerr, "failed to marshal response", 500)
+		}
+
+		return responseJSON, nil
+	},
+}
+
+// ApproveTx records the approval of a proposal by the caller organization
+var ApproveTx = Transaction{
+	Tag:         "approveTx",
+	Label:       "Approve Transaction",
+	Description: "ApproveTx records the approval of a proposal, running its transaction when the quorum is reached",
+	Method:      "PUT",
+
+	MetaTx: true,
+	Args: ArgList{
+		{
+			Tag:         "proposal",
+			Label:       "Proposal",
+			Description: "Key of the proposal.",
+			DataType:    "->txProposal",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		return voteOnProposal(stub, req["proposal"].(assets.Key), true)
+	},
+}
+
+// RejectTx records the rejection of a proposal by the caller organization
+var RejectTx = Transaction{
+	Tag:         "rejectTx",
+	Label:       "Reject Transaction",
+	Description: "RejectTx records the rejection of a proposal, discarding it when enough organizations reject it",
+	Method:      "PUT",
+
+	MetaTx: true,
+	Args: ArgList{
+		{
+			Tag:         "proposal",
+			Label:       "Proposal",
+			Description: "Key of the proposal.",
+			DataType:    "->txProposal",
+			Required:    true,
+		},
+	},
+	Routine: func(stub *sw.StubWrapper, req map[string]interface{}) ([]byte, errors.ICCError) {
+		return voteOnProposal(stub, req["proposal"].(assets.Key), false)
+	},
+}
+
+// voteOnProposal records the vote of the caller organization on a pending proposal.
+func voteOnProposal(stub *sw.StubWrapper, key assets.Key, approve bool) ([]byte, errors.ICCError) {
+	proposal, err := key.GetMap(stub)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to read proposal")
+	}
+
+	if status := proposal["status"]; status != ProposalPending {
+		return nil, errors.NewCCError(fmt.Sprintf("proposal is %s", status), 409)
+	}
+
+	txName, _ := proposal["txName"].(string)
+	tx := FetchTx(txName)
+	if tx == nil || tx.Quorum == nil {
+		return nil, errors.NewCCError(fmt.Sprintf("tx %s of the proposal does not require approval", txName), 400)
+	}
+
+	voter, nerr := accesscontrol.GetIdentity(stub.Stub)
+	if nerr != nil {
+		return nil, errors.WrapErrorWithStatus(nerr, "failed to get caller identity", 500)
+	}
+	isApprover, nerr := accesscontrol.AllowCaller(stub.Stub, tx.Quorum.Approvers)
+	if nerr != nil {
+		return nil, errors.WrapError(nerr, "failed to check approvers")
+	}
+	if !isApprover {
+		return nil, errors.NewCCError(fmt.Sprintf("%s cannot vote on proposals of tx %s", voter.MSP, txName), 403)
+	}
+
+	approvals, _ := proposal["approvals"].([]interface{})
+	rejections, _ := proposal["rejections"].([]interface{})
+	for _, org := range append(append([]interface{}{}, approvals...), rejections...) {
+		if org == voter.MSP {
+			return nil, errors.NewCCError(fmt.Sprintf("%s has already voted on the proposal", voter.MSP), 409)
+		}
+	}
+
+	// Votes arriving after the proposal expired are not counted and mark it as expired
+	expired, err := proposalExpired(stub, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	if expired {
+		proposal["status"] = ProposalExpired
+	} else if approve {
+		proposal["approvals"] = append(approvals, voter.MSP)
+		err = tx.executeIfApproved(stub, proposal)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rejections = append(rejections, voter.MSP)
+		proposal["rejections"] = rejections
+
+		rejectionThreshold := tx.Quorum.Rejections
+		if rejectionThreshold < 1 {
+			rejectionThreshold = 1
+		}
+		if len(rejections) >= rejectionThreshold {
+			proposal["status"] = ProposalRejected
+		}
+	}
+
+	update := map[string]interface{}{}
+	for _, prop := range []string{"approvals", "rejections", "status", "result"} {
+		update[prop] = proposal[prop]
+	}
+	response, err := key.Update(stub, update)
+	if err != nil {
+		return nil, errors.WrapError(err, "failed to update proposal")
+	}
+
+	responseJSON, nerr := json.Marshal(response)
+	if nerr != nil {
+		return nil, errors.WrapErrorWithStatus(nerr, "failed to marshal response", 500)
+	}
+
+	return responseJSON, nil
+}
+
+// proposalExpired returns true if the proposal has an expiry which is before the tx timestamp.
+func proposalExpired(stub *sw.StubWrapper, proposal map[string]interface{}) (bool, errors.ICCError) {
+	expiresAt, ok := proposal["expiresAt"].(string)
+	if !ok {
+		return false, nil
+	}
+
+	expiryTime, err := time.Parse(time.RFC3339Nano, expiresAt)
+	if err != nil {
+		return false, errors.WrapErrorWithStatus(err, "invalid proposal expiry", 500)
+	}
+	txTimestamp, err := stub.Stub.GetTxTimestamp()
+	if err != nil {
+		return false, errors.WrapErrorWithStatus(err, "failed to get tx timestamp", 500)
+	}
+
+	return txTimestamp.AsTime().After(expiryTime), nil
+}
+
+// executeIfApproved runs the proposed tx once the proposal reaches the quorum, recording
+// its result in the proposal. The writes of failed txs are discarded.
+//
+// The tx runs within the transaction of the last approval, so its routine sees the identity of
+// the organization casting it rather than the proposer's. Callers and authorization policies
+// are checked again against that identity, and the proposal fails if they do not allow it.
+func (tx Transaction) executeIfApproved(stub *sw.StubWrapper, proposal map[string]interface{}) errors.ICCError {
+	approvals, _ := proposal["approvals"].([]interface{})
+	if len(approvals) < tx.Quorum.Approvals {
+		return nil
+	}
+
+	args, _ := proposal["args"].(map[string]interface{})
+
+	savepoint := stub.Savepoint()
+	response, err := func() ([]byte, errors.ICCError) {
+		reqMap, err := tx.validateArgs(args, nil)
+		if err != nil {
+			return nil, errors.WrapError(err, "unable to get args")
+		}
+		err = tx.checkCallers(stub.Stub)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.authorize(stub, reqMap)
+		if err != nil {
+			return nil, err
+		}
+		return tx.Routine(stub, reqMap)
+	}()
+	if err != nil {
+		rollbackErr := stub.RollbackTo(savepoint)
+		if rollbackErr != nil {
+			return errors.WrapError(rollbackErr, fmt.Sprintf("failed to discard writes of tx %s", tx.Tag))
+		}
+
+		proposal["status"] = ProposalFailed
+		proposal["result"] = err.Message()
+		return nil
+	}
+
+	proposal["status"] = ProposalExecuted
+	proposal["result"] = string(response)
+	return nil
+}
+
+// checkPublicArgs returns a 400 error if the args of a proposal have private data, since proposals
+// are stored on the public ledger. These are the private args of the tx, assets of private types and
+// the props of hybrid types stored in private collections.
+func (tx Transaction) checkPublicArgs(args map[string]interface{}) errors.ICCError {
+	for _, argDef := range tx.Args {
+		if _, included := args[argDef.Tag]; included && argDef.Private {
+			return errors.NewCCError(fmt.Sprintf("proposals of tx %s cannot have private arg %s", tx.Tag, argDef.Tag), 400)
+		}
+	}
+
+	return checkPublicValue(tx.Tag, args)
+}
+
+// checkPublicValue looks for assets with private data in an arg value, including nested assets.
+func checkPublicValue(txName string, value interface{}) errors.ICCError {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			err := checkPublicValue(txName, elem)
+			if err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		assetTypeTag, _ := v["@assetType"].(string)
+		if assetTypeDef := assets.FetchAssetType(assetTypeTag); assetTypeDef != nil {
+			if assetTypeDef.IsPrivate() {
+				return errors.NewCCError(fmt.Sprintf("proposals of tx %s cannot have assets of private asset type %s", txName, assetTypeTag), 400)
+			}
+			for _, prop := range assetTypeDef.Props {
+				if _, included := v[prop.Tag]; included && prop.Collection != "" {
+					return errors.NewCCError(fmt.Sprintf("proposals of tx %s cannot have prop %s of asset type %s stored in collection %s", txName, prop.Tag, assetTypeTag, prop.Collection), 400)
+				}
+			}
+		}
+
+		// Keys are sorted so the error is the same on every peer
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			err := checkPublicValue(txName, v[k])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkQuorum returns a 403 error if the tx requires approval, so it can only run through proposals.
+func (tx Transaction) checkQuorum() errors.ICCError {
+	if tx.Quorum != nil {
+		return errors.NewCCError(fmt.Sprintf("tx %s requires approval, propose it with %s", tx.Tag, ProposeTx.Tag), 403)
+	}
+
+	return nil
+}
+
+// initTxProposals includes the proposal txs and asset type when some tx requires approval.
+func initTxProposals() {
+	enabled := false
+	for _, tx := range txList {
+		if tx.Quorum != nil {
+			enabled = true
+			break
+		}
+	}
+
+	if enabled {
+		txList = append(txList, proposalTxs...)
+		if accesscontrol.FetchPolicy(assets.TxProposalPolicy) == nil {
+			_ = accesscontrol.RegisterPolicy(assets.TxProposalPolicy, accesscontrol.PolicyFunc(isProposalTx))
+		}
+	}
+	assets.InitTxProposals(enabled)
+}
+
+// isProposalTx is the policy which only allows the proposal txs to write proposals.
+func isProposalTx(stub shim.ChaincodeStubInterface) (bool, error) {
+	txName, _ := stub.GetFunctionAndParameters()
+	for _, tx := range proposalTxs {
+		if tx.Tag == txName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
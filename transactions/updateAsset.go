@@ -44,6 +44,11 @@ var UpdateAsset = Transaction{
 			return nil, errors.WrapError(err, "failed to update asset")
 		}
 
+		err = assets.Redact(stub, response)
+		if err != nil {
+			return nil, errors.WrapError(err, "failed to redact asset")
+		}
+
 		resBytes, err := json.Marshal(response)
 		if err != nil {
 			return nil, errors.WrapError(err, "failed to marshal response")